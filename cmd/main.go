@@ -4,15 +4,22 @@ import (
 	"context"
 	"errors"
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/viper"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/thinhpq0112/soa-backend/config"
 	_ "github.com/thinhpq0112/soa-backend/docs"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/health"
+	"github.com/thinhpq0112/soa-backend/internal/logging"
+	"github.com/thinhpq0112/soa-backend/internal/metrics"
 	"github.com/thinhpq0112/soa-backend/internal/middleware"
 	"github.com/thinhpq0112/soa-backend/internal/repository"
 	"github.com/thinhpq0112/soa-backend/internal/service"
+	"github.com/thinhpq0112/soa-backend/internal/tracing"
 	"github.com/thinhpq0112/soa-backend/internal/transport"
+	"github.com/thinhpq0112/soa-backend/internal/util"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"log"
 	"net/http"
 	"os"
@@ -22,46 +29,176 @@ import (
 )
 
 func main() {
-	loadConfig()
+	cfg, args, err := config.Load(os.Args[1:])
+	if config.IsHelp(err) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	logging.Setup(cfg.Log)
 
-	db, err := config.NewConn()
+	db, err := config.NewConn(cfg.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sqlDb, err := db.DB()
 	if err != nil {
 		log.Fatal(err)
 	}
-	productRepo := repository.NewProductRepo(db)
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(sqlDb, args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if cfg.DB.AutoMigrate {
+		if err := migrateOnStartup(sqlDb); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	db.Logger = logging.NewGormLogger()
+	if err := db.Use(metrics.GormPlugin{}); err != nil {
+		log.Fatal(err)
+	}
+	metrics.RegisterDBStats(sqlDb, cfg.DB.Name)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		log.Fatal(err)
+	}
+
+	stockWatch := repository.NewStockWatch()
+	productRepo := repository.NewProductRepo(db, stockWatch)
 	categoryRepo := repository.NewCategoryRepo(db)
 	supplierRepo := repository.NewSupplierRepo(db)
-
-	productService := service.NewProductService(productRepo)
+	apiKeyRepo := repository.NewAPIKeyRepo(db)
+	idempotencyRepo := repository.NewIdempotencyRepo(db)
+	auditRepo := repository.NewAuditRepo(db)
+	inventoryRepo := repository.NewInventoryRepo(db, stockWatch)
+	warehouseRepo := repository.NewWarehouseRepo(db)
+	reservationRepo := repository.NewReservationRepo(db, stockWatch)
+	alertRepo := repository.NewAlertRepo(db)
+	priceRepo := repository.NewPriceRepo(db)
+
+	productService := service.NewProductService(productRepo, categoryRepo, supplierRepo)
 	categoryService := service.NewCategoryService(categoryRepo)
 	supplierService := service.NewSupplierService(supplierRepo)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	idempotencyService := service.NewIdempotencyService(idempotencyRepo, cfg.Idempotency.TTL)
+	trashService := service.NewTrashService(productRepo, categoryRepo, supplierRepo, cfg.Trash.Retention)
+	auditService := service.NewAuditService(auditRepo)
+	inventoryService := service.NewInventoryService(inventoryRepo)
+	warehouseService := service.NewWarehouseService(warehouseRepo)
+	reservationService := service.NewReservationService(reservationRepo, cfg.Reservation.DefaultTTL, cfg.Reservation.MaxTTL)
+	var alertNotifier service.AlertNotifier
+	if cfg.Alerts.WebhookURL != "" {
+		alertNotifier = service.NewWebhookNotifier(cfg.Alerts.WebhookURL, cfg.Alerts.WebhookTimeout)
+	}
+	alertService := service.NewAlertService(alertRepo, alertNotifier)
+	priceService := service.NewPriceService(priceRepo)
+
+	verifier, err := auth.NewVerifier(cfg.Auth)
+	if err != nil {
+		log.Fatal(err)
+	}
+	policy := auth.DefaultPolicy()
+	if cfg.Auth.RBACPolicyFile != "" {
+		policy, err = auth.LoadPolicy(cfg.Auth.RBACPolicyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	authz := middleware.NewAuthorizer(policy)
 
 	//router := gin.Default()
 	router := gin.New()
+	router.ContextWithFallback = true
 	router.Use(gin.Recovery())
+	router.Use(otelgin.Middleware(cfg.Tracing.ServiceName, otelgin.WithGinFilter(tracing.SkipInfraRoutes)))
+	router.Use(middleware.RequestIDMiddleware())
 	router.Use(middleware.LogMiddleWare())
+	router.Use(middleware.MetricsMiddleware())
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	geocoder := util.NewGeocoder(cfg.Geo)
+	checker := health.NewChecker(sqlDb)
+	checker.Register(health.Check{Name: "geocoder", Fn: geocoder.Check, CacheTTL: time.Minute})
+	healthHandler := transport.NewHealthHandler(checker)
+	healthHandler.RegisterRoutes(router)
+
+	if cfg.Auth.PublicSwagger {
+		router.GET("/api/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	}
 
 	api := router.Group("/api/")
-	api.Use(middleware.AuthMiddleware())
-	api.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	productHandler := transport.NewProductHandler(productService)
-	productHandler.RegisterRoutes(api)
+	api.Use(middleware.AuthMiddleware(verifier, apiKeyService))
+	if !cfg.Auth.PublicSwagger {
+		api.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	}
+	// Catalog creates may be retried with an Idempotency-Key. API key routes
+	// are left out: their responses carry secrets that must not be stored.
+	catalog := api.Group("")
+	catalog.Use(middleware.IdempotencyMiddleware(idempotencyService))
+
+	productHandler := transport.NewProductHandler(productService, authz)
+	productHandler.RegisterRoutes(catalog)
+
+	categoryHandler := transport.NewCategoryHandler(categoryService, authz)
+	categoryHandler.RegisterRoutes(catalog)
+
+	supplierHandler := transport.NewSupplierHandler(supplierService, authz)
+	supplierHandler.RegisterRoutes(catalog)
 
-	categoryHandler := transport.NewCategoryHandler(categoryService)
-	categoryHandler.RegisterRoutes(api)
+	inventoryHandler := transport.NewInventoryHandler(inventoryService, authz)
+	inventoryHandler.RegisterRoutes(catalog)
 
-	supplierHandler := transport.NewSupplierHandler(supplierService)
-	supplierHandler.RegisterRoutes(api)
+	warehouseHandler := transport.NewWarehouseHandler(warehouseService, authz)
+	warehouseHandler.RegisterRoutes(catalog)
 
-	distanceService := service.NewDistanceService()
+	reservationHandler := transport.NewReservationHandler(reservationService, authz)
+	reservationHandler.RegisterRoutes(catalog)
+
+	priceHandler := transport.NewPriceHandler(priceService, authz)
+	priceHandler.RegisterRoutes(catalog)
+
+	apiKeyHandler := transport.NewAPIKeyHandler(apiKeyService, authz)
+	apiKeyHandler.RegisterRoutes(api)
+
+	trashHandler := transport.NewTrashHandler(trashService, authz)
+	trashHandler.RegisterRoutes(api)
+
+	auditHandler := transport.NewAuditHandler(auditService, authz)
+	auditHandler.RegisterRoutes(api)
+
+	alertHandler := transport.NewAlertHandler(alertService, authz)
+	alertHandler.RegisterRoutes(api)
+
+	distanceService := service.NewDistanceService(geocoder)
 	distanceHandler := transport.NewDistanceHandler(distanceService)
 	distanceHandler.RegisterRoutes(api)
 
 	srv := &http.Server{
-		Addr:    ":8080",
-		Handler: router.Handler(),
+		Addr:         cfg.Server.Addr,
+		Handler:      router.Handler(),
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go metrics.RefreshBusinessGauges(backgroundCtx, cfg.Metrics.RefreshInterval, productRepo.GetInventoryTotals)
+	go service.PurgeExpiredIdempotencyKeys(backgroundCtx, cfg.Idempotency.PurgeInterval, idempotencyService)
+	go service.SweepExpiredReservations(backgroundCtx, cfg.Reservation.SweepInterval, reservationService)
+	go service.EvaluateStockAlerts(backgroundCtx, cfg.Alerts.EvaluateInterval, alertService, stockWatch)
+	go service.ApplyScheduledPrices(backgroundCtx, cfg.Prices.ApplyInterval, priceService)
+
 	go func() {
 		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("listen: %s\n", err)
@@ -72,26 +209,23 @@ func main() {
 
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	log.Printf("Draining for %s ...", cfg.Server.DrainDelay)
+	checker.Drain()
+	stopBackground()
+	time.Sleep(cfg.Server.DrainDelay)
 	log.Println("Shutdown Server ...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 	if err := srv.Shutdown(ctx); err != nil {
 		log.Fatal("Server Shutdown:", err)
 	}
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("Tracing shutdown: %s", err)
+	}
 	select {
 	case <-ctx.Done():
-		log.Println("timeout of 5 seconds.")
+		log.Printf("timeout of %s.", cfg.Server.ShutdownTimeout)
 	}
 	log.Println("Server exiting")
 }
-
-func loadConfig() {
-	viper.SetConfigFile(".env")
-	viper.SetConfigType("env")
-	viper.AutomaticEnv()
-
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatalf("Error reading .env file: %s", err)
-	}
-}
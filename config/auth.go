@@ -0,0 +1,11 @@
+package config
+
+type AuthConfig struct {
+	Issuer           string
+	Audience         string
+	HMACSecret       string
+	RSAPublicKeyFile string
+	JWKSFile         string
+	PublicSwagger    bool
+	RBACPolicyFile   string
+}
@@ -0,0 +1,421 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	Server      ServerConfig
+	DB          DBConfig
+	Log         LogConfig
+	Auth        AuthConfig
+	Geo         GeoConfig
+	Metrics     MetricsConfig
+	Tracing     TracingConfig
+	Idempotency IdempotencyConfig
+	Trash       TrashConfig
+	Reservation ReservationConfig
+	Alerts      AlertsConfig
+	Prices      PricesConfig
+}
+
+type ServerConfig struct {
+	Addr            string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	// DrainDelay is how long /readyz reports not-ready before shutdown
+	// starts, giving load balancers time to stop sending traffic.
+	DrainDelay time.Duration
+}
+
+type DBConfig struct {
+	Host            string
+	Port            string
+	Name            string
+	User            string
+	Password        string
+	SSLMode         string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	AutoMigrate     bool
+}
+
+const (
+	LogFormatJSON    = "json"
+	LogFormatConsole = "console"
+)
+
+type LogConfig struct {
+	Level  string
+	Format string
+}
+
+type MetricsConfig struct {
+	// RefreshInterval is how often business gauges such as the product
+	// count are recomputed.
+	RefreshInterval time.Duration
+}
+
+type TracingConfig struct {
+	// Exporter is one of none, otlp, stdout or file.
+	Exporter     string
+	ServiceName  string
+	OTLPEndpoint string
+	File         string
+	// SampleRatio is the fraction of new traces that are recorded; requests
+	// arriving with a sampled traceparent are always recorded.
+	SampleRatio float64
+}
+
+type IdempotencyConfig struct {
+	// TTL is how long a stored response is replayed for retries.
+	TTL           time.Duration
+	PurgeInterval time.Duration
+}
+
+type TrashConfig struct {
+	// Retention is how long deleted items stay restorable before the admin
+	// purge removes them for good.
+	Retention time.Duration
+}
+
+type ReservationConfig struct {
+	// DefaultTTL is how long a reservation holds stock when the request
+	// does not say; no request may ask for more than MaxTTL.
+	DefaultTTL    time.Duration
+	MaxTTL        time.Duration
+	SweepInterval time.Duration
+}
+
+type AlertsConfig struct {
+	// EvaluateInterval is how often products are checked against their
+	// reorder points besides after each write to their stock.
+	EvaluateInterval time.Duration
+	// WebhookURL, when set, receives every new alert as a JSON POST.
+	WebhookURL     string
+	WebhookTimeout time.Duration
+}
+
+type PricesConfig struct {
+	// ApplyInterval is how often products are switched to the scheduled
+	// prices that have taken effect.
+	ApplyInterval time.Duration
+}
+
+type GeoConfig struct {
+	IPLookupURL   string
+	CityLookupURL string
+	UserAgent     string
+	Timeout       time.Duration
+}
+
+var defaults = map[string]interface{}{
+	"SERVER_ADDR":             ":8080",
+	"SERVER_READ_TIMEOUT":     "15s",
+	"SERVER_WRITE_TIMEOUT":    "60s",
+	"SERVER_IDLE_TIMEOUT":     "120s",
+	"SERVER_SHUTDOWN_TIMEOUT": "5s",
+	"SERVER_DRAIN_DELAY":      "5s",
+
+	"DB_PORT":              "5432",
+	"DB_SSLMODE":           "disable",
+	"DB_MAX_OPEN_CONNS":    10,
+	"DB_MAX_IDLE_CONNS":    5,
+	"DB_CONN_MAX_LIFETIME": "5m",
+	"DB_AUTO_MIGRATE":      false,
+
+	"LOG_LEVEL":  "info",
+	"LOG_FORMAT": LogFormatConsole,
+
+	"AUTH_PUBLIC_SWAGGER": true,
+
+	"GEO_IP_LOOKUP_URL":   "http://ip-api.com/json/",
+	"GEO_CITY_LOOKUP_URL": "https://nominatim.openstreetmap.org/search",
+	"GEO_USER_AGENT":      "soa-backend",
+	"GEO_TIMEOUT":         "5s",
+
+	"METRICS_REFRESH_INTERVAL": "30s",
+
+	"TRACING_EXPORTER":      "none",
+	"TRACING_SERVICE_NAME":  "soa-backend",
+	"TRACING_OTLP_ENDPOINT": "http://localhost:4318",
+	"TRACING_FILE":          "traces.json",
+	"TRACING_SAMPLE_RATIO":  1.0,
+
+	"IDEMPOTENCY_TTL":            "24h",
+	"IDEMPOTENCY_PURGE_INTERVAL": "1h",
+
+	"TRASH_RETENTION": "720h",
+
+	"RESERVATION_DEFAULT_TTL":    "15m",
+	"RESERVATION_MAX_TTL":        "24h",
+	"RESERVATION_SWEEP_INTERVAL": "1m",
+
+	"ALERTS_EVALUATE_INTERVAL": "1m",
+	"ALERTS_WEBHOOK_TIMEOUT":   "5s",
+
+	"PRICES_APPLY_INTERVAL": "1m",
+}
+
+// flags maps command-line flags to the configuration keys they override.
+var flags = []struct {
+	name, key, usage string
+}{
+	{"addr", "SERVER_ADDR", "listen address"},
+	{"log-level", "LOG_LEVEL", "log level (trace, debug, info, warn, error)"},
+	{"log-format", "LOG_FORMAT", "log format (json, console)"},
+	{"db-host", "DB_HOST", "database host"},
+	{"db-port", "DB_PORT", "database port"},
+	{"db-name", "DB_NAME", "database name"},
+	{"db-user", "DB_USERNAME", "database user"},
+	{"db-sslmode", "DB_SSLMODE", "database sslmode"},
+	{"db-max-open-conns", "DB_MAX_OPEN_CONNS", "maximum open database connections"},
+	{"db-max-idle-conns", "DB_MAX_IDLE_CONNS", "maximum idle database connections"},
+	{"db-conn-max-lifetime", "DB_CONN_MAX_LIFETIME", "maximum lifetime of a database connection"},
+	{"db-auto-migrate", "DB_AUTO_MIGRATE", "apply pending migrations at startup"},
+	{"tracing-exporter", "TRACING_EXPORTER", "trace exporter (none, otlp, stdout, file)"},
+}
+
+var tracingExporters = map[string]bool{
+	"none": true, "otlp": true, "stdout": true, "file": true,
+}
+
+var sslModes = map[string]bool{
+	"disable": true, "allow": true, "prefer": true,
+	"require": true, "verify-ca": true, "verify-full": true,
+}
+
+// Load builds the configuration from, in increasing precedence, built-in
+// defaults, an optional config file (--config or CONFIG_FILE, falling back
+// to .env when present), environment variables and command-line flags. It
+// returns the positional arguments left after flag parsing.
+func Load(args []string) (*Config, []string, error) {
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+
+	fs := pflag.NewFlagSet("soa-backend", pflag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a .env, yaml or json config file")
+	for _, f := range flags {
+		fs.String(f.name, "", f.usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		v.SetConfigFile(*configFile)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, nil, fmt.Errorf("read config file: %w", err)
+		}
+	} else if _, err := os.Stat(".env"); err == nil {
+		v.SetConfigFile(".env")
+		v.SetConfigType("env")
+		if err := v.ReadInConfig(); err != nil {
+			return nil, nil, fmt.Errorf("read .env file: %w", err)
+		}
+	}
+
+	v.AutomaticEnv()
+
+	for _, f := range flags {
+		if fl := fs.Lookup(f.name); fl.Changed {
+			v.Set(f.key, fl.Value.String())
+		}
+	}
+
+	cfg := &Config{
+		Server: ServerConfig{
+			Addr:            v.GetString("SERVER_ADDR"),
+			ReadTimeout:     v.GetDuration("SERVER_READ_TIMEOUT"),
+			WriteTimeout:    v.GetDuration("SERVER_WRITE_TIMEOUT"),
+			IdleTimeout:     v.GetDuration("SERVER_IDLE_TIMEOUT"),
+			ShutdownTimeout: v.GetDuration("SERVER_SHUTDOWN_TIMEOUT"),
+			DrainDelay:      v.GetDuration("SERVER_DRAIN_DELAY"),
+		},
+		DB: DBConfig{
+			Host:            v.GetString("DB_HOST"),
+			Port:            v.GetString("DB_PORT"),
+			Name:            v.GetString("DB_NAME"),
+			User:            v.GetString("DB_USERNAME"),
+			Password:        v.GetString("DB_PASSWORD"),
+			SSLMode:         v.GetString("DB_SSLMODE"),
+			MaxOpenConns:    v.GetInt("DB_MAX_OPEN_CONNS"),
+			MaxIdleConns:    v.GetInt("DB_MAX_IDLE_CONNS"),
+			ConnMaxLifetime: v.GetDuration("DB_CONN_MAX_LIFETIME"),
+			AutoMigrate:     v.GetBool("DB_AUTO_MIGRATE"),
+		},
+		Log: LogConfig{
+			Level:  v.GetString("LOG_LEVEL"),
+			Format: v.GetString("LOG_FORMAT"),
+		},
+		Auth: AuthConfig{
+			Issuer:           v.GetString("AUTH_ISSUER"),
+			Audience:         v.GetString("AUTH_AUDIENCE"),
+			HMACSecret:       v.GetString("AUTH_HS256_SECRET"),
+			RSAPublicKeyFile: v.GetString("AUTH_RS256_PUBLIC_KEY_FILE"),
+			JWKSFile:         v.GetString("AUTH_JWKS_FILE"),
+			PublicSwagger:    v.GetBool("AUTH_PUBLIC_SWAGGER"),
+			RBACPolicyFile:   v.GetString("AUTH_RBAC_POLICY_FILE"),
+		},
+		Geo: GeoConfig{
+			IPLookupURL:   v.GetString("GEO_IP_LOOKUP_URL"),
+			CityLookupURL: v.GetString("GEO_CITY_LOOKUP_URL"),
+			UserAgent:     v.GetString("GEO_USER_AGENT"),
+			Timeout:       v.GetDuration("GEO_TIMEOUT"),
+		},
+		Metrics: MetricsConfig{
+			RefreshInterval: v.GetDuration("METRICS_REFRESH_INTERVAL"),
+		},
+		Tracing: TracingConfig{
+			Exporter:     v.GetString("TRACING_EXPORTER"),
+			ServiceName:  v.GetString("TRACING_SERVICE_NAME"),
+			OTLPEndpoint: v.GetString("TRACING_OTLP_ENDPOINT"),
+			File:         v.GetString("TRACING_FILE"),
+			SampleRatio:  v.GetFloat64("TRACING_SAMPLE_RATIO"),
+		},
+		Idempotency: IdempotencyConfig{
+			TTL:           v.GetDuration("IDEMPOTENCY_TTL"),
+			PurgeInterval: v.GetDuration("IDEMPOTENCY_PURGE_INTERVAL"),
+		},
+		Trash: TrashConfig{
+			Retention: v.GetDuration("TRASH_RETENTION"),
+		},
+		Reservation: ReservationConfig{
+			DefaultTTL:    v.GetDuration("RESERVATION_DEFAULT_TTL"),
+			MaxTTL:        v.GetDuration("RESERVATION_MAX_TTL"),
+			SweepInterval: v.GetDuration("RESERVATION_SWEEP_INTERVAL"),
+		},
+		Alerts: AlertsConfig{
+			EvaluateInterval: v.GetDuration("ALERTS_EVALUATE_INTERVAL"),
+			WebhookURL:       v.GetString("ALERTS_WEBHOOK_URL"),
+			WebhookTimeout:   v.GetDuration("ALERTS_WEBHOOK_TIMEOUT"),
+		},
+		Prices: PricesConfig{
+			ApplyInterval: v.GetDuration("PRICES_APPLY_INTERVAL"),
+		},
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, err
+	}
+	return cfg, fs.Args(), nil
+}
+
+// ValidationError lists every problem found in a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func (c *Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "SERVER_ADDR is required")
+	check(c.Server.ReadTimeout > 0, "SERVER_READ_TIMEOUT must be a positive duration")
+	check(c.Server.WriteTimeout > 0, "SERVER_WRITE_TIMEOUT must be a positive duration")
+	check(c.Server.IdleTimeout > 0, "SERVER_IDLE_TIMEOUT must be a positive duration")
+	check(c.Server.ShutdownTimeout > 0, "SERVER_SHUTDOWN_TIMEOUT must be a positive duration")
+	check(c.Server.DrainDelay >= 0, "SERVER_DRAIN_DELAY must not be negative")
+
+	check(c.DB.Host != "", "DB_HOST is required")
+	check(c.DB.Name != "", "DB_NAME is required")
+	check(c.DB.User != "", "DB_USERNAME is required")
+	check(c.DB.Password != "", "DB_PASSWORD is required")
+	port, err := strconv.Atoi(c.DB.Port)
+	check(err == nil && port > 0 && port < 65536, "DB_PORT must be a port number, got %q", c.DB.Port)
+	check(sslModes[c.DB.SSLMode], "DB_SSLMODE %q is not a valid PostgreSQL sslmode", c.DB.SSLMode)
+	check(c.DB.MaxOpenConns > 0, "DB_MAX_OPEN_CONNS must be positive")
+	check(c.DB.MaxIdleConns >= 0 && c.DB.MaxIdleConns <= c.DB.MaxOpenConns,
+		"DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS")
+	check(c.DB.ConnMaxLifetime > 0, "DB_CONN_MAX_LIFETIME must be a positive duration")
+
+	_, err = zerolog.ParseLevel(c.Log.Level)
+	check(err == nil && c.Log.Level != "", "LOG_LEVEL %q is not a valid level", c.Log.Level)
+	check(c.Log.Format == LogFormatJSON || c.Log.Format == LogFormatConsole,
+		"LOG_FORMAT %q must be json or console", c.Log.Format)
+
+	check(c.Auth.HMACSecret != "" || c.Auth.RSAPublicKeyFile != "" || c.Auth.JWKSFile != "",
+		"one of AUTH_HS256_SECRET, AUTH_RS256_PUBLIC_KEY_FILE or AUTH_JWKS_FILE is required")
+	for _, file := range [][2]string{
+		{"AUTH_RS256_PUBLIC_KEY_FILE", c.Auth.RSAPublicKeyFile},
+		{"AUTH_JWKS_FILE", c.Auth.JWKSFile},
+		{"AUTH_RBAC_POLICY_FILE", c.Auth.RBACPolicyFile},
+	} {
+		if file[1] != "" {
+			_, err := os.Stat(file[1])
+			check(err == nil, "%s: %v", file[0], err)
+		}
+	}
+
+	for _, endpoint := range [][2]string{
+		{"GEO_IP_LOOKUP_URL", c.Geo.IPLookupURL},
+		{"GEO_CITY_LOOKUP_URL", c.Geo.CityLookupURL},
+	} {
+		u, err := url.Parse(endpoint[1])
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"%s must be an absolute http(s) URL, got %q", endpoint[0], endpoint[1])
+	}
+	check(c.Geo.Timeout > 0, "GEO_TIMEOUT must be a positive duration")
+	check(c.Metrics.RefreshInterval > 0, "METRICS_REFRESH_INTERVAL must be a positive duration")
+
+	check(tracingExporters[c.Tracing.Exporter], "TRACING_EXPORTER %q must be one of none, otlp, stdout or file", c.Tracing.Exporter)
+	check(c.Tracing.ServiceName != "", "TRACING_SERVICE_NAME is required")
+	if c.Tracing.Exporter == "otlp" {
+		u, err := url.Parse(c.Tracing.OTLPEndpoint)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"TRACING_OTLP_ENDPOINT must be an absolute http(s) URL, got %q", c.Tracing.OTLPEndpoint)
+	}
+	check(c.Tracing.Exporter != "file" || c.Tracing.File != "", "TRACING_FILE is required for the file exporter")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "TRACING_SAMPLE_RATIO must be between 0 and 1")
+
+	check(c.Idempotency.TTL > 0, "IDEMPOTENCY_TTL must be a positive duration")
+	check(c.Idempotency.PurgeInterval > 0, "IDEMPOTENCY_PURGE_INTERVAL must be a positive duration")
+
+	check(c.Trash.Retention > 0, "TRASH_RETENTION must be a positive duration")
+
+	check(c.Reservation.DefaultTTL > 0 && c.Reservation.DefaultTTL <= c.Reservation.MaxTTL,
+		"RESERVATION_DEFAULT_TTL must be positive and at most RESERVATION_MAX_TTL")
+	check(c.Reservation.SweepInterval > 0, "RESERVATION_SWEEP_INTERVAL must be a positive duration")
+
+	check(c.Alerts.EvaluateInterval > 0, "ALERTS_EVALUATE_INTERVAL must be a positive duration")
+	if c.Alerts.WebhookURL != "" {
+		u, err := url.Parse(c.Alerts.WebhookURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"ALERTS_WEBHOOK_URL must be an absolute http(s) URL, got %q", c.Alerts.WebhookURL)
+	}
+	check(c.Alerts.WebhookTimeout > 0, "ALERTS_WEBHOOK_TIMEOUT must be a positive duration")
+
+	check(c.Prices.ApplyInterval > 0, "PRICES_APPLY_INTERVAL must be a positive duration")
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// IsHelp reports whether err came from a --help flag.
+func IsHelp(err error) bool {
+	return errors.Is(err, pflag.ErrHelp)
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setRequiredEnv(t *testing.T) {
+	t.Setenv("DB_HOST", "localhost")
+	t.Setenv("DB_NAME", "soa")
+	t.Setenv("DB_USERNAME", "soa")
+	t.Setenv("DB_PASSWORD", "secret")
+	t.Setenv("AUTH_HS256_SECRET", "jwt-secret")
+}
+
+func TestLoadDefaults(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, args, err := Load(nil)
+	require.NoError(t, err)
+	assert.Empty(t, args)
+	assert.Equal(t, ":8080", cfg.Server.Addr)
+	assert.Equal(t, 10, cfg.DB.MaxOpenConns)
+	assert.Equal(t, 5, cfg.DB.MaxIdleConns)
+	assert.Equal(t, 5*time.Minute, cfg.DB.ConnMaxLifetime)
+	assert.Equal(t, "disable", cfg.DB.SSLMode)
+	assert.True(t, cfg.Auth.PublicSwagger)
+	assert.Equal(t, 24*time.Hour, cfg.Idempotency.TTL)
+	assert.Equal(t, 30*24*time.Hour, cfg.Trash.Retention)
+	assert.Equal(t, 15*time.Minute, cfg.Reservation.DefaultTTL)
+}
+
+func TestLoadLayers(t *testing.T) {
+	setRequiredEnv(t)
+
+	file := filepath.Join(t.TempDir(), "app.env")
+	require.NoError(t, os.WriteFile(file, []byte("SERVER_ADDR=:7000\nDB_MAX_OPEN_CONNS=20\nLOG_LEVEL=debug\n"), 0o600))
+	t.Setenv("DB_MAX_OPEN_CONNS", "30")
+
+	cfg, args, err := Load([]string{"--config", file, "migrate", "up", "--addr", ":9090"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"migrate", "up"}, args)
+	assert.Equal(t, ":9090", cfg.Server.Addr, "flags override the file")
+	assert.Equal(t, 30, cfg.DB.MaxOpenConns, "env overrides the file")
+	assert.Equal(t, "debug", cfg.Log.Level, "file overrides defaults")
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	t.Setenv("DB_PORT", "not-a-port")
+	t.Setenv("DB_SSLMODE", "sometimes")
+	t.Setenv("DB_MAX_IDLE_CONNS", "50")
+	t.Setenv("LOG_LEVEL", "loud")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("TRACING_EXPORTER", "jaeger")
+	t.Setenv("ALERTS_WEBHOOK_URL", "hooks.example.com/stock")
+
+	_, _, err := Load(nil)
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, validationErr.Problems, "DB_HOST is required")
+	assert.Contains(t, validationErr.Problems, `DB_PORT must be a port number, got "not-a-port"`)
+	assert.Contains(t, validationErr.Problems, `DB_SSLMODE "sometimes" is not a valid PostgreSQL sslmode`)
+	assert.Contains(t, validationErr.Problems, "DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS")
+	assert.Contains(t, validationErr.Problems, `LOG_LEVEL "loud" is not a valid level`)
+	assert.Contains(t, validationErr.Problems, `LOG_FORMAT "xml" must be json or console`)
+	assert.Contains(t, validationErr.Problems, `TRACING_EXPORTER "jaeger" must be one of none, otlp, stdout or file`)
+	assert.Contains(t, validationErr.Problems, `ALERTS_WEBHOOK_URL must be an absolute http(s) URL, got "hooks.example.com/stock"`)
+	assert.Contains(t, validationErr.Problems, "one of AUTH_HS256_SECRET, AUTH_RS256_PUBLIC_KEY_FILE or AUTH_JWKS_FILE is required")
+}
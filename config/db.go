@@ -4,35 +4,22 @@ import (
 	"database/sql"
 	"fmt"
 	_ "github.com/lib/pq"
-	"github.com/spf13/viper"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"log"
-	"time"
 )
 
 var db *gorm.DB
 
-func NewConn() (*gorm.DB, error) {
-	host := viper.GetString("DB_HOST")
-	port := viper.GetString("DB_PORT")
-	user := viper.GetString("DB_USERNAME")
-	password := viper.GetString("DB_PASSWORD")
-	dbname := viper.GetString("DB_NAME")
-
-	if host == "" || port == "" || user == "" || password == "" || dbname == "" {
-		log.Fatal("Database environment variables are missing or empty")
-	}
-
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		host, port, user, password, dbname)
+func NewConn(cfg DBConfig) (*gorm.DB, error) {
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Name, cfg.SSLMode)
 	sqlDb, err := sql.Open("postgres", dsn)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	sqlDb.SetMaxOpenConns(10)
-	sqlDb.SetMaxIdleConns(5)
-	sqlDb.SetConnMaxLifetime(5 * time.Minute)
+	sqlDb.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDb.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDb.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 	err = sqlDb.Ping()
 	if err != nil {
 		return nil, err
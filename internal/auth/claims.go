@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"context"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type Claims struct {
+	Roles  []string `json:"roles"`
+	Tenant string   `json:"tenant"`
+	// Scopes are permissions granted directly rather than through roles.
+	// They are only set for API key callers, never parsed from a JWT.
+	Scopes []string `json:"-"`
+	jwt.RegisteredClaims
+}
+
+type claimsKey struct{}
+
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
+
+// SystemActor is recorded for changes made outside of an authenticated
+// request.
+const SystemActor = "system"
+
+// Actor names the caller ctx belongs to, as recorded with the changes it
+// makes: who deleted an item, the audit log and the stock ledger.
+func Actor(ctx context.Context) string {
+	if claims, ok := FromContext(ctx); ok && claims.Subject != "" {
+		return claims.Subject
+	}
+	return SystemActor
+}
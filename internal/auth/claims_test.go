@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActor(t *testing.T) {
+	alice := NewContext(context.Background(), &Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "alice"}})
+	anonymous := NewContext(context.Background(), &Claims{})
+
+	assert.Equal(t, "alice", Actor(alice))
+	assert.Equal(t, SystemActor, Actor(anonymous))
+	assert.Equal(t, SystemActor, Actor(context.Background()))
+}
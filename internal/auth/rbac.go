@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type Permission string
+
+const (
+	PermProductRead      Permission = "products:read"
+	PermProductWrite     Permission = "products:write"
+	PermProductDelete    Permission = "products:delete"
+	PermCategoryRead     Permission = "categories:read"
+	PermCategoryWrite    Permission = "categories:write"
+	PermCategoryDelete   Permission = "categories:delete"
+	PermSupplierRead     Permission = "suppliers:read"
+	PermSupplierWrite    Permission = "suppliers:write"
+	PermSupplierDelete   Permission = "suppliers:delete"
+	PermWarehouseRead    Permission = "warehouses:read"
+	PermWarehouseWrite   Permission = "warehouses:write"
+	PermWarehouseDelete  Permission = "warehouses:delete"
+	PermReservationRead  Permission = "reservations:read"
+	PermReservationWrite Permission = "reservations:write"
+	PermAlertRead        Permission = "alerts:read"
+	PermAlertWrite       Permission = "alerts:write"
+	PermStatisticsRead   Permission = "statistics:read"
+	PermReportExport     Permission = "reports:export"
+	PermAPIKeyManage     Permission = "apikeys:manage"
+	PermTrashPurge       Permission = "trash:purge"
+	PermAuditRead        Permission = "audit:read"
+)
+
+var permissions = []Permission{
+	PermProductRead, PermProductWrite, PermProductDelete,
+	PermCategoryRead, PermCategoryWrite, PermCategoryDelete,
+	PermSupplierRead, PermSupplierWrite, PermSupplierDelete,
+	PermWarehouseRead, PermWarehouseWrite, PermWarehouseDelete,
+	PermReservationRead, PermReservationWrite,
+	PermAlertRead, PermAlertWrite,
+	PermStatisticsRead, PermReportExport, PermAPIKeyManage,
+	PermTrashPurge, PermAuditRead,
+}
+
+// Policy maps role names to the permissions they grant. A granted
+// permission of "*" matches everything and "resource:*" matches every
+// action on that resource.
+type Policy struct {
+	roles map[string][]Permission
+}
+
+func NewPolicy(roles map[string][]Permission) *Policy {
+	return &Policy{roles: roles}
+}
+
+func DefaultPolicy() *Policy {
+	viewer := []Permission{PermProductRead, PermCategoryRead, PermSupplierRead, PermWarehouseRead, PermReservationRead, PermAlertRead}
+	editor := append([]Permission{
+		PermProductWrite, PermCategoryWrite, PermSupplierWrite, PermWarehouseWrite, PermReservationWrite, PermAlertWrite,
+		PermStatisticsRead, PermReportExport,
+	}, viewer...)
+
+	return NewPolicy(map[string][]Permission{
+		"viewer": viewer,
+		"editor": editor,
+		"admin":  {"*"},
+	})
+}
+
+type policyFile struct {
+	Roles map[string][]Permission `json:"roles"`
+}
+
+// LoadPolicy reads a JSON document of the form
+// {"roles": {"viewer": ["products:read"], "admin": ["*"]}}.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rbac policy: %w", err)
+	}
+
+	var file policyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse rbac policy: %w", err)
+	}
+	if len(file.Roles) == 0 {
+		return nil, fmt.Errorf("rbac policy %s defines no roles", path)
+	}
+	return NewPolicy(file.Roles), nil
+}
+
+func (p *Policy) Allows(roles []string, perm Permission) bool {
+	for _, role := range roles {
+		for _, granted := range p.roles[role] {
+			if granted.matches(perm) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ScopesAllow reports whether permissions granted directly to the caller,
+// such as API key scopes, cover perm.
+func ScopesAllow(scopes []string, perm Permission) bool {
+	for _, scope := range scopes {
+		if Permission(scope).matches(perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsKnownPermission reports whether p names a permission, or a wildcard
+// covering at least one, that routes can require.
+func IsKnownPermission(p Permission) bool {
+	for _, known := range permissions {
+		if p.matches(known) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Permission) matches(required Permission) bool {
+	if p == "*" || p == required {
+		return true
+	}
+	resource, ok := strings.CutSuffix(string(p), ":*")
+	return ok && strings.HasPrefix(string(required), resource+":")
+}
@@ -0,0 +1,39 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	policy := DefaultPolicy()
+
+	assert.True(t, policy.Allows([]string{"viewer"}, PermProductRead))
+	assert.False(t, policy.Allows([]string{"viewer"}, PermProductWrite))
+	assert.False(t, policy.Allows([]string{"viewer"}, PermStatisticsRead))
+
+	assert.True(t, policy.Allows([]string{"editor"}, PermProductWrite))
+	assert.True(t, policy.Allows([]string{"editor"}, PermReportExport))
+	assert.False(t, policy.Allows([]string{"editor"}, PermProductDelete))
+
+	assert.True(t, policy.Allows([]string{"admin"}, PermSupplierDelete))
+	assert.True(t, policy.Allows([]string{"unknown", "viewer"}, PermCategoryRead))
+	assert.False(t, policy.Allows(nil, PermCategoryRead))
+}
+
+func TestLoadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rbac.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"roles": {"auditor": ["statistics:read", "products:*"]}}`), 0o600))
+
+	policy, err := LoadPolicy(path)
+	require.NoError(t, err)
+
+	assert.True(t, policy.Allows([]string{"auditor"}, PermStatisticsRead))
+	assert.True(t, policy.Allows([]string{"auditor"}, PermProductDelete))
+	assert.False(t, policy.Allows([]string{"auditor"}, PermCategoryRead))
+	assert.False(t, policy.Allows([]string{"viewer"}, PermProductRead))
+}
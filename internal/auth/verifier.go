@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/thinhpq0112/soa-backend/config"
+	"os"
+)
+
+var (
+	ErrNoKeysConfigured = errors.New("auth: no HS256 secret, RS256 public key or JWKS file configured")
+	ErrUnknownKey       = errors.New("auth: no key matches the token")
+	ErrInvalidAPIKey    = errors.New("auth: invalid or expired api key")
+)
+
+type verificationKey struct {
+	kid  string
+	hmac []byte
+	rsa  *rsa.PublicKey
+}
+
+// Verifier validates HS256 and RS256 bearer tokens against the keys from
+// config.AuthConfig. Tokens carrying a "kid" header must match a JWKS key
+// with that id; tokens without one are checked against the keys that have
+// no id.
+type Verifier struct {
+	byKid   map[string]verificationKey
+	hmacs   [][]byte
+	rsaKeys []*rsa.PublicKey
+	parser  *jwt.Parser
+}
+
+func NewVerifier(cfg config.AuthConfig) (*Verifier, error) {
+	var keys []verificationKey
+
+	if cfg.HMACSecret != "" {
+		keys = append(keys, verificationKey{hmac: []byte(cfg.HMACSecret)})
+	}
+
+	if cfg.RSAPublicKeyFile != "" {
+		pem, err := os.ReadFile(cfg.RSAPublicKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("read rsa public key: %w", err)
+		}
+		pub, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+		if err != nil {
+			return nil, fmt.Errorf("parse rsa public key: %w", err)
+		}
+		keys = append(keys, verificationKey{rsa: pub})
+	}
+
+	if cfg.JWKSFile != "" {
+		jwks, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, jwks...)
+	}
+
+	if len(keys) == 0 {
+		return nil, ErrNoKeysConfigured
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	v := &Verifier{
+		byKid:  make(map[string]verificationKey),
+		parser: jwt.NewParser(opts...),
+	}
+	for _, k := range keys {
+		if k.kid != "" {
+			v.byKid[k.kid] = k
+			continue
+		}
+		if k.hmac != nil {
+			v.hmacs = append(v.hmacs, k.hmac)
+		}
+		if k.rsa != nil {
+			v.rsaKeys = append(v.rsaKeys, k.rsa)
+		}
+	}
+	return v, nil
+}
+
+// Verify checks the signature, expiry, issuer and audience of a raw token
+// and returns its claims.
+func (v *Verifier) Verify(raw string) (*Claims, error) {
+	token, err := v.parser.ParseWithClaims(raw, &Claims{}, v.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(*Claims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+func (v *Verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+	if kid, ok := token.Header["kid"].(string); ok && kid != "" {
+		k, found := v.byKid[kid]
+		if !found {
+			return nil, ErrUnknownKey
+		}
+		switch token.Method.(type) {
+		case *jwt.SigningMethodHMAC:
+			if k.hmac != nil {
+				return k.hmac, nil
+			}
+		case *jwt.SigningMethodRSA:
+			if k.rsa != nil {
+				return k.rsa, nil
+			}
+		}
+		return nil, ErrUnknownKey
+	}
+
+	var set jwt.VerificationKeySet
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		for _, k := range v.hmacs {
+			set.Keys = append(set.Keys, k)
+		}
+	case *jwt.SigningMethodRSA:
+		for _, k := range v.rsaKeys {
+			set.Keys = append(set.Keys, k)
+		}
+	}
+	if len(set.Keys) == 0 {
+		return nil, ErrUnknownKey
+	}
+	return set, nil
+}
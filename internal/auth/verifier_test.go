@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/config"
+)
+
+func signHS256(t *testing.T, secret string, claims Claims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func validClaims() Claims {
+	return Claims{
+		Roles:  []string{"editor"},
+		Tenant: "acme",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "user-1",
+			Issuer:    "https://issuer.test",
+			Audience:  jwt.ClaimStrings{"soa-backend"},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestVerifyHS256(t *testing.T) {
+	v, err := NewVerifier(config.AuthConfig{
+		Issuer:     "https://issuer.test",
+		Audience:   "soa-backend",
+		HMACSecret: "secret",
+	})
+	require.NoError(t, err)
+
+	claims, err := v.Verify(signHS256(t, "secret", validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+	assert.Equal(t, []string{"editor"}, claims.Roles)
+	assert.Equal(t, "acme", claims.Tenant)
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	v, err := NewVerifier(config.AuthConfig{
+		Issuer:     "https://issuer.test",
+		Audience:   "soa-backend",
+		HMACSecret: "secret",
+	})
+	require.NoError(t, err)
+
+	expired := validClaims()
+	expired.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+	wrongIssuer := validClaims()
+	wrongIssuer.Issuer = "https://other.test"
+
+	wrongAudience := validClaims()
+	wrongAudience.Audience = jwt.ClaimStrings{"other"}
+
+	noExpiry := validClaims()
+	noExpiry.ExpiresAt = nil
+
+	tests := map[string]string{
+		"bad signature":  signHS256(t, "not-the-secret", validClaims()),
+		"expired":        signHS256(t, "secret", expired),
+		"wrong issuer":   signHS256(t, "secret", wrongIssuer),
+		"wrong audience": signHS256(t, "secret", wrongAudience),
+		"no expiry":      signHS256(t, "secret", noExpiry),
+		"malformed":      "not.a.token",
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := v.Verify(token)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestVerifyRS256FromJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks, err := json.Marshal(map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks, 0o600))
+
+	v, err := NewVerifier(config.AuthConfig{JWKSFile: path, HMACSecret: "secret"})
+	require.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims())
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	claims, err := v.Verify(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", claims.Subject)
+
+	token.Header["kid"] = "unknown"
+	signed, err = token.SignedString(key)
+	require.NoError(t, err)
+	_, err = v.Verify(signed)
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	// An HS256 token forged with the RSA modulus must not pass as RS256.
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	forged.Header["kid"] = "key-1"
+	signed, err = forged.SignedString(key.N.Bytes())
+	require.NoError(t, err)
+	_, err = v.Verify(signed)
+	assert.Error(t, err)
+}
+
+func TestNewVerifierRequiresKeys(t *testing.T) {
+	_, err := NewVerifier(config.AuthConfig{})
+	assert.ErrorIs(t, err, ErrNoKeysConfigured)
+}
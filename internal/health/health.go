@@ -0,0 +1,154 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusDraining = "draining"
+)
+
+const checkTimeout = 2 * time.Second
+
+type CheckFunc func(ctx context.Context) error
+
+type Check struct {
+	Name string
+	Fn   CheckFunc
+	// Critical checks make the service not ready when they fail; others are
+	// only reported.
+	Critical bool
+	// CacheTTL reuses the last result for this long, so that frequent probes
+	// do not hammer rate-limited dependencies.
+	CacheTTL time.Duration
+}
+
+type cachedResult struct {
+	result    model.CheckResult
+	checkedAt time.Time
+}
+
+// Checker backs the /readyz endpoint: it runs the registered checks, reports
+// database pool statistics and can be switched to not-ready while the
+// server drains.
+type Checker struct {
+	db       *sql.DB
+	draining atomic.Bool
+
+	mu     sync.Mutex
+	checks []Check
+	cache  map[string]cachedResult
+}
+
+func NewChecker(db *sql.DB) *Checker {
+	c := &Checker{db: db, cache: make(map[string]cachedResult)}
+	c.Register(Check{Name: "database", Fn: db.PingContext, Critical: true})
+	return c
+}
+
+func (c *Checker) Register(check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check)
+}
+
+// Drain marks the service as not ready so load balancers stop routing new
+// traffic to it before shutdown.
+func (c *Checker) Drain() {
+	c.draining.Store(true)
+}
+
+func (c *Checker) Readiness(ctx context.Context) (model.ReadinessResponse, bool) {
+	c.mu.Lock()
+	checks := append([]Check(nil), c.checks...)
+	c.mu.Unlock()
+
+	results := make([]model.CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	ready := !c.draining.Load()
+	resp := model.ReadinessResponse{
+		Checks: make(map[string]model.CheckResult, len(checks)),
+		DBPool: poolStats(c.db.Stats()),
+	}
+	for i, check := range checks {
+		resp.Checks[check.Name] = results[i]
+		if check.Critical && results[i].Status != StatusUp {
+			ready = false
+		}
+	}
+
+	switch {
+	case c.draining.Load():
+		resp.Status = StatusDraining
+	case ready:
+		resp.Status = StatusReady
+	default:
+		resp.Status = StatusNotReady
+	}
+	return resp, ready
+}
+
+func (c *Checker) run(ctx context.Context, check Check) model.CheckResult {
+	if check.CacheTTL > 0 {
+		c.mu.Lock()
+		cached, ok := c.cache[check.Name]
+		c.mu.Unlock()
+		if ok && time.Since(cached.checkedAt) < check.CacheTTL {
+			return cached.result
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Fn(ctx)
+	result := model.CheckResult{
+		Status:   StatusUp,
+		Critical: check.Critical,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	if check.CacheTTL > 0 {
+		c.mu.Lock()
+		c.cache[check.Name] = cachedResult{result: result, checkedAt: start}
+		c.mu.Unlock()
+	}
+	return result
+}
+
+func poolStats(s sql.DBStats) *model.DBPoolStats {
+	return &model.DBPoolStats{
+		MaxOpenConnections: s.MaxOpenConnections,
+		OpenConnections:    s.OpenConnections,
+		InUse:              s.InUse,
+		Idle:               s.Idle,
+		WaitCount:          s.WaitCount,
+		WaitDuration:       s.WaitDuration.String(),
+		MaxIdleClosed:      s.MaxIdleClosed,
+		MaxIdleTimeClosed:  s.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  s.MaxLifetimeClosed,
+	}
+}
@@ -0,0 +1,65 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newChecker(t *testing.T) (*Checker, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return NewChecker(db), mock
+}
+
+func TestReadiness(t *testing.T) {
+	checker, mock := newChecker(t)
+	checker.Register(Check{Name: "geocoder", Fn: func(context.Context) error { return errors.New("timeout") }})
+
+	mock.ExpectPing()
+	resp, ready := checker.Readiness(context.Background())
+	assert.True(t, ready, "non-critical failures do not flip readiness")
+	assert.Equal(t, StatusReady, resp.Status)
+	assert.Equal(t, StatusUp, resp.Checks["database"].Status)
+	assert.Equal(t, StatusDown, resp.Checks["geocoder"].Status)
+	assert.Equal(t, "timeout", resp.Checks["geocoder"].Error)
+	assert.NotNil(t, resp.DBPool)
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	resp, ready = checker.Readiness(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, StatusNotReady, resp.Status)
+	assert.Equal(t, StatusDown, resp.Checks["database"].Status)
+}
+
+func TestReadinessWhileDraining(t *testing.T) {
+	checker, mock := newChecker(t)
+	checker.Drain()
+
+	mock.ExpectPing()
+	resp, ready := checker.Readiness(context.Background())
+	assert.False(t, ready)
+	assert.Equal(t, StatusDraining, resp.Status)
+}
+
+func TestCheckCacheTTL(t *testing.T) {
+	checker, mock := newChecker(t)
+
+	calls := 0
+	checker.Register(Check{Name: "geocoder", CacheTTL: time.Minute, Fn: func(context.Context) error {
+		calls++
+		return nil
+	}})
+
+	mock.ExpectPing()
+	mock.ExpectPing()
+	checker.Readiness(context.Background())
+	checker.Readiness(context.Background())
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,60 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"github.com/rs/zerolog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"time"
+)
+
+// GormLogger sends gorm's logs to the logger in the statement's context, so
+// that queries carry the request ID of the request that issued them. Failed
+// statements are logged at error level, slow ones at warn and the rest at
+// trace.
+type GormLogger struct {
+	SlowThreshold time.Duration
+}
+
+func NewGormLogger() GormLogger {
+	return GormLogger{SlowThreshold: 200 * time.Millisecond}
+}
+
+// LogMode is a no-op; the level comes from the zerolog global level.
+func (l GormLogger) LogMode(logger.LogLevel) logger.Interface {
+	return l
+}
+
+func (l GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	zerolog.Ctx(ctx).Info().Msgf(msg, args...)
+}
+
+func (l GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	zerolog.Ctx(ctx).Warn().Msgf(msg, args...)
+}
+
+func (l GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	zerolog.Ctx(ctx).Error().Msgf(msg, args...)
+}
+
+func (l GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	logger := zerolog.Ctx(ctx)
+
+	var event *zerolog.Event
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		event = logger.Error().Err(err)
+	case l.SlowThreshold > 0 && elapsed > l.SlowThreshold:
+		event = logger.Warn().Bool("slow", true)
+	default:
+		event = logger.Trace()
+	}
+	if !event.Enabled() {
+		return
+	}
+
+	sql, rows := fc()
+	event.Str("sql", sql).Int64("rows", rows).Dur("elapsed", elapsed).Msg("query")
+}
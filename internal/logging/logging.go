@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/thinhpq0112/soa-backend/config"
+	"io"
+	"os"
+)
+
+// Setup configures the global zerolog logger from cfg. Code that logs through
+// zerolog.Ctx(ctx) outside of a request falls back to this logger.
+func Setup(cfg config.LogConfig) {
+	level, _ := zerolog.ParseLevel(cfg.Level)
+	zerolog.SetGlobalLevel(level)
+	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
+
+	log.Logger = New(cfg.Format, os.Stderr)
+	zerolog.DefaultContextLogger = &log.Logger
+}
+
+// New returns a logger writing to w as JSON lines or, for the console format,
+// as human-readable output.
+func New(format string, w io.Writer) zerolog.Logger {
+	if format == config.LogFormatConsole {
+		w = zerolog.ConsoleWriter{Out: w}
+	}
+	return zerolog.New(w).With().Timestamp().Logger()
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the ID of the request it
+// belongs to, for the code that records it outside of logs.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, if any.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func sampleCount(t *testing.T, operation, table string) uint64 {
+	var m dto.Metric
+	require.NoError(t, DBQueryDuration.WithLabelValues(operation, table).(prometheus.Histogram).Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestGormPluginObservesQueries(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer sqlDB.Close()
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+	require.NoError(t, db.Use(GormPlugin{}))
+
+	before := sampleCount(t, "query", "widgets")
+
+	mock.ExpectQuery(`SELECT \* FROM "widgets"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	var rows []struct{ Id int }
+	require.NoError(t, db.Table("widgets").Find(&rows).Error)
+
+	assert.Equal(t, before+1, sampleCount(t, "query", "widgets"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
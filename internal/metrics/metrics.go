@@ -0,0 +1,90 @@
+// Package metrics defines the Prometheus collectors exposed on /metrics.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/rs/zerolog/log"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"time"
+)
+
+const namespace = "soa"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests handled, by route template, method and status.",
+	}, []string{"route", "method", "status"})
+
+	HTTPDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency, by route template, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "gorm query duration, by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	PDFGenerationDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "pdf_generation_duration_seconds",
+		Help:      "Time spent generating the product PDF report.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	})
+
+	GeocodingRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "geocoding_requests_total",
+		Help:      "Outbound geocoding calls, by lookup kind and outcome.",
+	}, []string{"lookup", "outcome"})
+
+	ProductsTotal = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "products_total",
+		Help:      "Number of products in the catalog.",
+	})
+
+	StockQuantityTotal = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stock_quantity_total",
+		Help:      "Sum of the quantity of every product.",
+	})
+)
+
+// RegisterDBStats exposes the sql.DB connection pool statistics.
+func RegisterDBStats(db *sql.DB, dbName string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// RefreshBusinessGauges updates the business gauges from fetch every
+// interval until ctx is cancelled.
+func RefreshBusinessGauges(ctx context.Context, interval time.Duration, fetch func(ctx context.Context) (model.InventoryTotals, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		totals, err := fetch(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("refresh business metrics")
+		} else {
+			ProductsTotal.Set(float64(totals.Products))
+			StockQuantityTotal.Set(float64(totals.Quantity))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,19 +1,80 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+	"net/http"
+	"strings"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// ClaimsKey is the gin.Context key holding the *auth.Claims of the caller.
+const ClaimsKey = "claims"
+
+const APIKeyHeader = "X-API-Key"
+
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, rawKey string) (*auth.Claims, error)
+}
+
+// AuthMiddleware identifies the caller from an X-API-Key header when present,
+// and from an Authorization bearer token otherwise.
+func AuthMiddleware(verifier *auth.Verifier, apiKeys APIKeyAuthenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		//	authHeader := c.GetHeader("Authorization")
-		//	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-		//		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-		//		c.Abort()
-		//		return
-		//	}
-		// ////
-		//	///
+		var claims *auth.Claims
+		var err error
+
+		if apiKey := c.GetHeader(APIKeyHeader); apiKey != "" {
+			claims, err = apiKeys.Authenticate(c.Request.Context(), apiKey)
+			if errors.Is(err, auth.ErrInvalidAPIKey) {
+				abortUnauthorized(c, "invalid api key")
+				return
+			}
+			if err != nil {
+				c.Error(err)
+				abortAPIKeyLookupFailed(c, err)
+				return
+			}
+		} else {
+			authHeader := c.GetHeader("Authorization")
+			if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+				abortUnauthorized(c, "missing bearer token")
+				return
+			}
+
+			claims, err = verifier.Verify(strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer ")))
+			if err != nil {
+				abortUnauthorized(c, "invalid token")
+				return
+			}
+		}
+
+		c.Set(ClaimsKey, claims)
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), claims))
 		c.Next()
 	}
 }
+
+func abortUnauthorized(c *gin.Context, reason string) {
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, model.ErrorResponse{
+		Error:     "unauthorized: " + reason,
+		Code:      "unauthorized",
+		RequestId: RequestID(c),
+	})
+}
+
+// abortAPIKeyLookupFailed answers 503 when the key store is unreachable and a
+// generic 500 otherwise, without exposing the cause.
+func abortAPIKeyLookupFailed(c *gin.Context, err error) {
+	resp := model.ErrorResponse{Error: "internal server error", Code: "internal_error", RequestId: RequestID(c)}
+	status := http.StatusInternalServerError
+	if errors.Is(err, service.ErrUnavailable) {
+		resp.Error, resp.Code = "authentication is temporarily unavailable", "unavailable"
+		status = http.StatusServiceUnavailable
+	}
+	c.AbortWithStatusJSON(status, resp)
+}
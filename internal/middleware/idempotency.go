@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+	"io"
+	"net/http"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from storage.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+const maxIdempotencyKeyLen = 255
+
+type IdempotencyStore interface {
+	Begin(ctx context.Context, owner, key, requestHash string) (*model.IdempotencyKey, error)
+	Complete(ctx context.Context, owner, key string, statusCode int, contentType string, body []byte) error
+	Abandon(ctx context.Context, owner, key string) error
+}
+
+// IdempotencyMiddleware makes POST requests carrying an Idempotency-Key
+// header safe to retry: the first response is stored and replayed for
+// retries with the same key, method, path and body. Keys are scoped to the
+// authenticated caller, so the middleware must run after AuthMiddleware.
+//
+// Responses with a 5xx status are not stored, so that a retry after a
+// transient failure is processed again.
+func IdempotencyMiddleware(store IdempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if !validIdempotencyKey(key) {
+			abortIdempotency(c, http.StatusBadRequest, "bad_request", "Idempotency-Key must be 1 to 255 printable ASCII characters")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortIdempotency(c, http.StatusBadRequest, "bad_request", "could not read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		owner := idempotencyOwner(ctx)
+		stored, err := store.Begin(ctx, owner, key, requestHash(c.Request, body))
+		if err != nil {
+			c.Error(err)
+			abortIdempotencyFailed(c, err)
+			return
+		}
+		if stored != nil {
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		completed := false
+		defer func() {
+			// Reached without completing when a handler panics.
+			if !completed {
+				releaseIdempotencyKey(ctx, store, owner, key)
+			}
+		}()
+
+		c.Next()
+
+		// The caller may have given up waiting, which is when a retry is
+		// most likely: store the response regardless.
+		storeCtx := context.WithoutCancel(ctx)
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			releaseIdempotencyKey(ctx, store, owner, key)
+		} else if err := store.Complete(storeCtx, owner, key, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			zerolog.Ctx(ctx).Error().Err(err).Msg("store idempotent response")
+		}
+		completed = true
+	}
+}
+
+func releaseIdempotencyKey(ctx context.Context, store IdempotencyStore, owner, key string) {
+	if err := store.Abandon(context.WithoutCancel(ctx), owner, key); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Msg("release idempotency key")
+	}
+}
+
+func idempotencyOwner(ctx context.Context) string {
+	if claims, ok := auth.FromContext(ctx); ok {
+		return claims.Subject
+	}
+	return ""
+}
+
+// requestHash fingerprints a request so that a key reused for a different
+// request is detected.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLen {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func abortIdempotency(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, model.ErrorResponse{Error: message, Code: code, RequestId: RequestID(c)})
+}
+
+// abortIdempotencyFailed answers errors from IdempotencyStore.Begin, which
+// are service errors or unexpected failures.
+func abortIdempotencyFailed(c *gin.Context, err error) {
+	var svcErr *service.Error
+	if !errors.As(err, &svcErr) {
+		abortIdempotency(c, http.StatusInternalServerError, "internal_error", "internal server error")
+		return
+	}
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		status = http.StatusUnprocessableEntity
+	case errors.Is(err, service.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+	abortIdempotency(c, status, svcErr.Code, svcErr.Message)
+}
+
+// responseRecorder keeps a copy of the response body written through it.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
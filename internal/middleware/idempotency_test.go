@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+)
+
+// memoryIdempotencyStore mimics the service without expiry.
+type memoryIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]*model.IdempotencyKey
+}
+
+func (s *memoryIdempotencyStore) Begin(_ context.Context, owner, key, requestHash string) (*model.IdempotencyKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.keys[owner+"/"+key]
+	if !ok {
+		s.keys[owner+"/"+key] = &model.IdempotencyKey{Owner: owner, Key: key, RequestHash: requestHash}
+		return nil, nil
+	}
+	if existing.RequestHash != requestHash {
+		return nil, service.ValidationError("idempotency_key_reused", "reused")
+	}
+	if !existing.Completed() {
+		return nil, service.ConflictError("idempotency_key_in_progress", "in progress")
+	}
+	replay := *existing
+	return &replay, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, owner, key string, statusCode int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := s.keys[owner+"/"+key]
+	k.StatusCode, k.ContentType, k.Body = statusCode, contentType, append([]byte(nil), body...)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Abandon(_ context.Context, owner, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, owner+"/"+key)
+	return nil
+}
+
+func newIdempotencyRouter(store IdempotencyStore, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		claims := &auth.Claims{}
+		claims.Subject = c.GetHeader("X-Test-Subject")
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), claims))
+	})
+	router.Use(IdempotencyMiddleware(store))
+	router.POST("/products", handler)
+	return router
+}
+
+func postWithKey(router *gin.Engine, subject, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	req.Header.Set("X-Test-Subject", subject)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddlewareReplaysResponse(t *testing.T) {
+	store := &memoryIdempotencyStore{keys: map[string]*model.IdempotencyKey{}}
+	calls := 0
+	router := newIdempotencyRouter(store, func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	first := postWithKey(router, "alice", "k1", `{"name":"lamp"}`)
+	retry := postWithKey(router, "alice", "k1", `{"name":"lamp"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	other := postWithKey(router, "bob", "k1", `{"name":"lamp"}`)
+	assert.Equal(t, 2, calls, "keys are scoped to the caller")
+	assert.Equal(t, http.StatusCreated, other.Code)
+}
+
+func TestIdempotencyMiddlewareRejectsDifferentPayload(t *testing.T) {
+	store := &memoryIdempotencyStore{keys: map[string]*model.IdempotencyKey{}}
+	router := newIdempotencyRouter(store, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	postWithKey(router, "alice", "k1", `{"name":"lamp"}`)
+	w := postWithKey(router, "alice", "k1", `{"name":"desk"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "idempotency_key_reused")
+}
+
+func TestIdempotencyMiddlewareDoesNotStoreServerErrors(t *testing.T) {
+	store := &memoryIdempotencyStore{keys: map[string]*model.IdempotencyKey{}}
+	calls := 0
+	router := newIdempotencyRouter(store, func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.JSON(http.StatusServiceUnavailable, gin.H{})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	postWithKey(router, "alice", "k1", `{}`)
+	w := postWithKey(router, "alice", "k1", `{}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestIdempotencyMiddlewareRejectsInvalidKey(t *testing.T) {
+	store := &memoryIdempotencyStore{keys: map[string]*model.IdempotencyKey{}}
+	router := newIdempotencyRouter(store, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	w := postWithKey(router, "alice", strings.Repeat("k", 256), `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
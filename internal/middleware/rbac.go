@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"net/http"
+)
+
+type Authorizer struct {
+	policy *auth.Policy
+}
+
+func NewAuthorizer(policy *auth.Policy) *Authorizer {
+	return &Authorizer{policy: policy}
+}
+
+// Require rejects callers whose roles or scopes do not grant perm. It must
+// run after AuthMiddleware.
+func (a *Authorizer) Require(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !a.Allows(c, perm) {
+			AbortForbidden(c, perm)
+			return
+		}
+		c.Next()
+	}
+}
+
+// Allows reports whether the caller's roles or scopes grant perm, for
+// handlers whose required permission depends on the request.
+func (a *Authorizer) Allows(c *gin.Context, perm auth.Permission) bool {
+	claims, ok := auth.FromContext(c.Request.Context())
+	return ok && (a.policy.Allows(claims.Roles, perm) || auth.ScopesAllow(claims.Scopes, perm))
+}
+
+// AbortForbidden answers 403 for a caller lacking perm.
+func AbortForbidden(c *gin.Context, perm auth.Permission) {
+	c.AbortWithStatusJSON(http.StatusForbidden, model.ForbiddenResponse{
+		Error:              "forbidden",
+		Code:               "forbidden",
+		RequiredPermission: string(perm),
+		RequestId:          RequestID(c),
+	})
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/thinhpq0112/soa-backend/internal/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey is the gin.Context key holding the request ID.
+const RequestIDKey = "request_id"
+
+const maxRequestIDLen = 128
+
+// RequestIDMiddleware reuses the caller's X-Request-ID when it is sensible,
+// generates one otherwise, and echoes it in the response. It attaches a
+// logger carrying the request ID, and the trace ID when the request is
+// traced, to the request context for zerolog.Ctx, and the request ID itself
+// for logging.RequestID.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+
+		ctx := c.Request.Context()
+		logCtx := log.Logger.With().Str("request_id", id)
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			logCtx = logCtx.Str("trace_id", sc.TraceID().String())
+		}
+		logger := logCtx.Logger()
+		ctx = logging.WithRequestID(logger.WithContext(ctx), id)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// RequestID returns the ID assigned by RequestIDMiddleware.
+func RequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
+
+// validRequestID accepts short printable ASCII IDs so that caller-supplied
+// values cannot inject control characters into logs or headers.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
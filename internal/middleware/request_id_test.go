@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/logging"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	previous := log.Logger
+	log.Logger = zerolog.New(&buf)
+	t.Cleanup(func() { log.Logger = previous })
+
+	router := gin.New()
+	router.Use(RequestIDMiddleware())
+	router.GET("/ping", func(c *gin.Context) {
+		zerolog.Ctx(c.Request.Context()).Info().Msg("handled")
+		assert.Equal(t, RequestID(c), logging.RequestID(c.Request.Context()))
+		c.String(http.StatusOK, RequestID(c))
+	})
+
+	cases := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"reuses the caller's id", "req-123", "req-123"},
+		{"generates one when missing", "", ""},
+		{"replaces ids with control characters", "bad\nid", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+			if tc.header != "" {
+				req.Header.Set(RequestIDHeader, tc.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(RequestIDHeader)
+			require.NotEmpty(t, id)
+			if tc.expected != "" {
+				assert.Equal(t, tc.expected, id)
+			} else {
+				assert.NotEqual(t, tc.header, id)
+			}
+			assert.Equal(t, id, w.Body.String())
+
+			var entry map[string]string
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+			assert.Equal(t, id, entry["request_id"])
+		})
+	}
+}
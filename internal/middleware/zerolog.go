@@ -3,18 +3,13 @@ package middleware
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
-	"github.com/rs/zerolog/log"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
 	"net/http"
-	"os"
 	"time"
 )
 
-func init() {
-	// Configure zerolog
-	zerolog.TimeFieldFormat = zerolog.TimeFormatUnix
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
-}
-
+// LogMiddleWare logs every request with the request-scoped logger, so it must
+// run after RequestIDMiddleware.
 func LogMiddleWare() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -22,21 +17,35 @@ func LogMiddleWare() gin.HandlerFunc {
 		// Process request
 		c.Next()
 
-		// Log request details
+		logger := zerolog.Ctx(c.Request.Context())
 		status := c.Writer.Status()
-		event := log.Info()
-		if status != http.StatusOK {
-			event = log.Error()
+		var event *zerolog.Event
+		switch {
+		case status >= http.StatusInternalServerError:
+			event = logger.Error()
+		case status >= http.StatusBadRequest:
+			event = logger.Warn()
+		default:
+			event = logger.Info()
+		}
+
+		if claims, ok := auth.FromContext(c.Request.Context()); ok {
+			event.Str("subject", claims.Subject)
+		}
+		if len(c.Errors) > 0 {
+			event.Str("errors", c.Errors.String())
 		}
 
 		// Log request details
-		//log.Info().
 		event.
 			Str("method", c.Request.Method).
 			Str("path", c.Request.URL.Path).
-			Int("status", c.Writer.Status()).
+			Str("route", c.FullPath()).
+			Int("status", status).
+			Int("bytes", c.Writer.Size()).
 			Dur("latency", time.Since(start)).
 			Str("client_ip", c.ClientIP()).
+			Str("user_agent", c.Request.UserAgent()).
 			Msg("request completed")
 	}
 }
@@ -0,0 +1,77 @@
+package migrate
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/migrations"
+)
+
+func TestLoadOrdersMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":   {Data: []byte("CREATE INDEX")},
+		"0002_add_index.down.sql": {Data: []byte("DROP INDEX")},
+		"0001_init.up.sql":        {Data: []byte("CREATE TABLE")},
+		"0001_init.down.sql":      {Data: []byte("DROP TABLE")},
+		"README.md":               {Data: []byte("ignored")},
+	}
+
+	loaded, err := Load(fsys)
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, Migration{Version: 1, Name: "init", Up: "CREATE TABLE", Down: "DROP TABLE"}, loaded[0])
+	assert.Equal(t, int64(2), loaded[1].Version)
+}
+
+func TestLoadRejectsIncompleteMigrations(t *testing.T) {
+	_, err := Load(fstest.MapFS{"0001_init.up.sql": {Data: []byte("CREATE TABLE")}})
+	assert.Error(t, err)
+
+	_, err = Load(fstest.MapFS{
+		"0001_init.up.sql":    {Data: []byte("CREATE TABLE")},
+		"0001_init.down.sql":  {Data: []byte("DROP TABLE")},
+		"0001_other.up.sql":   {Data: []byte("CREATE TABLE")},
+		"0001_other.down.sql": {Data: []byte("DROP TABLE")},
+	})
+	assert.Error(t, err)
+}
+
+func TestEmbeddedMigrationsLoad(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+	assert.Equal(t, int64(1), loaded[0].Version)
+}
+
+func TestUpAppliesPendingMigrations(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	migrator := &Migrator{db: db, migrations: []Migration{
+		{Version: 1, Name: "init", Up: "CREATE TABLE a", Down: "DROP TABLE a"},
+		{Version: 2, Name: "next", Up: "CREATE TABLE b", Down: "DROP TABLE b"},
+	}}
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT version, applied_at FROM schema_migrations").
+		WillReturnRows(sqlmock.NewRows([]string{"version", "applied_at"}).AddRow(1, time.Now()))
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO schema_migrations").WithArgs(int64(2), "next").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	applied, err := migrator.Up(context.Background())
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.Equal(t, int64(2), applied[0].Version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
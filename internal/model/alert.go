@@ -0,0 +1,53 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+)
+
+// StockAlert is raised when the quantity of a product drops below its
+// reorder point. It stays the alert of the product until the quantity
+// recovers, however often the stock changes meanwhile and whatever its
+// status, so that a product below its reorder point is alerted once.
+type StockAlert struct {
+	Id        int64     `json:"id" gorm:"primary_key"`
+	ProductId uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	Status    string    `json:"status" gorm:"type:varchar(25);not null;default:open" enums:"open,acknowledged,resolved"`
+	// Quantity, ReorderPoint and ReorderQuantity are those of the product
+	// when the alert was raised.
+	Quantity        int        `json:"quantity" gorm:"not null" example:"3"`
+	ReorderPoint    int        `json:"reorder_point" gorm:"not null" example:"5"`
+	ReorderQuantity *int       `json:"reorder_quantity" example:"20"`
+	RaisedAt        time.Time  `json:"raised_at" gorm:"type:timestamptz;not null"`
+	AcknowledgedAt  *time.Time `json:"acknowledged_at,omitempty" gorm:"type:timestamptz"`
+	AcknowledgedBy  string     `json:"acknowledged_by,omitempty" gorm:"type:varchar(255)"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty" gorm:"type:timestamptz"`
+	ResolvedBy      string     `json:"resolved_by,omitempty" gorm:"type:varchar(255)"`
+	// RecoveredAt is when the quantity was found back at the reorder point,
+	// which resolves the alert if it was not already.
+	RecoveredAt *time.Time `json:"recovered_at,omitempty" gorm:"type:timestamptz"`
+	// DeliveredAt is when the alert was posted to the webhook.
+	DeliveredAt *time.Time `json:"delivered_at,omitempty" gorm:"type:timestamptz"`
+}
+
+// AlertFilter selects stock alerts. Empty fields match everything.
+type AlertFilter struct {
+	Statuses  []string
+	ProductId string
+	// BeforeId continues a listing after its last entry, alerts being
+	// listed newest first.
+	BeforeId int64
+	Limit    int
+}
+
+type AlertListResponse struct {
+	Data []StockAlert `json:"data"`
+	// NextBeforeId is the before_id of the next page, or 0 on the last page.
+	NextBeforeId int64 `json:"next_before_id,omitempty"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"time"
+)
+
+type APIKey struct {
+	Id         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name       string         `json:"name" gorm:"type:varchar(255);not null"`
+	Prefix     string         `json:"prefix" gorm:"type:varchar(16);not null"`
+	KeyHash    string         `json:"-" gorm:"type:char(64);not null;unique"`
+	Scopes     pq.StringArray `json:"scopes" gorm:"type:text[];not null" swaggertype:"array,string"`
+	ExpiresAt  *time.Time     `json:"expires_at" gorm:"type:timestamptz"`
+	RevokedAt  *time.Time     `json:"revoked_at" gorm:"type:timestamptz"`
+	LastUsedAt *time.Time     `json:"last_used_at" gorm:"type:timestamptz"`
+	UsageCount int64          `json:"usage_count" gorm:"type:bigint;not null;default:0"`
+	CreatedBy  string         `json:"created_by" gorm:"type:varchar(255)"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"type:timestamptz;not null;default:now()"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeySecretResponse is returned when a key is created or rotated; Key is
+// never retrievable again afterwards.
+type APIKeySecretResponse struct {
+	Key  string `json:"key"`
+	Data APIKey `json:"data"`
+}
+
+type APIKeyListResponse struct {
+	Data []APIKey `json:"data"`
+}
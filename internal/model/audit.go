@@ -0,0 +1,95 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	AuditEntityProduct   = "product"
+	AuditEntityCategory  = "category"
+	AuditEntitySupplier  = "supplier"
+	AuditEntityWarehouse = "warehouse"
+)
+
+const (
+	AuditActionCreate  = "create"
+	AuditActionUpdate  = "update"
+	AuditActionDelete  = "delete"
+	AuditActionRestore = "restore"
+	// AuditActionPurge records the permanent deletion of an item from the
+	// trash.
+	AuditActionPurge = "purge"
+)
+
+// AuditEntry records one change of a product, category, supplier or
+// warehouse: who made it, when, during which request, and the fields it
+// changed.
+type AuditEntry struct {
+	Id         int64        `json:"id" gorm:"primary_key"`
+	OccurredAt time.Time    `json:"occurred_at" gorm:"type:timestamptz;not null;default:now()"`
+	Actor      string       `json:"actor" gorm:"type:varchar(255);not null"`
+	RequestId  string       `json:"request_id,omitempty" gorm:"type:varchar(128)"`
+	Entity     string       `json:"entity" gorm:"type:varchar(25);not null" enums:"product,category,supplier,warehouse"`
+	EntityId   uuid.UUID    `json:"entity_id" gorm:"type:uuid;not null"`
+	Action     string       `json:"action" gorm:"type:varchar(25);not null" enums:"create,update,delete,restore,purge"`
+	Changes    AuditChanges `json:"changes" gorm:"type:jsonb;not null"`
+}
+
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
+
+// FieldChange is the value of a field before and after a change. Before is
+// null for a creation and After for a deletion.
+type FieldChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditChanges maps the JSON name of each changed field to its change.
+type AuditChanges map[string]FieldChange
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(c)
+	return string(data), err
+}
+
+func (c *AuditChanges) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	case nil:
+		*c = nil
+		return nil
+	}
+	return errors.New("unsupported type for audit changes")
+}
+
+// AuditFilter selects audit entries. Empty fields match everything; From is
+// inclusive and To exclusive.
+type AuditFilter struct {
+	Entity   string
+	EntityId string
+	Actor    string
+	From     *time.Time
+	To       *time.Time
+	// BeforeId continues a listing after its last entry, entries being
+	// listed newest first.
+	BeforeId int64
+	Limit    int
+}
+
+type AuditListResponse struct {
+	Data []AuditEntry `json:"data"`
+	// NextBeforeId is the before_id of the next page, or 0 on the last page.
+	NextBeforeId int64 `json:"next_before_id,omitempty"`
+}
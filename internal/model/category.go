@@ -2,18 +2,41 @@ package model
 
 import (
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"time"
 )
 
+const (
+	CategoryStatusActive   = "active"
+	CategoryStatusInactive = "inactive"
+	CategoryStatusArchived = "archived"
+)
+
+// Category is a node of the category tree. Only active categories are
+// listed publicly and can receive new products. DefaultReorderPoint and
+// DefaultReorderQuantity apply to its products that set none.
 type Category struct {
-	Id   uuid.UUID `json:"id" gorm:"type:uuid;primary_key"`
-	Name string    `json:"category_name"`
+	Id                     uuid.UUID      `json:"id" gorm:"type:uuid;primary_key"`
+	Name                   string         `json:"category_name" validate:"notblank,max=255"`
+	ParentId               *uuid.UUID     `json:"parent_id" gorm:"type:uuid"`
+	Status                 string         `json:"status" gorm:"type:varchar(25);not null;default:active" validate:"omitempty,oneof=active inactive archived" enums:"active,inactive,archived"`
+	DefaultReorderPoint    *int           `json:"default_reorder_point" validate:"omitempty,gte=0"`
+	DefaultReorderQuantity *int           `json:"default_reorder_quantity" validate:"omitempty,gte=1"`
+	CreatedAt              time.Time      `json:"created_at" gorm:"type:timestamptz;not null"`
+	UpdatedAt              time.Time      `json:"updated_at" gorm:"type:timestamptz;not null"`
+	Version                int            `json:"version" gorm:"not null;default:1"`
+	DeletedAt              gorm.DeletedAt `json:"-" swaggerignore:"true"`
+	DeletedBy              string         `json:"-" gorm:"type:varchar(255);<-:update"`
+}
+
+// CategoryNode is a category with its subcategories, recursively.
+type CategoryNode struct {
+	Category
+	Children []CategoryNode `json:"children"`
 }
 
-type ProductCategory struct {
-	CategoryId   uuid.UUID `json:"product_category_id" gorm:"type:uuid;primary_key"`
-	CategoryName string    `json:"product_category_name" gorm:"type:varchar(255);not null"`
-	Status       string    `json:"status" gorm:"type:varchar(25);not null"`
-	CreatedAt    time.Time `json:"created_at" gorm:"type:timestamp;not null"`
-	UpdatedAt    time.Time `json:"updated_at" gorm:"type:timestamp;not null"`
+// CategoryStatusRequest changes the status of several categories at once.
+type CategoryStatusRequest struct {
+	Ids    []uuid.UUID `json:"ids" binding:"required"`
+	Status string      `json:"status" binding:"required" enums:"active,inactive,archived"`
 }
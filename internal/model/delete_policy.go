@@ -0,0 +1,18 @@
+package model
+
+// What happens to the products of a category or supplier being deleted.
+const (
+	// DeleteRestrict refuses the delete while live products belong to it.
+	DeleteRestrict = "restrict"
+	// DeleteReassign moves the products to another category or supplier.
+	DeleteReassign = "reassign"
+	// DeleteArchive moves the products to the trash along with it.
+	DeleteArchive = "archive"
+)
+
+type DeletePolicy struct {
+	Mode string
+	// ReassignTo is the id of the category or supplier receiving the
+	// products with DeleteReassign.
+	ReassignTo string
+}
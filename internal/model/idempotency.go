@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// IdempotencyKey records the response to a request sent with an
+// Idempotency-Key header, so that retries of it get the same response.
+type IdempotencyKey struct {
+	// Owner is the subject of the caller: keys of different callers never
+	// collide.
+	Owner string `gorm:"type:varchar(255);primary_key"`
+	Key   string `gorm:"type:varchar(255);primary_key"`
+	// RequestHash fingerprints the method, path and body of the request.
+	RequestHash string `gorm:"type:char(64);not null"`
+	// StatusCode is 0 while the first request is still being processed.
+	StatusCode  int       `gorm:"type:int;not null;default:0"`
+	ContentType string    `gorm:"type:varchar(255)"`
+	Body        []byte    `gorm:"type:bytea"`
+	CreatedAt   time.Time `gorm:"type:timestamptz;not null;default:now()"`
+	ExpiresAt   time.Time `gorm:"type:timestamptz;not null"`
+}
+
+// Completed reports whether the response to the request has been stored.
+func (k IdempotencyKey) Completed() bool {
+	return k.StatusCode != 0
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	MovementReceipt    = "receipt"
+	MovementShipment   = "shipment"
+	MovementAdjustment = "adjustment"
+	MovementReturn     = "return"
+	MovementTransfer   = "transfer"
+)
+
+// ReasonOpeningBalance is the reason of the adjustments that start the
+// stock ledger of a product, whether it is created with stock or was
+// migrated with some.
+const ReasonOpeningBalance = "opening balance"
+
+// InventoryMovement is an entry of the stock ledger. The quantity of a
+// product in a warehouse is the sum of the quantities of its movements
+// there.
+type InventoryMovement struct {
+	Id        int64     `json:"id" gorm:"primary_key"`
+	ProductId uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	// WarehouseId is nil once the warehouse has been deleted.
+	WarehouseId *uuid.UUID `json:"warehouse_id" gorm:"type:uuid"`
+	Kind        string     `json:"kind" gorm:"type:varchar(25);not null" enums:"receipt,shipment,adjustment,return,transfer"`
+	// Quantity is the change of stock: positive when goods come in,
+	// negative when they go out.
+	Quantity int `json:"quantity" gorm:"not null"`
+	// QuantityAfter is the total stock of the product, over all warehouses,
+	// once the movement is applied.
+	QuantityAfter int       `json:"quantity_after" gorm:"not null"`
+	Reason        string    `json:"reason" gorm:"type:varchar(255);not null"`
+	Reference     string    `json:"reference,omitempty" gorm:"type:varchar(100)"`
+	Actor         string    `json:"actor" gorm:"type:varchar(255);not null"`
+	RequestId     string    `json:"request_id,omitempty" gorm:"type:varchar(128)"`
+	OccurredAt    time.Time `json:"occurred_at" gorm:"type:timestamptz;not null;default:now()"`
+}
+
+// MovementRequest is the body of POST /api/products/{id}/movements.
+// Receipts, returns and shipments take a positive quantity, shipments
+// taking goods out; adjustments take a signed one. Transfers between
+// warehouses go through TransferRequest.
+type MovementRequest struct {
+	WarehouseId uuid.UUID `json:"warehouse_id" validate:"required"`
+	Kind        string    `json:"kind" validate:"required,oneof=receipt shipment adjustment return" enums:"receipt,shipment,adjustment,return"`
+	Quantity    int       `json:"quantity" validate:"required" example:"5"`
+	Reason      string    `json:"reason" validate:"notblank,max=255" example:"supplier delivery"`
+	Reference   string    `json:"reference" validate:"max=100" example:"PO-2026-0142"`
+}
+
+// MovementFilter selects the movements of a product. From is inclusive and
+// To exclusive.
+type MovementFilter struct {
+	From *time.Time
+	To   *time.Time
+	// BeforeId continues a listing after its last entry, movements being
+	// listed newest first.
+	BeforeId int64
+	Limit    int
+}
+
+type MovementListResponse struct {
+	Data []InventoryMovement `json:"data"`
+	// NextBeforeId is the before_id of the next page, or 0 on the last page.
+	NextBeforeId int64 `json:"next_before_id,omitempty"`
+}
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ProductPrice is an entry of the price history of a product: the price it
+// has from EffectiveFrom until EffectiveTo, exclusive. The entries of a
+// product never overlap.
+type ProductPrice struct {
+	Id            int64     `json:"id" gorm:"primary_key"`
+	ProductId     uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	Price         float64   `json:"price" gorm:"type:numeric(10,2);not null" example:"19.99"`
+	EffectiveFrom time.Time `json:"effective_from" gorm:"type:timestamptz;not null"`
+	// EffectiveTo is nil while no later price is scheduled.
+	EffectiveTo *time.Time `json:"effective_to" gorm:"type:timestamptz"`
+	Actor       string     `json:"actor" gorm:"type:varchar(255);not null"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"type:timestamptz;not null;default:now()"`
+}
+
+func (ProductPrice) TableName() string {
+	return "product_prices"
+}
+
+// PriceRequest is the body of POST /api/products/{id}/prices. Without
+// EffectiveTo the price holds until the next price already scheduled, if
+// any.
+type PriceRequest struct {
+	Price         float64    `json:"price" validate:"gte=0,lte=99999999.99" example:"17.99"`
+	EffectiveFrom time.Time  `json:"effective_from" validate:"required" example:"2026-11-27T00:00:00Z"`
+	EffectiveTo   *time.Time `json:"effective_to" example:"2026-11-30T00:00:00Z"`
+}
+
+type PriceListResponse struct {
+	Data []ProductPrice `json:"data"`
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"encoding/json"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"time"
 )
 
@@ -19,35 +20,57 @@ import (
 //	Quantity   int       `json:"quantity" gorm:"type:int;default:0"`
 //}
 
+const (
+	ProductStatusAvailable    = "Available"
+	ProductStatusOutOfStock   = "OutOfStock"
+	ProductStatusDiscontinued = "Discontinued"
+)
+
 type Product struct {
 	Id         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Reference  string    `json:"reference" gorm:"type:varchar(50);not null;unique"`
-	Name       string    `json:"name" gorm:"type:varchar(255);not null"`
-	AddedDate  time.Time `json:"added_date" gorm:"type:date;default:CURRENT_DATE"`
-	Status     string    `json:"status" gorm:"type:varchar(50)"`
-	CategoryId uuid.UUID `json:"-" gorm:"type:uuid"`
+	Reference  string    `json:"reference" gorm:"type:varchar(50);not null;uniqueIndex:idx_products_reference_live,where:deleted_at IS NULL" validate:"required,max=50,reference"`
+	Name       string    `json:"name" gorm:"type:varchar(255);not null" validate:"notblank,max=255"`
+	AddedDate  time.Time `json:"added_date" gorm:"type:date;default:CURRENT_DATE" validate:"sane_date"`
+	Status     string    `json:"status" gorm:"type:varchar(50)" validate:"required,oneof=Available OutOfStock Discontinued"`
+	CategoryId uuid.UUID `json:"category_id" gorm:"type:uuid" validate:"required"`
 
-	Price      float64   `json:"price" gorm:"type:numeric(10,2);default:0"`
-	StockCity  string    `json:"stock_city" gorm:"type:varchar(100)"`
-	SupplierId uuid.UUID `json:"-" gorm:"type:uuid"`
+	Price      float64   `json:"price" gorm:"type:numeric(10,2);default:0" validate:"gte=0,lte=99999999.99"`
+	SupplierId uuid.UUID `json:"supplier_id" gorm:"type:uuid" validate:"required"`
 
-	Quantity int `json:"quantity" gorm:"type:int;default:0"`
+	// Quantity is the total stock of the product, over all warehouses.
+	Quantity int `json:"quantity" gorm:"type:int;default:0" validate:"gte=0"`
+	// ReorderPoint and ReorderQuantity override the defaults of the
+	// category. A quantity below the reorder point raises a stock alert.
+	ReorderPoint    *int `json:"reorder_point" validate:"omitempty,gte=0"`
+	ReorderQuantity *int `json:"reorder_quantity" validate:"omitempty,gte=1"`
+	// Version is bumped on every write and backs the ETag of the product.
+	Version int `json:"version" gorm:"not null;default:1"`
+	// DeletedAt marks a product moved to the trash; gorm leaves such rows out
+	// of every query unless it is told otherwise with Unscoped.
+	DeletedAt gorm.DeletedAt `json:"-" swaggerignore:"true"`
+	DeletedBy string         `json:"-" gorm:"type:varchar(255);<-:update"`
 
-	Category *Category `json:"category"`
-	Supplier *Supplier `json:"supplier"`
+	Category *Category `json:"category" validate:"-"`
+	Supplier *Supplier `json:"supplier" validate:"-"`
+	// Stock breaks Quantity down by warehouse.
+	Stock []WarehouseStock `json:"stock" gorm:"foreignKey:ProductId" validate:"-"`
 }
 
 type FilterOption struct {
-	Reference  string   `json:"reference"`
-	StartDate  string   `json:"start_date"`
-	EndDate    string   `json:"end_date"`
-	MinPrice   *float64 `json:"min_price"`
-	MaxPrice   *float64 `json:"max_price"`
-	Categories []string `json:"categories"`
-	Suppliers  []string `json:"suppliers"`
-	StockCity  []string `json:"stock"`
-	Status     []string `json:"status"`
-	Search     string   `json:"search"`
+	Reference          string   `json:"reference"`
+	StartDate          string   `json:"start_date"`
+	EndDate            string   `json:"end_date"`
+	MinPrice           *float64 `json:"min_price"`
+	MaxPrice           *float64 `json:"max_price"`
+	Categories         []string `json:"categories"`
+	Suppliers          []string `json:"suppliers"`
+	StockCity          []string `json:"stock"`
+	Status             []string `json:"status"`
+	Search             string   `json:"search"`
+	IncludeDescendants bool     `json:"include_descendants"`
+	// AsOf, when set, prices the products as they were, or are scheduled to
+	// be, at that time, and leaves out those without a price then.
+	AsOf *time.Time `json:"as_of"`
 }
 
 type ProductsPerCategoryResponse struct {
@@ -60,6 +83,11 @@ type ProductsPerSupplierResponse struct {
 	Percentage   float64 `json:"percentage"`
 }
 
+type InventoryTotals struct {
+	Products int64 `json:"products"`
+	Quantity int64 `json:"quantity"`
+}
+
 func (p Product) MarshalJSON() ([]byte, error) {
 	type Alias Product
 
@@ -0,0 +1,58 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+const (
+	ReservationActive    = "active"
+	ReservationConfirmed = "confirmed"
+	ReservationReleased  = "released"
+	ReservationExpired   = "expired"
+)
+
+// Reservation holds stock of a product for a pending order until it is
+// confirmed, released or expires. Only active reservations hold stock.
+type Reservation struct {
+	Id        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductId uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	Status    string    `json:"status" gorm:"type:varchar(25);not null" enums:"active,confirmed,released,expired"`
+	Reference string    `json:"reference,omitempty" gorm:"type:varchar(100)"`
+	Actor     string    `json:"actor" gorm:"type:varchar(255);not null"`
+	// WarehouseId is the warehouse the stock was shipped from, once the
+	// reservation is confirmed.
+	WarehouseId *uuid.UUID `json:"warehouse_id,omitempty" gorm:"type:uuid"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"type:timestamptz;not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"type:timestamptz;not null"`
+	ClosedAt    *time.Time `json:"closed_at,omitempty" gorm:"type:timestamptz"`
+}
+
+func (Reservation) TableName() string {
+	return "stock_reservations"
+}
+
+// ReservationRequest is the body of POST /api/reservations. A zero TTL
+// means the default one.
+type ReservationRequest struct {
+	ProductId  uuid.UUID `json:"product_id" validate:"required"`
+	Quantity   int       `json:"quantity" validate:"gte=1" example:"2"`
+	TTLSeconds int       `json:"ttl_seconds" validate:"gte=0" example:"900"`
+	Reference  string    `json:"reference" validate:"max=100" example:"SO-1042"`
+}
+
+// ConfirmReservationRequest is the body of POST
+// /api/reservations/{id}/confirm.
+type ConfirmReservationRequest struct {
+	WarehouseId uuid.UUID `json:"warehouse_id" validate:"required"`
+}
+
+// StockAvailability is the stock of a product that can be promised to new
+// orders: what is on hand minus what active reservations hold.
+type StockAvailability struct {
+	ProductId uuid.UUID `json:"product_id"`
+	OnHand    int       `json:"on_hand" example:"12"`
+	Reserved  int       `json:"reserved" example:"3"`
+	Available int       `json:"available" example:"9"`
+}
@@ -1,11 +1,25 @@
 package model
 
-type ProductListResponse struct {
-	Data []Product `json:"data"`
+type ErrorResponse struct {
+	Error   string       `json:"error"`
+	Code    string       `json:"code"`
+	Details []FieldError `json:"details,omitempty"`
+	// BlockingProducts counts the products preventing a delete.
+	BlockingProducts int64  `json:"blocking_products,omitempty"`
+	RequestId        string `json:"request_id,omitempty"`
 }
 
-type ErrorResponse struct {
-	Error string `json:"error"`
+// FieldError describes one invalid field of a request payload, by JSON path.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type ForbiddenResponse struct {
+	Error              string `json:"error"`
+	Code               string `json:"code"`
+	RequiredPermission string `json:"required_permission"`
+	RequestId          string `json:"request_id,omitempty"`
 }
 
 type ActionResponse struct {
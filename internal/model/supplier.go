@@ -1,8 +1,14 @@
 package model
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
 
 type Supplier struct {
-	Id   uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	Name string    `json:"name" gorm:"type:varchar(255);not null;unique"`
+	Id        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string         `json:"name" gorm:"type:varchar(255);not null;uniqueIndex:idx_suppliers_name_live,where:deleted_at IS NULL" validate:"notblank,max=255"`
+	Version   int            `json:"version" gorm:"not null;default:1"`
+	DeletedAt gorm.DeletedAt `json:"-" swaggerignore:"true"`
+	DeletedBy string         `json:"-" gorm:"type:varchar(255);<-:update"`
 }
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// TrashPurgeResult counts the items permanently removed from the trash.
+type TrashPurgeResult struct {
+	DeletedBefore time.Time `json:"deleted_before"`
+	Products      int64     `json:"products"`
+	Categories    int64     `json:"categories"`
+	Suppliers     int64     `json:"suppliers"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import (
+	"github.com/google/uuid"
+)
+
+// Warehouse is a location holding stock. Its coordinates are optional but
+// come in pairs.
+type Warehouse struct {
+	Id        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string    `json:"name" gorm:"type:varchar(255);not null;uniqueIndex:idx_warehouses_name" validate:"notblank,max=255" example:"Lyon North"`
+	City      string    `json:"city" gorm:"type:varchar(100);not null" validate:"notblank,max=100" example:"Lyon"`
+	Latitude  *float64  `json:"latitude" validate:"omitempty,gte=-90,lte=90" example:"45.764"`
+	Longitude *float64  `json:"longitude" validate:"omitempty,gte=-180,lte=180" example:"4.8357"`
+	Version   int       `json:"version" gorm:"not null;default:1"`
+}
+
+// WarehouseStock is the quantity of a product held in a warehouse.
+type WarehouseStock struct {
+	ProductId   uuid.UUID  `json:"product_id" gorm:"type:uuid;primaryKey"`
+	WarehouseId uuid.UUID  `json:"warehouse_id" gorm:"type:uuid;primaryKey"`
+	Quantity    int        `json:"quantity" gorm:"not null"`
+	Warehouse   *Warehouse `json:"warehouse,omitempty"`
+}
+
+func (WarehouseStock) TableName() string {
+	return "warehouse_stock"
+}
+
+// TransferRequest is the body of POST /api/products/{id}/transfers.
+type TransferRequest struct {
+	FromWarehouseId uuid.UUID `json:"from_warehouse_id" validate:"required"`
+	ToWarehouseId   uuid.UUID `json:"to_warehouse_id" validate:"required"`
+	Quantity        int       `json:"quantity" validate:"gte=1" example:"5"`
+	Reason          string    `json:"reason" validate:"notblank,max=255" example:"rebalancing"`
+	Reference       string    `json:"reference" validate:"max=100" example:"TR-2026-0007"`
+}
+
+// TransferResponse holds the two movements of a transfer: the stock leaving
+// the source warehouse and the stock entering the destination.
+type TransferResponse struct {
+	Out InventoryMovement `json:"out"`
+	In  InventoryMovement `json:"in"`
+}
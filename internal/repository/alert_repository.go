@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type IAlertRepo interface {
+	RaiseAlerts(ctx context.Context, now time.Time) ([]model.StockAlert, error)
+	RecoverAlerts(ctx context.Context, now time.Time) (int64, error)
+	GetAlerts(ctx context.Context, filter model.AlertFilter) ([]model.StockAlert, error)
+	GetAlertById(ctx context.Context, id int64) (model.StockAlert, error)
+	GetUndeliveredAlerts(ctx context.Context, limit int) ([]model.StockAlert, error)
+	MarkAlertDelivered(ctx context.Context, id int64, now time.Time) error
+	AcknowledgeAlert(ctx context.Context, id int64, now time.Time) (model.StockAlert, error)
+	ResolveAlert(ctx context.Context, id int64, now time.Time) (model.StockAlert, error)
+}
+
+// StockWatch wakes the stock alert evaluator after writes that may take a
+// product below its reorder point. Notify never blocks: a wake-up already
+// pending covers every write made before the evaluator runs. A nil
+// StockWatch ignores notifications.
+type StockWatch chan struct{}
+
+func NewStockWatch() StockWatch {
+	return make(StockWatch, 1)
+}
+
+func (w StockWatch) Notify() {
+	select {
+	case w <- struct{}{}:
+	default:
+	}
+}
+
+// after notifies w when err, the outcome of a committed write, is nil, and
+// returns err.
+func (w StockWatch) after(err error) error {
+	if err == nil {
+		w.Notify()
+	}
+	return err
+}
+
+// belowReorderPoint matches the live products p, joined with their category
+// c, whose quantity is below their reorder point. Discontinued products are
+// not replenished; queries using it bind @discontinued to
+// model.ProductStatusDiscontinued.
+const belowReorderPoint = `p.deleted_at IS NULL AND p.status <> @discontinued
+	AND p.quantity < COALESCE(p.reorder_point, c.default_reorder_point)`
+
+type alertRepo struct {
+	db *gorm.DB
+}
+
+func NewAlertRepo(db *gorm.DB) *alertRepo {
+	return &alertRepo{db: db}
+}
+
+// RaiseAlerts raises an alert for every product below its reorder point
+// that has none until it recovers, and returns the alerts raised. The
+// unique index on the alerts that have not recovered keeps concurrent
+// evaluators from raising the same alert twice.
+func (r *alertRepo) RaiseAlerts(ctx context.Context, now time.Time) ([]model.StockAlert, error) {
+	var alerts []model.StockAlert
+	err := r.db.WithContext(ctx).Raw(`INSERT INTO stock_alerts (product_id, status, quantity, reorder_point, reorder_quantity, raised_at)
+		SELECT p.id, @open, p.quantity, COALESCE(p.reorder_point, c.default_reorder_point), COALESCE(p.reorder_quantity, c.default_reorder_quantity), @now
+		FROM products p LEFT JOIN categories c ON c.id = p.category_id
+		WHERE `+belowReorderPoint+`
+			AND NOT EXISTS (SELECT 1 FROM stock_alerts a WHERE a.product_id = p.id AND a.recovered_at IS NULL)
+		ON CONFLICT (product_id) WHERE recovered_at IS NULL DO NOTHING
+		RETURNING *`,
+		map[string]interface{}{"open": model.AlertOpen, "now": now, "discontinued": model.ProductStatusDiscontinued}).Scan(&alerts).Error
+	return alerts, err
+}
+
+// RecoverAlerts marks as recovered the alerts of the products no longer
+// below their reorder point, which includes products moved to the trash,
+// resolving those still open or acknowledged.
+func (r *alertRepo) RecoverAlerts(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`UPDATE stock_alerts a SET
+			recovered_at = @now,
+			status = @resolved,
+			resolved_at = COALESCE(a.resolved_at, @now),
+			resolved_by = CASE WHEN a.resolved_at IS NULL THEN @actor ELSE a.resolved_by END
+		WHERE a.recovered_at IS NULL AND NOT EXISTS (
+			SELECT 1 FROM products p LEFT JOIN categories c ON c.id = p.category_id
+			WHERE p.id = a.product_id AND `+belowReorderPoint+`)`,
+		map[string]interface{}{"now": now, "resolved": model.AlertResolved, "actor": auth.SystemActor, "discontinued": model.ProductStatusDiscontinued})
+	return result.RowsAffected, result.Error
+}
+
+// GetAlerts lists the alerts matching filter, newest first.
+func (r *alertRepo) GetAlerts(ctx context.Context, filter model.AlertFilter) ([]model.StockAlert, error) {
+	query := r.db.WithContext(ctx)
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status IN ?", filter.Statuses)
+	}
+	if filter.ProductId != "" {
+		query = query.Where("product_id = ?", filter.ProductId)
+	}
+	if filter.BeforeId > 0 {
+		query = query.Where("id < ?", filter.BeforeId)
+	}
+	var alerts []model.StockAlert
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&alerts).Error
+	return alerts, err
+}
+
+func (r *alertRepo) GetAlertById(ctx context.Context, id int64) (model.StockAlert, error) {
+	var alert model.StockAlert
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&alert).Error
+	return alert, err
+}
+
+// GetUndeliveredAlerts lists, oldest first, the open alerts not yet posted
+// to the webhook.
+func (r *alertRepo) GetUndeliveredAlerts(ctx context.Context, limit int) ([]model.StockAlert, error) {
+	var alerts []model.StockAlert
+	err := r.db.WithContext(ctx).
+		Where("delivered_at IS NULL AND status = ?", model.AlertOpen).
+		Order("id").Limit(limit).Find(&alerts).Error
+	return alerts, err
+}
+
+func (r *alertRepo) MarkAlertDelivered(ctx context.Context, id int64, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.StockAlert{}).Where("id = ?", id).Update("delivered_at", now).Error
+}
+
+// AcknowledgeAlert records that someone is handling an open alert. It fails
+// with an *AlertClosedError unless the alert is open.
+func (r *alertRepo) AcknowledgeAlert(ctx context.Context, id int64, now time.Time) (model.StockAlert, error) {
+	return r.closeAlert(ctx, id, func(alert *model.StockAlert, actor string) error {
+		if alert.Status != model.AlertOpen {
+			return &AlertClosedError{Status: alert.Status}
+		}
+		alert.Status = model.AlertAcknowledged
+		alert.AcknowledgedAt, alert.AcknowledgedBy = &now, actor
+		return nil
+	})
+}
+
+// ResolveAlert closes an open or acknowledged alert. It fails with an
+// *AlertClosedError when the alert is already resolved. The product gets no
+// new alert before its quantity recovers.
+func (r *alertRepo) ResolveAlert(ctx context.Context, id int64, now time.Time) (model.StockAlert, error) {
+	return r.closeAlert(ctx, id, func(alert *model.StockAlert, actor string) error {
+		if alert.Status == model.AlertResolved {
+			return &AlertClosedError{Status: alert.Status}
+		}
+		alert.Status = model.AlertResolved
+		alert.ResolvedAt, alert.ResolvedBy = &now, actor
+		return nil
+	})
+}
+
+// closeAlert locks the alert, applies change to it and saves it.
+func (r *alertRepo) closeAlert(ctx context.Context, id int64, change func(alert *model.StockAlert, actor string) error) (model.StockAlert, error) {
+	var alert model.StockAlert
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&alert).Error
+		if err != nil {
+			return err
+		}
+		if err := change(&alert, auth.Actor(tx.Statement.Context)); err != nil {
+			return err
+		}
+		return tx.Model(&alert).
+			Select("status", "acknowledged_at", "acknowledged_by", "resolved_at", "resolved_by").
+			Updates(&alert).Error
+	})
+	return alert, err
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+func TestRaiseAlertsSkipsProductsAlreadyAlerted(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewAlertRepo(db)
+
+	productID := uuid.New()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`INSERT INTO stock_alerts .* SELECT .* WHERE p.deleted_at IS NULL AND p.status <> \$3\s+AND p.quantity < COALESCE\(p.reorder_point, c.default_reorder_point\)\s+AND NOT EXISTS \(SELECT 1 FROM stock_alerts a WHERE a.product_id = p.id AND a.recovered_at IS NULL\)\s+ON CONFLICT \(product_id\) WHERE recovered_at IS NULL DO NOTHING`).
+		WithArgs(model.AlertOpen, now, model.ProductStatusDiscontinued).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "status", "quantity", "reorder_point"}).
+			AddRow(4, productID, model.AlertOpen, 2, 5))
+
+	alerts, err := repo.RaiseAlerts(context.Background(), now)
+
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, productID, alerts[0].ProductId)
+	assert.Equal(t, 5, alerts[0].ReorderPoint)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcknowledgeAlertRefusesResolved(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewAlertRepo(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "stock_alerts" WHERE id = \$1 ORDER BY "stock_alerts"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(int64(4), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(4, model.AlertResolved))
+	mock.ExpectRollback()
+
+	_, err := repo.AcknowledgeAlert(context.Background(), 4, time.Now())
+
+	var closed *AlertClosedError
+	require.ErrorAs(t, err, &closed)
+	assert.ErrorIs(t, err, ErrAlertClosed)
+	assert.Equal(t, model.AlertResolved, closed.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestResolveAlertRecordsActor(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewAlertRepo(db)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "stock_alerts" WHERE id = \$1 .* FOR UPDATE`).
+		WithArgs(int64(4), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status"}).AddRow(4, model.AlertAcknowledged))
+	mock.ExpectExec(`UPDATE "stock_alerts" SET "status"=\$1,"acknowledged_at"=\$2,"acknowledged_by"=\$3,"resolved_at"=\$4,"resolved_by"=\$5 WHERE "id" = \$6`).
+		WithArgs(model.AlertResolved, nil, "", now, "system", int64(4)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	alert, err := repo.ResolveAlert(context.Background(), 4, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.AlertResolved, alert.Status)
+	assert.Equal(t, "system", alert.ResolvedBy)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStockWatchNotifiesAfterCommit(t *testing.T) {
+	db, mock := setupMockDB(t)
+	watch := NewStockWatch()
+	repo := NewInventoryRepo(db, watch)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "products"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err := repo.AddMovement(context.Background(), model.InventoryMovement{ProductId: uuid.New()}, time.Now())
+	require.Error(t, err)
+	assert.Empty(t, watch, "failed writes do not wake the evaluator")
+
+	watch.Notify()
+	watch.Notify()
+	assert.Len(t, watch, 1, "pending wake-ups coalesce")
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+type IAPIKeyRepo interface {
+	GetAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	GetAPIKeyById(ctx context.Context, id string) (model.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error)
+	AddAPIKey(ctx context.Context, key model.APIKey) error
+	RotateAPIKey(ctx context.Context, id, prefix, hash string) error
+	RevokeAPIKey(ctx context.Context, id string, revokedAt time.Time) error
+	TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error
+}
+
+type apiKeyRepo struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepo(db *gorm.DB) *apiKeyRepo {
+	return &apiKeyRepo{db: db}
+}
+
+func (r *apiKeyRepo) GetAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.WithContext(ctx).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepo) GetAPIKeyById(ctx context.Context, id string) (model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	return key, err
+}
+
+func (r *apiKeyRepo) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", hash).First(&key).Error
+	return key, err
+}
+
+func (r *apiKeyRepo) AddAPIKey(ctx context.Context, key model.APIKey) error {
+	return r.db.WithContext(ctx).Create(&key).Error
+}
+
+func (r *apiKeyRepo) RotateAPIKey(ctx context.Context, id, prefix, hash string) error {
+	return r.updateActive(ctx, id, map[string]interface{}{
+		"prefix":   prefix,
+		"key_hash": hash,
+	})
+}
+
+func (r *apiKeyRepo) RevokeAPIKey(ctx context.Context, id string, revokedAt time.Time) error {
+	return r.updateActive(ctx, id, map[string]interface{}{"revoked_at": revokedAt})
+}
+
+func (r *apiKeyRepo) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_used_at": usedAt,
+			"usage_count":  gorm.Expr("usage_count + 1"),
+		}).Error
+}
+
+func (r *apiKeyRepo) updateActive(ctx context.Context, id string, values map[string]interface{}) error {
+	result := r.db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(values)
+	return affectedOne(result)
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/logging"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+)
+
+type IAuditRepo interface {
+	GetAuditEntries(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error)
+}
+
+type auditRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditRepo(db *gorm.DB) *auditRepo {
+	return &auditRepo{db: db}
+}
+
+// GetAuditEntries lists the entries matching filter, newest first.
+func (r *auditRepo) GetAuditEntries(ctx context.Context, filter model.AuditFilter) ([]model.AuditEntry, error) {
+	query := r.db.WithContext(ctx)
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+	if filter.EntityId != "" {
+		query = query.Where("entity_id = ?", filter.EntityId)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.From != nil {
+		query = query.Where("occurred_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("occurred_at < ?", *filter.To)
+	}
+	if filter.BeforeId > 0 {
+		query = query.Where("id < ?", filter.BeforeId)
+	}
+	var entries []model.AuditEntry
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&entries).Error
+	return entries, err
+}
+
+// audited runs write, which changes the row of model T with the given id,
+// and records the change in the audit log, all within tx. The row is locked
+// first so that the entry shows it as it was right before write.
+func audited[T any](tx *gorm.DB, entity, action, id string, write func() error) error {
+	before, err := lockForAudit[T](tx, id)
+	if err != nil {
+		return err
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	if action == model.AuditActionDelete {
+		return recordChanges(tx, auditChange{entity, action, id, before, nil})
+	}
+	after, err := lockForAudit[T](tx, id)
+	if err != nil {
+		return err
+	}
+	if action == model.AuditActionRestore {
+		return recordChanges(tx, auditChange{entity, action, id, nil, after})
+	}
+	return recordChanges(tx, auditChange{entity, action, id, before, after})
+}
+
+// lockForAudit reads the row of model T with the given id, in the trash or
+// not, and locks it until tx ends.
+func lockForAudit[T any](tx *gorm.DB, id string) (*T, error) {
+	var row T
+	err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&row).Error
+	return &row, err
+}
+
+// auditProducts runs write on the live products matching query and records
+// the change of each of them, all within tx.
+func auditProducts(tx *gorm.DB, action string, write func(products *gorm.DB) error, query interface{}, args ...interface{}) error {
+	var before []model.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).Find(&before).Error; err != nil {
+		return err
+	}
+	if len(before) == 0 {
+		return nil
+	}
+	ids := make([]uuid.UUID, len(before))
+	for i, p := range before {
+		ids[i] = p.Id
+	}
+	if err := write(tx.Model(&model.Product{}).Where("id IN ?", ids)); err != nil {
+		return err
+	}
+
+	var after []model.Product
+	if action != model.AuditActionDelete {
+		if err := tx.Unscoped().Where("id IN ?", ids).Find(&after).Error; err != nil {
+			return err
+		}
+	}
+	afterById := make(map[uuid.UUID]model.Product, len(after))
+	for _, p := range after {
+		afterById[p.Id] = p
+	}
+	changes := make([]auditChange, 0, len(before))
+	for _, p := range before {
+		change := auditChange{model.AuditEntityProduct, action, p.Id.String(), p, nil}
+		if a, ok := afterById[p.Id]; ok {
+			change.after = a
+		}
+		changes = append(changes, change)
+	}
+	return recordChanges(tx, changes...)
+}
+
+// purgeAudited permanently deletes the rows of model T that scope selects,
+// in the trash or not, and records the purge of each, all in one
+// transaction. It returns how many rows were purged.
+func purgeAudited[T any](db *gorm.DB, entity string, idOf func(T) uuid.UUID, scope func(*gorm.DB) *gorm.DB) (int64, error) {
+	var purged []T
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := scope(tx.Unscoped().Clauses(clause.Returning{})).Delete(&purged).Error; err != nil {
+			return err
+		}
+		if len(purged) == 0 {
+			return nil
+		}
+		changes := make([]auditChange, len(purged))
+		for i, row := range purged {
+			changes[i] = auditChange{entity, model.AuditActionPurge, idOf(row).String(), row, nil}
+		}
+		return recordChanges(tx, changes...)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(purged)), nil
+}
+
+// auditChange is a change of the row id of entity. before is nil for a
+// creation and after for a deletion.
+type auditChange struct {
+	entity, action, id string
+	before, after      interface{}
+}
+
+// recordChanges appends changes to the audit log within tx, so that they are
+// kept only if the changes themselves are committed. The actor and request
+// ID are taken from the context of tx.
+func recordChanges(tx *gorm.DB, changes ...auditChange) error {
+	ctx := tx.Statement.Context
+	actor := auth.Actor(ctx)
+
+	entries := make([]model.AuditEntry, 0, len(changes))
+	for _, c := range changes {
+		id, err := uuid.Parse(c.id)
+		if err != nil {
+			return err
+		}
+		fields, err := diffFields(c.before, c.after)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, model.AuditEntry{
+			Actor:     actor,
+			RequestId: logging.RequestID(ctx),
+			Entity:    c.entity,
+			EntityId:  id,
+			Action:    c.action,
+			Changes:   fields,
+		})
+	}
+	return tx.Create(&entries).Error
+}
+
+// diffFields compares the JSON fields of before and after. Nested objects,
+// the embedded category and supplier of a product, are not fields of the
+// row and are left out, and so is the version, which changes on every write.
+func diffFields(before, after interface{}) (model.AuditChanges, error) {
+	b, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := model.AuditChanges{}
+	add := func(name string) {
+		bv, av := b[name], a[name]
+		if name == "version" || isObject(bv) || isObject(av) || reflect.DeepEqual(bv, av) {
+			return
+		}
+		changes[name] = model.FieldChange{Before: bv, After: av}
+	}
+	for name := range b {
+		add(name)
+	}
+	for name := range a {
+		if _, seen := b[name]; !seen {
+			add(name)
+		}
+	}
+	return changes, nil
+}
+
+func jsonFields(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+func isObject(v interface{}) bool {
+	_, ok := v.(map[string]interface{})
+	return ok
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+func TestDiffFieldsKeepsChangedFieldsOnly(t *testing.T) {
+	parent := uuid.New()
+	before := model.Category{Id: uuid.New(), Name: "Audio", Status: model.CategoryStatusActive, Version: 2}
+	after := before
+	after.Name = "Sound"
+	after.ParentId = &parent
+	after.Version = 3
+
+	changes, err := diffFields(before, after)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.AuditChanges{
+		"category_name": {Before: "Audio", After: "Sound"},
+		"parent_id":     {Before: nil, After: parent.String()},
+	}, changes)
+}
+
+func TestDiffFieldsOfDeletion(t *testing.T) {
+	supplier := model.Supplier{Id: uuid.New(), Name: "Acme", Version: 4}
+
+	changes, err := diffFields(supplier, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.AuditChanges{
+		"id":   {Before: supplier.Id.String(), After: nil},
+		"name": {Before: "Acme", After: nil},
+	}, changes)
+}
+
+func TestGetAuditEntriesAppliesFilter(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewAuditRepo(db)
+
+	entityID := uuid.New().String()
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT \* FROM "audit_log" WHERE entity = \$1 AND entity_id = \$2 AND actor = \$3 AND occurred_at >= \$4 AND id < \$5 ORDER BY id DESC LIMIT \$6`).
+		WithArgs(model.AuditEntityProduct, entityID, "alice", from, int64(90), 20).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity", "changes"}).
+			AddRow(89, model.AuditEntityProduct, []byte(`{"price":{"before":10,"after":12}}`)))
+
+	entries, err := repo.GetAuditEntries(context.Background(), model.AuditFilter{
+		Entity:   model.AuditEntityProduct,
+		EntityId: entityID,
+		Actor:    "alice",
+		From:     &from,
+		BeforeId: 90,
+		Limit:    20,
+	})
+
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, model.FieldChange{Before: float64(10), After: float64(12)}, entries[0].Changes["price"])
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectAuditLock expects the row of table with the given id to be read and
+// locked before it is changed.
+func expectAuditLock(mock sqlmock.Sqlmock, table, id string) {
+	mock.ExpectQuery(`SELECT \* FROM "`+table+`" WHERE id = \$1 ORDER BY "`+table+`"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(id, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+}
+
+// expectAuditEntries expects n entries to be appended to the audit log at
+// once.
+func expectAuditEntries(mock sqlmock.Sqlmock, n int) {
+	rows := sqlmock.NewRows([]string{"occurred_at", "id"})
+	for i := 1; i <= n; i++ {
+		rows.AddRow(time.Now(), i)
+	}
+	mock.ExpectQuery(`INSERT INTO "audit_log"`).WillReturnRows(rows)
+}
+
+func TestPurgeProductsRecordsEachPurge(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewProductRepo(db, nil)
+
+	cutoff := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	first, second := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM "products" WHERE deleted_at < \$1 RETURNING \*`).
+		WithArgs(cutoff).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(first, "Desk lamp").AddRow(second, "Floor lamp"))
+	mock.ExpectQuery(`INSERT INTO "audit_log" \("actor","request_id","entity","entity_id","action","changes"\)`).
+		WithArgs("system", "", model.AuditEntityProduct, first, model.AuditActionPurge, sqlmock.AnyArg(),
+			"system", "", model.AuditEntityProduct, second, model.AuditActionPurge, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"occurred_at", "id"}).AddRow(time.Now(), 1).AddRow(time.Now(), 2))
+	mock.ExpectCommit()
+
+	purged, err := repo.PurgeProducts(context.Background(), cutoff)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,16 +2,27 @@ package repository
 
 import (
 	"context"
+	"github.com/google/uuid"
 	"github.com/thinhpq0112/soa-backend/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
 type ICategoryRepo interface {
-	GetCategories(ctx context.Context) ([]model.Category, error)
+	GetCategories(ctx context.Context, statuses []string) ([]model.Category, error)
 	GetCategoryById(ctx context.Context, id string) (model.Category, error)
+	FindCategoriesByName(ctx context.Context, name string) ([]model.Category, error)
+	GetCategorySubtree(ctx context.Context, id string) ([]model.Category, error)
+	GetCategoryPath(ctx context.Context, id string) ([]model.Category, error)
 	AddCategory(ctx context.Context, category model.Category) error
-	UpdateCategory(ctx context.Context, category model.Category) error
-	DeleteCategory(ctx context.Context, id string) error
+	UpdateCategory(ctx context.Context, category model.Category) (model.Category, error)
+	SetCategoriesStatus(ctx context.Context, ids []string, status string) ([]model.Category, error)
+	DeleteCategory(ctx context.Context, id string, version int, actor string, policy model.DeletePolicy) error
+	GetDeletedCategories(ctx context.Context) ([]model.Category, error)
+	GetDeletedCategoryById(ctx context.Context, id string) (model.Category, error)
+	RestoreCategory(ctx context.Context, id string) error
+	PurgeCategories(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type CategoryRepo struct {
@@ -22,9 +33,15 @@ func NewCategoryRepo(db *gorm.DB) *CategoryRepo {
 	return &CategoryRepo{db: db}
 }
 
-func (r *CategoryRepo) GetCategories(ctx context.Context) ([]model.Category, error) {
+// GetCategories lists the categories having one of statuses, or all of them
+// when statuses is empty.
+func (r *CategoryRepo) GetCategories(ctx context.Context, statuses []string) ([]model.Category, error) {
 	var categories []model.Category
-	err := r.db.WithContext(ctx).Find(&categories).Error
+	query := r.db.WithContext(ctx)
+	if len(statuses) > 0 {
+		query = query.Where("status IN (?)", statuses)
+	}
+	err := query.Find(&categories).Error
 	return categories, err
 }
 
@@ -34,14 +51,217 @@ func (r *CategoryRepo) GetCategoryById(ctx context.Context, id string) (model.Ca
 	return category, err
 }
 
+// FindCategoriesByName matches names case-insensitively. Category names are
+// not unique, so it returns up to two matches to let callers detect
+// ambiguity.
+func (r *CategoryRepo) FindCategoriesByName(ctx context.Context, name string) ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.WithContext(ctx).Where("LOWER(name) = LOWER(?)", name).Limit(2).Find(&categories).Error
+	return categories, err
+}
+
+// GetCategorySubtree returns the category with the given id followed by all
+// its descendants, in no particular order.
+func (r *CategoryRepo) GetCategorySubtree(ctx context.Context, id string) ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.WithContext(ctx).Raw(`WITH RECURSIVE subtree AS (
+			SELECT * FROM categories WHERE id = ? AND deleted_at IS NULL
+			UNION
+			SELECT c.* FROM categories c JOIN subtree s ON c.parent_id = s.id WHERE c.deleted_at IS NULL
+		) SELECT * FROM subtree`, id).Scan(&categories).Error
+	return categories, err
+}
+
+// GetCategoryPath returns the ancestors of the category with the given id,
+// from the top-level one down to the category itself.
+func (r *CategoryRepo) GetCategoryPath(ctx context.Context, id string) ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.WithContext(ctx).Raw(`WITH RECURSIVE path AS (
+			SELECT categories.*, 0 AS depth FROM categories WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.*, p.depth + 1 FROM categories c JOIN path p ON c.id = p.parent_id
+			WHERE c.deleted_at IS NULL AND p.depth < ?
+		) SELECT * FROM path ORDER BY depth DESC`, id, maxCategoryDepth).Scan(&categories).Error
+	return categories, err
+}
+
+// AddCategory creates category. Its parent, if any, is checked under the
+// tree lock, so that it cannot be deleted before the category is committed.
 func (r *CategoryRepo) AddCategory(ctx context.Context, category model.Category) error {
-	return r.db.WithContext(ctx).Create(&category).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if category.ParentId != nil {
+			if err := lockCategoryTree(tx); err != nil {
+				return err
+			}
+			if err := checkCategoryParent(tx, category.Id, *category.ParentId); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(&category).Error; err != nil {
+			return err
+		}
+		return recordChanges(tx, auditChange{model.AuditEntityCategory, model.AuditActionCreate, category.Id.String(), nil, category})
+	})
+}
+
+// UpdateCategory writes every field of category but its status and creation
+// time, and returns it with its new version. A positive category.Version
+// makes the write conditional on the stored version.
+func (r *CategoryRepo) UpdateCategory(ctx context.Context, category model.Category) (model.Category, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		id := category.Id.String()
+		// Like DeleteCategory, take the tree lock before the row lock.
+		if category.ParentId != nil {
+			if err := lockCategoryTree(tx); err != nil {
+				return err
+			}
+		}
+		return audited[model.Category](tx, model.AuditEntityCategory, model.AuditActionUpdate, id, func() error {
+			version, err := bumpVersion(tx, "categories", id, category.Version)
+			if err != nil {
+				return err
+			}
+			category.Version = version
+			if category.ParentId != nil {
+				if err := checkCategoryParent(tx, category.Id, *category.ParentId); err != nil {
+					return err
+				}
+			}
+			return tx.Model(&category).Select("*").Omit("id", "version", "status", "created_at", "deleted_at", "deleted_by").Updates(&category).Error
+		})
+	})
+	if err != nil {
+		return model.Category{}, err
+	}
+	return r.GetCategoryById(ctx, category.Id.String())
 }
 
-func (r *CategoryRepo) UpdateCategory(ctx context.Context, category model.Category) error {
-	return r.db.WithContext(ctx).Save(&category).Error
+// SetCategoriesStatus changes the status of the categories with the given
+// ids and returns them. Either all of them are changed or, when one is
+// missing, none is and gorm.ErrRecordNotFound is returned.
+func (r *CategoryRepo) SetCategoriesStatus(ctx context.Context, ids []string, status string) ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var before []model.Category
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN (?)", ids).Find(&before).Error; err != nil {
+			return err
+		}
+		if len(before) != len(ids) {
+			return gorm.ErrRecordNotFound
+		}
+		err := tx.Model(&model.Category{}).Where("id IN (?)", ids).Updates(map[string]interface{}{
+			"status":  status,
+			"version": gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("id IN (?)", ids).Find(&categories).Error; err != nil {
+			return err
+		}
+		beforeById := make(map[uuid.UUID]model.Category, len(before))
+		for _, c := range before {
+			beforeById[c.Id] = c
+		}
+		changes := make([]auditChange, 0, len(categories))
+		for _, c := range categories {
+			changes = append(changes, auditChange{model.AuditEntityCategory, model.AuditActionUpdate, c.Id.String(), beforeById[c.Id], c})
+		}
+		return recordChanges(tx, changes...)
+	})
+	return categories, err
+}
+
+// DeleteCategory moves the category to the trash and applies policy to its
+// products, in one transaction. The restrict policy fails with an
+// *InUseError while products that are not in the trash belong to it, and
+// any policy fails with ErrHasChildren while it has subcategories.
+func (r *CategoryRepo) DeleteCategory(ctx context.Context, id string, version int, actor string, policy model.DeletePolicy) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockCategoryTree(tx); err != nil {
+			return err
+		}
+		var children int64
+		if err := tx.Model(&model.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			return ErrHasChildren
+		}
+		return deleteWithProducts[model.Category](tx, model.AuditEntityCategory, "categories", "category_id", id, version, actor, policy)
+	})
+}
+
+// GetDeletedCategories lists the trash, most recently deleted first.
+func (r *CategoryRepo) GetDeletedCategories(ctx context.Context) ([]model.Category, error) {
+	var categories []model.Category
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&categories).Error
+	return categories, err
+}
+
+func (r *CategoryRepo) GetDeletedCategoryById(ctx context.Context, id string) (model.Category, error) {
+	var category model.Category
+	err := r.db.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&category).Error
+	return category, err
+}
+
+func (r *CategoryRepo) RestoreCategory(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return audited[model.Category](tx, model.AuditEntityCategory, model.AuditActionRestore, id, func() error {
+			return restore(tx, &model.Category{}, id)
+		})
+	})
+}
+
+// PurgeCategories permanently deletes the categories put in the trash before
+// deletedBefore. Those still referenced by a product, even one in the trash,
+// are kept until the product is purged. Each purge is recorded in the audit
+// log.
+func (r *CategoryRepo) PurgeCategories(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return purgeAudited(r.db.WithContext(ctx), model.AuditEntityCategory,
+		func(category model.Category) uuid.UUID { return category.Id },
+		func(db *gorm.DB) *gorm.DB {
+			return db.Where("deleted_at < ?", deletedBefore).
+				Where("NOT EXISTS (SELECT 1 FROM products WHERE products.category_id = categories.id)")
+		})
+}
+
+// maxCategoryDepth bounds the walks up the tree.
+const maxCategoryDepth = 100
+
+// lockCategoryTree serializes the transactions that add, move or delete
+// categories until they end, so that two concurrent moves cannot create a
+// cycle together and a category cannot be put under one being deleted. It
+// is taken before any row lock.
+func lockCategoryTree(tx *gorm.DB) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext('categories_tree'))").Error
 }
 
-func (r *CategoryRepo) DeleteCategory(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.Category{}, id).Error
+// checkCategoryParent fails with ErrUnknownParent when parentId is not
+// a live category, and with ErrCategoryCycle when it is id or one of its
+// descendants, trashed ones included. The caller holds the tree lock.
+func checkCategoryParent(tx *gorm.DB, id, parentId uuid.UUID) error {
+	var parents int64
+	if err := tx.Model(&model.Category{}).Where("id = ?", parentId).Count(&parents).Error; err != nil {
+		return err
+	}
+	if parents == 0 {
+		return ErrUnknownParent
+	}
+	var cycles int64
+	err := tx.Raw(`WITH RECURSIVE subtree AS (
+			SELECT id FROM categories WHERE id = ?
+			UNION
+			SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+		) SELECT count(*) FROM subtree WHERE id = ?`, id, parentId).Scan(&cycles).Error
+	if err != nil {
+		return err
+	}
+	if cycles > 0 {
+		return ErrCategoryCycle
+	}
+	return nil
 }
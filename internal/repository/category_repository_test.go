@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+func TestDeleteCategoryRestrictCountsProducts(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewCategoryRepo(db)
+
+	categoryID := uuid.New().String()
+
+	mock.ExpectBegin()
+	expectSubcategoryCheck(mock, categoryID, 0)
+	expectAuditLock(mock, "categories", categoryID)
+	mock.ExpectExec(`UPDATE "categories" SET "deleted_at"=now\(\),"deleted_by"=\$1,"version"=version \+ 1,"updated_at"=\$2 WHERE id = \$3 AND "categories"."deleted_at" IS NULL`).
+		WithArgs("alice", sqlmock.AnyArg(), categoryID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAuditEntries(mock, 1)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "products" WHERE category_id = \$1 AND "products"."deleted_at" IS NULL`).
+		WithArgs(categoryID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectRollback()
+
+	err := repo.DeleteCategory(context.Background(), categoryID, 0, "alice", model.DeletePolicy{Mode: model.DeleteRestrict})
+
+	var inUse *InUseError
+	require.ErrorAs(t, err, &inUse)
+	assert.ErrorIs(t, err, ErrInUse)
+	assert.Equal(t, int64(3), inUse.Products)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCategoryReassignsProducts(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewCategoryRepo(db)
+
+	categoryID := uuid.New().String()
+	targetID := uuid.New().String()
+	productA, productB := uuid.New().String(), uuid.New().String()
+
+	mock.ExpectBegin()
+	expectSubcategoryCheck(mock, categoryID, 0)
+	expectAuditLock(mock, "categories", categoryID)
+	mock.ExpectExec(`UPDATE "categories" SET "deleted_at"=now\(\),"deleted_by"=\$1,"version"=version \+ 1,"updated_at"=\$2 WHERE id = \$3 AND version = \$4 AND "categories"."deleted_at" IS NULL`).
+		WithArgs("alice", sqlmock.AnyArg(), categoryID, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAuditEntries(mock, 1)
+	mock.ExpectQuery(`SELECT "id" FROM "categories" WHERE id = \$1 AND id <> \$2 AND deleted_at IS NULL FOR SHARE`).
+		WithArgs(targetID, categoryID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(targetID))
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE category_id = \$1 AND "products"."deleted_at" IS NULL FOR UPDATE`).
+		WithArgs(categoryID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id"}).AddRow(productA, categoryID).AddRow(productB, categoryID))
+	mock.ExpectExec(`UPDATE "products" SET "category_id"=\$1,"version"=version \+ 1 WHERE id IN \(\$2,\$3\) AND "products"."deleted_at" IS NULL`).
+		WithArgs(targetID, productA, productB).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id IN \(\$1,\$2\)`).
+		WithArgs(productA, productB).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id"}).AddRow(productA, targetID).AddRow(productB, targetID))
+	expectAuditEntries(mock, 2)
+	mock.ExpectCommit()
+
+	err := repo.DeleteCategory(context.Background(), categoryID, 2, "alice", model.DeletePolicy{Mode: model.DeleteReassign, ReassignTo: targetID})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCategoryRejectsMissingReassignTarget(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewCategoryRepo(db)
+
+	categoryID := uuid.New().String()
+	targetID := uuid.New().String()
+
+	mock.ExpectBegin()
+	expectSubcategoryCheck(mock, categoryID, 0)
+	expectAuditLock(mock, "categories", categoryID)
+	mock.ExpectExec(`UPDATE "categories" SET "deleted_at"=now\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAuditEntries(mock, 1)
+	mock.ExpectQuery(`SELECT "id" FROM "categories"`).
+		WithArgs(targetID, categoryID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	err := repo.DeleteCategory(context.Background(), categoryID, 0, "alice", model.DeletePolicy{Mode: model.DeleteReassign, ReassignTo: targetID})
+
+	assert.ErrorIs(t, err, ErrInvalidReassignTarget)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteCategoryRefusesSubcategories(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewCategoryRepo(db)
+
+	categoryID := uuid.New().String()
+
+	mock.ExpectBegin()
+	expectSubcategoryCheck(mock, categoryID, 2)
+	mock.ExpectRollback()
+
+	err := repo.DeleteCategory(context.Background(), categoryID, 0, "alice", model.DeletePolicy{Mode: model.DeleteArchive})
+
+	assert.ErrorIs(t, err, ErrHasChildren)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateCategoryRejectsCycle(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewCategoryRepo(db)
+
+	categoryID := uuid.New()
+	parentID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectAuditLock(mock, "categories", categoryID.String())
+	mock.ExpectQuery(`UPDATE categories SET version = version \+ 1`).
+		WithArgs(categoryID.String(), 0, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(2))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "categories" WHERE id = \$1 AND "categories"."deleted_at" IS NULL`).
+		WithArgs(parentID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`WITH RECURSIVE subtree AS`).
+		WithArgs(categoryID, parentID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	_, err := repo.UpdateCategory(context.Background(), model.Category{Id: categoryID, Name: "Audio", ParentId: &parentID})
+
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddCategoryRejectsDeletedParent(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewCategoryRepo(db)
+
+	parentID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "categories" WHERE id = \$1 AND "categories"."deleted_at" IS NULL`).
+		WithArgs(parentID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectRollback()
+
+	err := repo.AddCategory(context.Background(), model.Category{Name: "Headphones", ParentId: &parentID})
+
+	assert.ErrorIs(t, err, ErrUnknownParent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func expectSubcategoryCheck(mock sqlmock.Sqlmock, categoryID string, children int) {
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "categories" WHERE parent_id = \$1 AND "categories"."deleted_at" IS NULL`).
+		WithArgs(categoryID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(children))
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type IIdempotencyRepo interface {
+	// ReserveKey inserts key unless the owner already used it, and reports
+	// whether it did.
+	ReserveKey(ctx context.Context, key model.IdempotencyKey) (bool, error)
+	GetKey(ctx context.Context, owner, key string) (model.IdempotencyKey, error)
+	CompleteKey(ctx context.Context, owner, key string, statusCode int, contentType string, body []byte) error
+	DeleteKey(ctx context.Context, owner, key string) error
+	DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error)
+}
+
+type idempotencyRepo struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepo(db *gorm.DB) *idempotencyRepo {
+	return &idempotencyRepo{db: db}
+}
+
+func (r *idempotencyRepo) ReserveKey(ctx context.Context, key model.IdempotencyKey) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&key)
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *idempotencyRepo) GetKey(ctx context.Context, owner, key string) (model.IdempotencyKey, error) {
+	var record model.IdempotencyKey
+	err := r.db.WithContext(ctx).Where("owner = ? AND key = ?", owner, key).First(&record).Error
+	return record, err
+}
+
+func (r *idempotencyRepo) CompleteKey(ctx context.Context, owner, key string, statusCode int, contentType string, body []byte) error {
+	result := r.db.WithContext(ctx).Model(&model.IdempotencyKey{}).
+		Where("owner = ? AND key = ?", owner, key).
+		Updates(map[string]interface{}{
+			"status_code":  statusCode,
+			"content_type": contentType,
+			"body":         body,
+		})
+	return affectedOne(result)
+}
+
+func (r *idempotencyRepo) DeleteKey(ctx context.Context, owner, key string) error {
+	return r.db.WithContext(ctx).Where("owner = ? AND key = ?", owner, key).Delete(&model.IdempotencyKey{}).Error
+}
+
+func (r *idempotencyRepo) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&model.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/logging"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type IInventoryRepo interface {
+	AddMovement(ctx context.Context, movement model.InventoryMovement, now time.Time) (model.InventoryMovement, error)
+	TransferStock(ctx context.Context, productId uuid.UUID, transfer model.TransferRequest) (model.TransferResponse, error)
+	GetMovements(ctx context.Context, productId string, filter model.MovementFilter) ([]model.InventoryMovement, error)
+}
+
+type inventoryRepo struct {
+	db    *gorm.DB
+	watch StockWatch
+}
+
+func NewInventoryRepo(db *gorm.DB, watch StockWatch) *inventoryRepo {
+	return &inventoryRepo{db: db, watch: watch}
+}
+
+// AddMovement adds movement to the stock ledger and applies it to the stock
+// of its product in its warehouse and to the total quantity of the product,
+// in one transaction. It fails with gorm.ErrRecordNotFound when the product
+// does not exist or is in the trash, with ErrUnknownWarehouse when the
+// warehouse does not exist, and with an *InsufficientStockError rather than
+// take the stock of the warehouse below zero or the total quantity of the
+// product below what reservations active at now hold. Reserved stock only
+// goes out by confirming its reservation.
+func (r *inventoryRepo) AddMovement(ctx context.Context, movement model.InventoryMovement, now time.Time) (model.InventoryMovement, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		product, err := lockLiveProduct(tx, movement.ProductId.String())
+		if err != nil {
+			return err
+		}
+		if movement.Quantity < 0 {
+			reserved, err := reservedQuantity(tx, product.Id, now)
+			if err != nil {
+				return err
+			}
+			if product.Quantity+movement.Quantity < reserved {
+				return &InsufficientStockError{Available: max(product.Quantity-reserved, 0), Reserved: reserved}
+			}
+		}
+		return applyMovement(tx, product, &movement)
+	})
+	return movement, r.watch.after(err)
+}
+
+// TransferStock moves stock of a product from one warehouse to another in
+// one transaction, recording a transfer out of the first and a transfer into
+// the second. The total quantity of the product does not change, so neither
+// does what reservations hold of it. It fails like AddMovement.
+func (r *inventoryRepo) TransferStock(ctx context.Context, productId uuid.UUID, transfer model.TransferRequest) (model.TransferResponse, error) {
+	resp := model.TransferResponse{
+		Out: model.InventoryMovement{
+			ProductId:   productId,
+			WarehouseId: &transfer.FromWarehouseId,
+			Kind:        model.MovementTransfer,
+			Quantity:    -transfer.Quantity,
+			Reason:      transfer.Reason,
+			Reference:   transfer.Reference,
+		},
+		In: model.InventoryMovement{
+			ProductId:   productId,
+			WarehouseId: &transfer.ToWarehouseId,
+			Kind:        model.MovementTransfer,
+			Quantity:    transfer.Quantity,
+			Reason:      transfer.Reason,
+			Reference:   transfer.Reference,
+		},
+	}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		id := productId.String()
+		product, err := lockLiveProduct(tx, id)
+		if err != nil {
+			return err
+		}
+		for _, movement := range []*model.InventoryMovement{&resp.Out, &resp.In} {
+			if err := moveStock(tx, productId, *movement.WarehouseId, movement.Quantity); err != nil {
+				return err
+			}
+			movement.QuantityAfter = product.Quantity
+			if err := createMovement(tx, movement); err != nil {
+				return err
+			}
+		}
+		// The breakdown of the stock is part of the product, so its version
+		// changes even though its row does not.
+		return tx.Model(&model.Product{}).Where("id = ?", id).Update("version", gorm.Expr("version + 1")).Error
+	})
+	return resp, err
+}
+
+// GetMovements lists the movements of a product matching filter, newest
+// first. Products in the trash keep their history; it fails with
+// gorm.ErrRecordNotFound only for products that do not exist at all.
+func (r *inventoryRepo) GetMovements(ctx context.Context, productId string, filter model.MovementFilter) ([]model.InventoryMovement, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Unscoped().Model(&model.Product{}).Where("id = ?", productId).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	query := r.db.WithContext(ctx).Where("product_id = ?", productId)
+	if filter.From != nil {
+		query = query.Where("occurred_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("occurred_at < ?", *filter.To)
+	}
+	if filter.BeforeId > 0 {
+		query = query.Where("id < ?", filter.BeforeId)
+	}
+	var movements []model.InventoryMovement
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&movements).Error
+	return movements, err
+}
+
+// recordOpeningStock puts the stock product is created with in its
+// warehouses and records each part as an opening balance, within tx.
+func recordOpeningStock(tx *gorm.DB, product model.Product, stock []model.WarehouseStock) error {
+	total := 0
+	for _, s := range stock {
+		if s.Quantity == 0 {
+			continue
+		}
+		if err := moveStock(tx, product.Id, s.WarehouseId, s.Quantity); err != nil {
+			return err
+		}
+		total += s.Quantity
+		err := createMovement(tx, &model.InventoryMovement{
+			ProductId:     product.Id,
+			WarehouseId:   &s.WarehouseId,
+			Kind:          model.MovementAdjustment,
+			Quantity:      s.Quantity,
+			QuantityAfter: total,
+			Reason:        model.ReasonOpeningBalance,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyMovement adds movement to the stock ledger and applies it to the
+// stock of product, which tx holds the lock on, in the warehouse of the
+// movement and to its total quantity.
+func applyMovement(tx *gorm.DB, product *model.Product, movement *model.InventoryMovement) error {
+	if movement.WarehouseId == nil {
+		return ErrUnknownWarehouse
+	}
+	if err := moveStock(tx, product.Id, *movement.WarehouseId, movement.Quantity); err != nil {
+		return err
+	}
+	id := product.Id.String()
+	after := *product
+	after.Quantity += movement.Quantity
+	err := tx.Model(&model.Product{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"quantity": after.Quantity,
+		"version":  gorm.Expr("version + 1"),
+	}).Error
+	if err != nil {
+		return err
+	}
+	movement.QuantityAfter = after.Quantity
+	if err := createMovement(tx, movement); err != nil {
+		return err
+	}
+	return recordChanges(tx, auditChange{model.AuditEntityProduct, model.AuditActionUpdate, id, product, after})
+}
+
+// lockLiveProduct reads the product with the given id and locks it until tx
+// ends, which serializes the changes of its stock. It fails with
+// gorm.ErrRecordNotFound when the product does not exist or is in the trash.
+func lockLiveProduct(tx *gorm.DB, id string) (*model.Product, error) {
+	product, err := lockForAudit[model.Product](tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if product.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return product, nil
+}
+
+// moveStock adds quantity, negative to take stock out, to the stock of a
+// product in a warehouse, within tx. The caller holds the lock on the
+// product. The warehouse is share-locked so that it cannot be deleted before
+// tx ends.
+func moveStock(tx *gorm.DB, productId, warehouseId uuid.UUID, quantity int) error {
+	var warehouses []string
+	err := tx.Table("warehouses").Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("id = ?", warehouseId).
+		Pluck("id", &warehouses).Error
+	if err != nil {
+		return err
+	}
+	if len(warehouses) == 0 {
+		return ErrUnknownWarehouse
+	}
+
+	var current []model.WarehouseStock
+	err = tx.Where("product_id = ? AND warehouse_id = ?", productId, warehouseId).Find(&current).Error
+	if err != nil {
+		return err
+	}
+	available := 0
+	if len(current) > 0 {
+		available = current[0].Quantity
+	}
+	if available+quantity < 0 {
+		return &InsufficientStockError{Available: available}
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "product_id"}, {Name: "warehouse_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"quantity"}),
+	}).Create(&model.WarehouseStock{ProductId: productId, WarehouseId: warehouseId, Quantity: available + quantity}).Error
+}
+
+// createMovement inserts movement, made by the caller ctx of tx belongs to.
+func createMovement(tx *gorm.DB, movement *model.InventoryMovement) error {
+	ctx := tx.Statement.Context
+	movement.Actor = auth.Actor(ctx)
+	movement.RequestId = logging.RequestID(ctx)
+	return tx.Create(movement).Error
+}
@@ -0,0 +1,234 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+// expectMoveStock expects the stock of a product in a warehouse to go from
+// available to after.
+func expectMoveStock(mock sqlmock.Sqlmock, productID, warehouseID uuid.UUID, available, after int) {
+	mock.ExpectQuery(`SELECT "id" FROM "warehouses" WHERE id = \$1 FOR SHARE`).
+		WithArgs(warehouseID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(warehouseID.String()))
+	rows := sqlmock.NewRows([]string{"product_id", "warehouse_id", "quantity"})
+	if available != 0 {
+		rows.AddRow(productID, warehouseID, available)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "warehouse_stock" WHERE product_id = \$1 AND warehouse_id = \$2`).
+		WithArgs(productID, warehouseID).
+		WillReturnRows(rows)
+	mock.ExpectExec(`INSERT INTO "warehouse_stock" \("product_id","warehouse_id","quantity"\) VALUES \(\$1,\$2,\$3\) ON CONFLICT \("product_id","warehouse_id"\) DO UPDATE SET "quantity"="excluded"."quantity"`).
+		WithArgs(productID, warehouseID, after).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestAddMovementUpdatesStockInTransaction(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewInventoryRepo(db, nil)
+
+	productID := uuid.New()
+	warehouseID := uuid.New()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity"}).AddRow(productID, 12))
+	expectReserved(mock, productID, now, 4)
+	expectMoveStock(mock, productID, warehouseID, 8, 3)
+	mock.ExpectExec(`UPDATE "products" SET "quantity"=\$1,"version"=version \+ 1 WHERE id = \$2 AND "products"."deleted_at" IS NULL`).
+		WithArgs(7, productID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "inventory_movements"`).
+		WithArgs(productID, warehouseID, model.MovementShipment, -5, 7, "order 1042", "SO-1042", "system", "").
+		WillReturnRows(sqlmock.NewRows([]string{"occurred_at", "id"}).AddRow(time.Now(), 31))
+	mock.ExpectQuery(`INSERT INTO "audit_log"`).
+		WithArgs("system", "", model.AuditEntityProduct, productID, model.AuditActionUpdate, `{"quantity":{"before":12,"after":7}}`).
+		WillReturnRows(sqlmock.NewRows([]string{"occurred_at", "id"}).AddRow(time.Now(), 1))
+	mock.ExpectCommit()
+
+	movement, err := repo.AddMovement(context.Background(), model.InventoryMovement{
+		ProductId:   productID,
+		WarehouseId: &warehouseID,
+		Kind:        model.MovementShipment,
+		Quantity:    -5,
+		Reason:      "order 1042",
+		Reference:   "SO-1042",
+	}, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(31), movement.Id)
+	assert.Equal(t, 7, movement.QuantityAfter)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddMovementRefusesNegativeStock(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewInventoryRepo(db, nil)
+
+	productID := uuid.New()
+	warehouseID := uuid.New()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity"}).AddRow(productID, 10))
+	expectReserved(mock, productID, now, 0)
+	mock.ExpectQuery(`SELECT "id" FROM "warehouses" WHERE id = \$1 FOR SHARE`).
+		WithArgs(warehouseID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(warehouseID.String()))
+	mock.ExpectQuery(`SELECT \* FROM "warehouse_stock"`).
+		WithArgs(productID, warehouseID).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "warehouse_id", "quantity"}).AddRow(productID, warehouseID, 2))
+	mock.ExpectRollback()
+
+	_, err := repo.AddMovement(context.Background(), model.InventoryMovement{
+		ProductId:   productID,
+		WarehouseId: &warehouseID,
+		Kind:        model.MovementAdjustment,
+		Quantity:    -3,
+		Reason:      "stock count",
+	}, now)
+
+	var insufficient *InsufficientStockError
+	require.ErrorAs(t, err, &insufficient)
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	assert.Equal(t, 2, insufficient.Available)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddMovementRefusesReservedStock(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewInventoryRepo(db, nil)
+
+	productID := uuid.New()
+	warehouseID := uuid.New()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity"}).AddRow(productID, 10))
+	expectReserved(mock, productID, now, 8)
+	mock.ExpectRollback()
+
+	_, err := repo.AddMovement(context.Background(), model.InventoryMovement{
+		ProductId:   productID,
+		WarehouseId: &warehouseID,
+		Kind:        model.MovementShipment,
+		Quantity:    -3,
+		Reason:      "walk-in sale",
+	}, now)
+
+	var insufficient *InsufficientStockError
+	require.ErrorAs(t, err, &insufficient)
+	assert.Equal(t, 2, insufficient.Available)
+	assert.Equal(t, 8, insufficient.Reserved)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddMovementUnknownWarehouse(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewInventoryRepo(db, nil)
+
+	productID := uuid.New()
+	warehouseID := uuid.New()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	expectAuditLock(mock, "products", productID.String())
+	mock.ExpectQuery(`SELECT "id" FROM "warehouses" WHERE id = \$1 FOR SHARE`).
+		WithArgs(warehouseID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	_, err := repo.AddMovement(context.Background(), model.InventoryMovement{
+		ProductId:   productID,
+		WarehouseId: &warehouseID,
+		Kind:        model.MovementReceipt,
+		Quantity:    4,
+		Reason:      "delivery",
+	}, now)
+
+	assert.ErrorIs(t, err, ErrUnknownWarehouse)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransferStockMovesBetweenWarehouses(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewInventoryRepo(db, nil)
+
+	productID := uuid.New()
+	from, to := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity"}).AddRow(productID, 12))
+	expectMoveStock(mock, productID, from, 7, 3)
+	mock.ExpectQuery(`INSERT INTO "inventory_movements"`).
+		WithArgs(productID, from, model.MovementTransfer, -4, 12, "rebalancing", "TR-7", "system", "").
+		WillReturnRows(sqlmock.NewRows([]string{"occurred_at", "id"}).AddRow(time.Now(), 40))
+	expectMoveStock(mock, productID, to, 5, 9)
+	mock.ExpectQuery(`INSERT INTO "inventory_movements"`).
+		WithArgs(productID, to, model.MovementTransfer, 4, 12, "rebalancing", "TR-7", "system", "").
+		WillReturnRows(sqlmock.NewRows([]string{"occurred_at", "id"}).AddRow(time.Now(), 41))
+	mock.ExpectExec(`UPDATE "products" SET "version"=version \+ 1 WHERE id = \$1`).
+		WithArgs(productID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	resp, err := repo.TransferStock(context.Background(), productID, model.TransferRequest{
+		FromWarehouseId: from,
+		ToWarehouseId:   to,
+		Quantity:        4,
+		Reason:          "rebalancing",
+		Reference:       "TR-7",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(40), resp.Out.Id)
+	assert.Equal(t, &from, resp.Out.WarehouseId)
+	assert.Equal(t, int64(41), resp.In.Id)
+	assert.Equal(t, 4, resp.In.Quantity)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransferStockRefusesMoreThanSourceHolds(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewInventoryRepo(db, nil)
+
+	productID := uuid.New()
+	from, to := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	expectAuditLock(mock, "products", productID.String())
+	mock.ExpectQuery(`SELECT "id" FROM "warehouses" WHERE id = \$1 FOR SHARE`).
+		WithArgs(from).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(from.String()))
+	mock.ExpectQuery(`SELECT \* FROM "warehouse_stock"`).
+		WithArgs(productID, from).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "warehouse_id", "quantity"}).AddRow(productID, from, 3))
+	mock.ExpectRollback()
+
+	_, err := repo.TransferStock(context.Background(), productID, model.TransferRequest{
+		FromWarehouseId: from,
+		ToWarehouseId:   to,
+		Quantity:        4,
+		Reason:          "rebalancing",
+	})
+
+	var insufficient *InsufficientStockError
+	require.ErrorAs(t, err, &insufficient)
+	assert.Equal(t, 3, insufficient.Available)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
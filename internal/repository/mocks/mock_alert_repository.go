@@ -0,0 +1,53 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+type MockAlertRepo struct {
+	mock.Mock
+}
+
+func (m *MockAlertRepo) RaiseAlerts(ctx context.Context, now time.Time) ([]model.StockAlert, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).([]model.StockAlert), args.Error(1)
+}
+
+func (m *MockAlertRepo) RecoverAlerts(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockAlertRepo) GetAlerts(ctx context.Context, filter model.AlertFilter) ([]model.StockAlert, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]model.StockAlert), args.Error(1)
+}
+
+func (m *MockAlertRepo) GetAlertById(ctx context.Context, id int64) (model.StockAlert, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.StockAlert), args.Error(1)
+}
+
+func (m *MockAlertRepo) GetUndeliveredAlerts(ctx context.Context, limit int) ([]model.StockAlert, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]model.StockAlert), args.Error(1)
+}
+
+func (m *MockAlertRepo) MarkAlertDelivered(ctx context.Context, id int64, now time.Time) error {
+	args := m.Called(ctx, id, now)
+	return args.Error(0)
+}
+
+func (m *MockAlertRepo) AcknowledgeAlert(ctx context.Context, id int64, now time.Time) (model.StockAlert, error) {
+	args := m.Called(ctx, id, now)
+	return args.Get(0).(model.StockAlert), args.Error(1)
+}
+
+func (m *MockAlertRepo) ResolveAlert(ctx context.Context, id int64, now time.Time) (model.StockAlert, error) {
+	args := m.Called(ctx, id, now)
+	return args.Get(0).(model.StockAlert), args.Error(1)
+}
@@ -0,0 +1,48 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+type MockAPIKeyRepo struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepo) GetAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) GetAPIKeyById(ctx context.Context, id string) (model.APIKey, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(model.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepo) AddAPIKey(ctx context.Context, key model.APIKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) RotateAPIKey(ctx context.Context, id, prefix, hash string) error {
+	args := m.Called(ctx, id, prefix, hash)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) RevokeAPIKey(ctx context.Context, id string, revokedAt time.Time) error {
+	args := m.Called(ctx, id, revokedAt)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepo) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	args := m.Called(ctx, id, usedAt)
+	return args.Error(0)
+}
@@ -0,0 +1,78 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+type MockCategoryRepo struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepo) GetCategories(ctx context.Context, statuses []string) ([]model.Category, error) {
+	args := m.Called(ctx, statuses)
+	return args.Get(0).([]model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) GetCategoryById(ctx context.Context, id string) (model.Category, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) FindCategoriesByName(ctx context.Context, name string) ([]model.Category, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).([]model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) GetCategorySubtree(ctx context.Context, id string) ([]model.Category, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) GetCategoryPath(ctx context.Context, id string) ([]model.Category, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) AddCategory(ctx context.Context, category model.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepo) UpdateCategory(ctx context.Context, category model.Category) (model.Category, error) {
+	args := m.Called(ctx, category)
+	return args.Get(0).(model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) SetCategoriesStatus(ctx context.Context, ids []string, status string) ([]model.Category, error) {
+	args := m.Called(ctx, ids, status)
+	return args.Get(0).([]model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) DeleteCategory(ctx context.Context, id string, version int, actor string, policy model.DeletePolicy) error {
+	args := m.Called(ctx, id, version, actor, policy)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepo) GetDeletedCategories(ctx context.Context) ([]model.Category, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) GetDeletedCategoryById(ctx context.Context, id string) (model.Category, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepo) RestoreCategory(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepo) PurgeCategories(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
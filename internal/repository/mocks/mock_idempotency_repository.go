@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+type MockIdempotencyRepo struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepo) ReserveKey(ctx context.Context, key model.IdempotencyKey) (bool, error) {
+	args := m.Called(ctx, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) GetKey(ctx context.Context, owner, key string) (model.IdempotencyKey, error) {
+	args := m.Called(ctx, owner, key)
+	return args.Get(0).(model.IdempotencyKey), args.Error(1)
+}
+
+func (m *MockIdempotencyRepo) CompleteKey(ctx context.Context, owner, key string, statusCode int, contentType string, body []byte) error {
+	args := m.Called(ctx, owner, key, statusCode, contentType, body)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepo) DeleteKey(ctx context.Context, owner, key string) error {
+	args := m.Called(ctx, owner, key)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepo) DeleteExpiredKeys(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+type MockInventoryRepo struct {
+	mock.Mock
+}
+
+func (m *MockInventoryRepo) AddMovement(ctx context.Context, movement model.InventoryMovement, now time.Time) (model.InventoryMovement, error) {
+	args := m.Called(ctx, movement, now)
+	return args.Get(0).(model.InventoryMovement), args.Error(1)
+}
+
+func (m *MockInventoryRepo) TransferStock(ctx context.Context, productId uuid.UUID, transfer model.TransferRequest) (model.TransferResponse, error) {
+	args := m.Called(ctx, productId, transfer)
+	return args.Get(0).(model.TransferResponse), args.Error(1)
+}
+
+func (m *MockInventoryRepo) GetMovements(ctx context.Context, productId string, filter model.MovementFilter) ([]model.InventoryMovement, error) {
+	args := m.Called(ctx, productId, filter)
+	return args.Get(0).([]model.InventoryMovement), args.Error(1)
+}
@@ -0,0 +1,28 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+type MockPriceRepo struct {
+	mock.Mock
+}
+
+func (m *MockPriceRepo) GetPrices(ctx context.Context, productId string) ([]model.ProductPrice, error) {
+	args := m.Called(ctx, productId)
+	return args.Get(0).([]model.ProductPrice), args.Error(1)
+}
+
+func (m *MockPriceRepo) SchedulePrice(ctx context.Context, price model.ProductPrice) (model.ProductPrice, error) {
+	args := m.Called(ctx, price)
+	return args.Get(0).(model.ProductPrice), args.Error(1)
+}
+
+func (m *MockPriceRepo) ApplyScheduledPrices(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
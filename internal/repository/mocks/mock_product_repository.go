@@ -17,6 +17,11 @@ func (m *MockProductRepo) GetProductById(ctx context.Context, id string) (model.
 	return args.Get(0).(model.Product), args.Error(1)
 }
 
+func (m *MockProductRepo) GetProductAsOf(ctx context.Context, id string, asOf time.Time) (model.Product, error) {
+	args := m.Called(ctx, id, asOf)
+	return args.Get(0).(model.Product), args.Error(1)
+}
+
 func (m *MockProductRepo) AddProduct(ctx context.Context, product model.Product) error {
 	args := m.Called(ctx, product)
 	return args.Error(0)
@@ -27,18 +32,43 @@ func (m *MockProductRepo) GetProducts(ctx context.Context, pageNumber, limit *in
 	return args.Get(0).([]model.Product), args.Error(1)
 }
 
-func (m *MockProductRepo) DeleteProduct(ctx context.Context, id string) error {
+func (m *MockProductRepo) DeleteProduct(ctx context.Context, id string, version int, actor string) error {
+	args := m.Called(ctx, id, version, actor)
+	return args.Error(0)
+}
+
+func (m *MockProductRepo) GetDeletedProducts(ctx context.Context) ([]model.Product, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Product), args.Error(1)
+}
+
+func (m *MockProductRepo) GetDeletedProductById(ctx context.Context, id string) (model.Product, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Product), args.Error(1)
+}
+
+func (m *MockProductRepo) RestoreProduct(ctx context.Context, id string) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
 }
 
+func (m *MockProductRepo) PurgeProducts(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *MockProductRepo) UpdateProduct(ctx context.Context, product model.Product) error {
 	args := m.Called(ctx, product)
 	return args.Error(0)
 }
 
-func (m *MockProductRepo) GetProductsPerCategory(ctx context.Context) ([]model.ProductsPerCategoryResponse, error) {
-	args := m.Called(ctx)
+func (m *MockProductRepo) UpdateProductColumns(ctx context.Context, id string, version int, columns map[string]interface{}) error {
+	args := m.Called(ctx, id, version, columns)
+	return args.Error(0)
+}
+
+func (m *MockProductRepo) GetProductsPerCategory(ctx context.Context, level int) ([]model.ProductsPerCategoryResponse, error) {
+	args := m.Called(ctx, level)
 	return args.Get(0).([]model.ProductsPerCategoryResponse), args.Error(1)
 }
 
@@ -46,3 +76,8 @@ func (m *MockProductRepo) GetProductsPerSupplier(ctx context.Context) ([]model.P
 	args := m.Called(ctx)
 	return args.Get(0).([]model.ProductsPerSupplierResponse), args.Error(1)
 }
+
+func (m *MockProductRepo) GetInventoryTotals(ctx context.Context) (model.InventoryTotals, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(model.InventoryTotals), args.Error(1)
+}
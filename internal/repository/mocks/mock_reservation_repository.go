@@ -0,0 +1,44 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+type MockReservationRepo struct {
+	mock.Mock
+}
+
+func (m *MockReservationRepo) Reserve(ctx context.Context, reservation model.Reservation, now time.Time) (model.Reservation, error) {
+	args := m.Called(ctx, reservation, now)
+	return args.Get(0).(model.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepo) GetReservationById(ctx context.Context, id string) (model.Reservation, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepo) ConfirmReservation(ctx context.Context, id string, warehouseId uuid.UUID, now time.Time) (model.Reservation, error) {
+	args := m.Called(ctx, id, warehouseId, now)
+	return args.Get(0).(model.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepo) ReleaseReservation(ctx context.Context, id string, now time.Time) (model.Reservation, error) {
+	args := m.Called(ctx, id, now)
+	return args.Get(0).(model.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepo) GetAvailability(ctx context.Context, productId string, now time.Time) (model.StockAvailability, error) {
+	args := m.Called(ctx, productId, now)
+	return args.Get(0).(model.StockAvailability), args.Error(1)
+}
+
+func (m *MockReservationRepo) ExpireReservations(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
@@ -0,0 +1,63 @@
+package mocks
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+type MockSupplierRepo struct {
+	mock.Mock
+}
+
+func (m *MockSupplierRepo) GetSuppliers(ctx context.Context) ([]model.Supplier, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepo) GetSupplierById(ctx context.Context, id string) (model.Supplier, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepo) GetSupplierByName(ctx context.Context, name string) (model.Supplier, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(model.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepo) AddSupplier(ctx context.Context, supplier model.Supplier) error {
+	args := m.Called(ctx, supplier)
+	return args.Error(0)
+}
+
+func (m *MockSupplierRepo) UpdateSupplier(ctx context.Context, supplier model.Supplier) (model.Supplier, error) {
+	args := m.Called(ctx, supplier)
+	return args.Get(0).(model.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepo) DeleteSupplier(ctx context.Context, id string, version int, actor string, policy model.DeletePolicy) error {
+	args := m.Called(ctx, id, version, actor, policy)
+	return args.Error(0)
+}
+
+func (m *MockSupplierRepo) GetDeletedSuppliers(ctx context.Context) ([]model.Supplier, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepo) GetDeletedSupplierById(ctx context.Context, id string) (model.Supplier, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Supplier), args.Error(1)
+}
+
+func (m *MockSupplierRepo) RestoreSupplier(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockSupplierRepo) PurgeSuppliers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
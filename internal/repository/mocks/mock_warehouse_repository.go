@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+type MockWarehouseRepo struct {
+	mock.Mock
+}
+
+func (m *MockWarehouseRepo) GetWarehouses(ctx context.Context) ([]model.Warehouse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Warehouse), args.Error(1)
+}
+
+func (m *MockWarehouseRepo) GetWarehouseById(ctx context.Context, id string) (model.Warehouse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Warehouse), args.Error(1)
+}
+
+func (m *MockWarehouseRepo) AddWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error) {
+	args := m.Called(ctx, warehouse)
+	return args.Get(0).(model.Warehouse), args.Error(1)
+}
+
+func (m *MockWarehouseRepo) UpdateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error) {
+	args := m.Called(ctx, warehouse)
+	return args.Get(0).(model.Warehouse), args.Error(1)
+}
+
+func (m *MockWarehouseRepo) DeleteWarehouse(ctx context.Context, id string, version int) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
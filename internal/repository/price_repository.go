@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+// priceInEffect selects the entries of the price history in effect at the
+// time given twice as argument.
+const priceInEffect = "product_prices.effective_from <= ? AND (product_prices.effective_to IS NULL OR product_prices.effective_to > ?)"
+
+type IPriceRepo interface {
+	GetPrices(ctx context.Context, productId string) ([]model.ProductPrice, error)
+	SchedulePrice(ctx context.Context, price model.ProductPrice) (model.ProductPrice, error)
+	ApplyScheduledPrices(ctx context.Context, now time.Time) (int64, error)
+}
+
+type priceRepo struct {
+	db *gorm.DB
+}
+
+func NewPriceRepo(db *gorm.DB) *priceRepo {
+	return &priceRepo{db: db}
+}
+
+// GetPrices lists the price history of a product, scheduled prices
+// included, oldest first. Products in the trash keep their history; it fails
+// with gorm.ErrRecordNotFound only for products that do not exist at all.
+func (r *priceRepo) GetPrices(ctx context.Context, productId string) ([]model.ProductPrice, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Unscoped().Model(&model.Product{}).Where("id = ?", productId).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var prices []model.ProductPrice
+	err := r.db.WithContext(ctx).Where("product_id = ?", productId).Order("effective_from").Find(&prices).Error
+	return prices, err
+}
+
+// SchedulePrice adds price to the history of its product, taking precedence
+// over the prices already scheduled for the same period. It fails with
+// gorm.ErrRecordNotFound when the product does not exist or is in the trash.
+// The price of the product itself changes once the period starts, through
+// ApplyScheduledPrices.
+func (r *priceRepo) SchedulePrice(ctx context.Context, price model.ProductPrice) (model.ProductPrice, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockLiveProduct(tx, price.ProductId.String()); err != nil {
+			return err
+		}
+		return setPrice(tx, &price)
+	})
+	return price, err
+}
+
+// ApplyScheduledPrices gives every live product the price its history has
+// in effect at now, and returns how many products changed price. Each
+// product changes in a transaction of its own, recorded in the audit log.
+func (r *priceRepo) ApplyScheduledPrices(ctx context.Context, now time.Time) (int64, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Raw(`SELECT products.id FROM products
+		JOIN product_prices ON product_prices.product_id = products.id
+		WHERE products.deleted_at IS NULL AND `+priceInEffect+` AND products.price <> product_prices.price`,
+		now, now).Scan(&ids).Error
+	if err != nil {
+		return 0, err
+	}
+	var applied int64
+	for _, id := range ids {
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return audited[model.Product](tx, model.AuditEntityProduct, model.AuditActionUpdate, id, func() error {
+				price, err := priceAt(tx, id, now)
+				if err != nil {
+					return err
+				}
+				return tx.Model(&model.Product{}).Where("id = ?", id).Updates(map[string]interface{}{
+					"price":   price.Price,
+					"version": gorm.Expr("version + 1"),
+				}).Error
+			})
+		})
+		if err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// priceAt returns the entry of the price history of a product in effect at
+// the given time, or gorm.ErrRecordNotFound when it had no price then.
+func priceAt(tx *gorm.DB, productId string, at time.Time) (model.ProductPrice, error) {
+	var price model.ProductPrice
+	err := tx.Where("product_id = ? AND "+priceInEffect, productId, at, at).First(&price).Error
+	return price, err
+}
+
+// recordPrice records that the product with the given id now has price, in
+// its history, until the next scheduled price. It does nothing when that is
+// already the price in effect. The caller holds the lock on the product.
+func recordPrice(tx *gorm.DB, productId string, price float64) error {
+	now := tx.NowFunc()
+	current, err := priceAt(tx, productId, now)
+	if err == nil && current.Price == price {
+		return nil
+	}
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return err
+	}
+	id, err := uuid.Parse(productId)
+	if err != nil {
+		return err
+	}
+	return setPrice(tx, &model.ProductPrice{ProductId: id, Price: price, EffectiveFrom: now})
+}
+
+// setPrice adds price to the history of its product, within tx, cutting
+// the entries it overlaps: one starting before it now ends where it starts,
+// one ending after it now starts where it ends, and one it covers is
+// removed. A price without an end holds until the next entry starting after
+// it. The caller holds the lock on the product.
+func setPrice(tx *gorm.DB, price *model.ProductPrice) error {
+	from := price.EffectiveFrom
+	if price.EffectiveTo == nil {
+		var next []time.Time
+		err := tx.Model(&model.ProductPrice{}).
+			Where("product_id = ? AND effective_from > ?", price.ProductId, from).
+			Order("effective_from").Limit(1).
+			Pluck("effective_from", &next).Error
+		if err != nil {
+			return err
+		}
+		if len(next) > 0 {
+			price.EffectiveTo = &next[0]
+		}
+	}
+	to := price.EffectiveTo
+
+	query := tx.Where("product_id = ? AND (effective_to IS NULL OR effective_to > ?)", price.ProductId, from)
+	if to != nil {
+		query = query.Where("effective_from < ?", *to)
+	}
+	var overlapped []model.ProductPrice
+	if err := query.Order("effective_from").Find(&overlapped).Error; err != nil {
+		return err
+	}
+	for _, e := range overlapped {
+		startsBefore := e.EffectiveFrom.Before(from)
+		endsAfter := to != nil && (e.EffectiveTo == nil || e.EffectiveTo.After(*to))
+		entry := tx.Model(&model.ProductPrice{}).Where("id = ?", e.Id)
+		var err error
+		switch {
+		case startsBefore && endsAfter:
+			// The new price falls within e, which goes on after it.
+			err = tx.Create(&model.ProductPrice{
+				ProductId:     e.ProductId,
+				Price:         e.Price,
+				EffectiveFrom: *to,
+				EffectiveTo:   e.EffectiveTo,
+				Actor:         e.Actor,
+			}).Error
+			if err == nil {
+				err = entry.Update("effective_to", from).Error
+			}
+		case startsBefore:
+			err = entry.Update("effective_to", from).Error
+		case endsAfter:
+			err = entry.Update("effective_from", *to).Error
+		default:
+			err = tx.Delete(&model.ProductPrice{}, e.Id).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	price.Actor = auth.Actor(tx.Statement.Context)
+	return tx.Create(price).Error
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+var priceColumns = []string{"id", "product_id", "price", "effective_from", "effective_to", "actor", "created_at"}
+
+func TestSchedulePriceSplitsPriceInEffect(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPriceRepo(db)
+
+	productID := uuid.New()
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	from := time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC)
+	to := from.Add(72 * time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "price"}).AddRow(productID, 19.99))
+	mock.ExpectQuery(`SELECT \* FROM "product_prices" WHERE .*effective_from < \$3 ORDER BY effective_from`).
+		WithArgs(productID, from, to).
+		WillReturnRows(sqlmock.NewRows(priceColumns).AddRow(1, productID, 19.99, t0, nil, "alice", t0))
+	mock.ExpectQuery(`INSERT INTO "product_prices" \("product_id","price","effective_from","effective_to","actor"\)`).
+		WithArgs(productID, 19.99, to, nil, "alice").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "id"}).AddRow(time.Now(), 2))
+	mock.ExpectExec(`UPDATE "product_prices" SET "effective_to"=\$1 WHERE id = \$2`).
+		WithArgs(from, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "product_prices" \("product_id","price","effective_from","effective_to","actor"\)`).
+		WithArgs(productID, 14.99, from, to, "system").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "id"}).AddRow(time.Now(), 3))
+	mock.ExpectCommit()
+
+	price, err := repo.SchedulePrice(context.Background(), model.ProductPrice{
+		ProductId:     productID,
+		Price:         14.99,
+		EffectiveFrom: from,
+		EffectiveTo:   &to,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), price.Id)
+	assert.Equal(t, "system", price.Actor)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSchedulePriceWithoutEndHoldsUntilNextPrice(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewPriceRepo(db)
+
+	productID := uuid.New()
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	from := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	next := time.Date(2026, 11, 27, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "price"}).AddRow(productID, 19.99))
+	mock.ExpectQuery(`SELECT "effective_from" FROM "product_prices" WHERE product_id = \$1 AND effective_from > \$2 ORDER BY effective_from LIMIT \$3`).
+		WithArgs(productID, from, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"effective_from"}).AddRow(next))
+	mock.ExpectQuery(`SELECT \* FROM "product_prices" WHERE .*effective_from < \$3 ORDER BY effective_from`).
+		WithArgs(productID, from, next).
+		WillReturnRows(sqlmock.NewRows(priceColumns).AddRow(1, productID, 19.99, t0, next, "alice", t0))
+	mock.ExpectExec(`UPDATE "product_prices" SET "effective_to"=\$1 WHERE id = \$2`).
+		WithArgs(from, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "product_prices" \("product_id","price","effective_from","effective_to","actor"\)`).
+		WithArgs(productID, 17.99, from, next, "system").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "id"}).AddRow(time.Now(), 2))
+	mock.ExpectCommit()
+
+	price, err := repo.SchedulePrice(context.Background(), model.ProductPrice{
+		ProductId:     productID,
+		Price:         17.99,
+		EffectiveFrom: from,
+	})
+
+	require.NoError(t, err)
+	require.NotNil(t, price.EffectiveTo)
+	assert.Equal(t, next, *price.EffectiveTo)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
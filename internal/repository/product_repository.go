@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
 	"github.com/thinhpq0112/soa-backend/internal/model"
 	"gorm.io/gorm"
 	"time"
@@ -10,20 +13,30 @@ import (
 type IProductRepo interface {
 	GetProducts(ctx context.Context, pageNumber, limit *int, lastCreatedAt *time.Time, options *model.FilterOption) ([]model.Product, error)
 	GetProductById(ctx context.Context, id string) (model.Product, error)
-	DeleteProduct(ctx context.Context, id string) error
+	GetProductAsOf(ctx context.Context, id string, asOf time.Time) (model.Product, error)
+	DeleteProduct(ctx context.Context, id string, version int, actor string) error
+	GetDeletedProducts(ctx context.Context) ([]model.Product, error)
+	GetDeletedProductById(ctx context.Context, id string) (model.Product, error)
+	RestoreProduct(ctx context.Context, id string) error
+	PurgeProducts(ctx context.Context, deletedBefore time.Time) (int64, error)
 	UpdateProduct(ctx context.Context, product model.Product) error
+	UpdateProductColumns(ctx context.Context, id string, version int, columns map[string]interface{}) error
 
 	AddProduct(ctx context.Context, product model.Product) error
-	GetProductsPerCategory(ctx context.Context) ([]model.ProductsPerCategoryResponse, error)
+	GetProductsPerCategory(ctx context.Context, level int) ([]model.ProductsPerCategoryResponse, error)
 	GetProductsPerSupplier(ctx context.Context) ([]model.ProductsPerSupplierResponse, error)
+	GetInventoryTotals(ctx context.Context) (model.InventoryTotals, error)
 }
 
 type productRepo struct {
-	db *gorm.DB
+	db    *gorm.DB
+	watch StockWatch
 }
 
-func NewProductRepo(db *gorm.DB) *productRepo {
-	return &productRepo{db: db}
+// NewProductRepo returns the product repository. Writes that may take a
+// product below its reorder point notify watch once committed.
+func NewProductRepo(db *gorm.DB, watch StockWatch) *productRepo {
+	return &productRepo{db: db, watch: watch}
 }
 
 const maxPageLimit = 100
@@ -32,7 +45,7 @@ const defaultSizeLimit = 20
 func (p *productRepo) GetProducts(ctx context.Context, pageNumber *int, limit *int, lastCreatedAt *time.Time, options *model.FilterOption) ([]model.Product, error) {
 	var products []model.Product
 
-	query := p.db.WithContext(ctx).
+	query := p.withStock(p.db.WithContext(ctx)).
 		Preload("Category").
 		Preload("Supplier")
 
@@ -44,7 +57,24 @@ func (p *productRepo) GetProducts(ctx context.Context, pageNumber *int, limit *i
 		query = query.Joins("JOIN suppliers ON suppliers.id = products.supplier_id")
 	}
 
-	if len(options.Categories) > 0 {
+	price := "products.price"
+	if options.AsOf != nil {
+		columns, err := p.asOfColumns()
+		if err != nil {
+			return nil, err
+		}
+		query = query.Select(columns).
+			Joins("JOIN product_prices ON product_prices.product_id = products.id AND "+priceInEffect, *options.AsOf, *options.AsOf)
+		price = "product_prices.price"
+	}
+
+	if len(options.Categories) > 0 && options.IncludeDescendants {
+		query = query.Where("products.category_id IN (?)", p.db.Raw(`WITH RECURSIVE tree AS (
+				SELECT id FROM categories WHERE name IN (?) AND deleted_at IS NULL
+				UNION
+				SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+			) SELECT id FROM tree`, options.Categories))
+	} else if len(options.Categories) > 0 {
 		query = query.Where("categories.name IN (?)", options.Categories)
 	}
 
@@ -53,43 +83,47 @@ func (p *productRepo) GetProducts(ctx context.Context, pageNumber *int, limit *i
 	}
 
 	if options.Reference != "" {
-		query = query.Where("reference = ?", options.Reference)
+		query = query.Where("products.reference = ?", options.Reference)
 	}
 
 	if options.StartDate != "" {
-		query = query.Where("added_date >= ?", options.StartDate)
+		query = query.Where("products.added_date >= ?", options.StartDate)
 	}
 
 	if options.EndDate != "" {
-		query = query.Where("added_date <= ?", options.EndDate)
+		query = query.Where("products.added_date <= ?", options.EndDate)
 	}
 
 	if len(options.Status) > 0 {
-		query = query.Where("status IN (?)", options.Status)
+		query = query.Where("products.status IN (?)", options.Status)
 	}
 
 	if len(options.StockCity) > 0 {
-		query = query.Where("stock_city IN (?)", options.StockCity)
+		query = query.Where(`EXISTS (SELECT 1 FROM warehouse_stock
+			JOIN warehouses ON warehouses.id = warehouse_stock.warehouse_id
+			WHERE warehouse_stock.product_id = products.id AND warehouse_stock.quantity > 0 AND warehouses.city IN (?))`, options.StockCity)
 	}
 
 	if options.MinPrice != nil {
-		query = query.Where("price >= ?", *options.MinPrice)
+		query = query.Where(price+" >= ?", *options.MinPrice)
 	}
 
 	if options.MaxPrice != nil {
-		query = query.Where("price <= ?", *options.MaxPrice)
+		query = query.Where(price+" <= ?", *options.MaxPrice)
 	}
 
 	if options.Search != "" {
 		search := "%" + options.Search + "%"
 		query = query.Where(`
-			(reference ILIKE ? 
-			OR stock_city ILIKE ? 
+			(products.reference ILIKE ? 
+			OR EXISTS (SELECT 1 FROM warehouse_stock
+				JOIN warehouses ON warehouses.id = warehouse_stock.warehouse_id
+				WHERE warehouse_stock.product_id = products.id AND warehouse_stock.quantity > 0 AND warehouses.city ILIKE ?)
 			OR categories.name ILIKE ? 
 			OR suppliers.name ILIKE ? 
 			OR products.name ILIKE ? 
-			OR status ILIKE ? 
-			OR CAST(price AS TEXT) ILIKE ?)`,
+			OR products.status ILIKE ? 
+			OR CAST(`+price+` AS TEXT) ILIKE ?)`,
 			search, search, search, search, search, search, search,
 		)
 	}
@@ -103,7 +137,7 @@ func (p *productRepo) GetProducts(ctx context.Context, pageNumber *int, limit *i
 	}
 
 	if lastCreatedAt != nil {
-		query = query.Where("added_date > ?", *lastCreatedAt)
+		query = query.Where("products.added_date > ?", *lastCreatedAt)
 	} else if pageNumber != nil {
 		offset := (*pageNumber - 1) * *limit
 		query = query.Offset(offset)
@@ -115,9 +149,27 @@ func (p *productRepo) GetProducts(ctx context.Context, pageNumber *int, limit *i
 	return products, err
 }
 
+// asOfColumns lists the columns of products with the price taken from the
+// joined product_prices instead.
+func (p *productRepo) asOfColumns() ([]string, error) {
+	stmt := &gorm.Statement{DB: p.db}
+	if err := stmt.Parse(&model.Product{}); err != nil {
+		return nil, err
+	}
+	columns := make([]string, 0, len(stmt.Schema.DBNames))
+	for _, name := range stmt.Schema.DBNames {
+		if name == "price" {
+			columns = append(columns, "product_prices.price")
+		} else {
+			columns = append(columns, "products."+name)
+		}
+	}
+	return columns, nil
+}
+
 func (p *productRepo) GetProductById(ctx context.Context, id string) (model.Product, error) {
 	var product model.Product
-	err := p.db.WithContext(ctx).
+	err := p.withStock(p.db.WithContext(ctx)).
 		Preload("Category").
 		Preload("Supplier").
 		Where("id = ?", id).
@@ -125,24 +177,227 @@ func (p *productRepo) GetProductById(ctx context.Context, id string) (model.Prod
 	return product, err
 }
 
+// GetProductAsOf returns the product with the given id priced as it was, or
+// is scheduled to be, at asOf. It fails with gorm.ErrRecordNotFound when the
+// product had no price then.
+func (p *productRepo) GetProductAsOf(ctx context.Context, id string, asOf time.Time) (model.Product, error) {
+	product, err := p.GetProductById(ctx, id)
+	if err != nil {
+		return product, err
+	}
+	price, err := priceAt(p.db.WithContext(ctx), id, asOf)
+	if err != nil {
+		return model.Product{}, err
+	}
+	product.Price = price.Price
+	return product, nil
+}
+
+// withStock loads the warehouses holding stock of the products queried by
+// db.
+func (p *productRepo) withStock(db *gorm.DB) *gorm.DB {
+	return db.Preload("Stock", "quantity <> 0").Preload("Stock.Warehouse")
+}
+
+// UpdateProduct writes the non-zero fields of product but its quantity,
+// which only changes through inventory movements. A positive
+// product.Version makes the write conditional on the stored version.
 func (p *productRepo) UpdateProduct(ctx context.Context, product model.Product) error {
-	return p.db.WithContext(ctx).Updates(&product).Error
+	return p.watch.after(p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		id := product.Id.String()
+		if err := lockParents(tx, product.CategoryId, product.SupplierId); err != nil {
+			return err
+		}
+		return audited[model.Product](tx, model.AuditEntityProduct, model.AuditActionUpdate, id, func() error {
+			if _, err := bumpVersion(tx, "products", id, product.Version); err != nil {
+				return err
+			}
+			if err := tx.Model(&product).Omit("version", "quantity", "Stock").Updates(&product).Error; err != nil {
+				return err
+			}
+			if product.Price == 0 {
+				return nil
+			}
+			return recordPrice(tx, id, product.Price)
+		})
+	}))
 }
 
-func (p *productRepo) DeleteProduct(ctx context.Context, id string) error {
-	return p.db.WithContext(ctx).Where("id = ?", id).Delete(&model.Product{}).Error
+// UpdateProductColumns writes exactly the given columns, zero values
+// included, unlike UpdateProduct which skips them. A price must be given as
+// a float64; it is recorded in the price history.
+func (p *productRepo) UpdateProductColumns(ctx context.Context, id string, version int, columns map[string]interface{}) error {
+	return p.watch.after(p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if category, ok := columns["category_id"]; ok {
+			if err := lockLive(tx, "categories", category, ErrCategoryDeleted); err != nil {
+				return err
+			}
+		}
+		if supplier, ok := columns["supplier_id"]; ok {
+			if err := lockLive(tx, "suppliers", supplier, ErrSupplierDeleted); err != nil {
+				return err
+			}
+		}
+		return audited[model.Product](tx, model.AuditEntityProduct, model.AuditActionUpdate, id, func() error {
+			if _, err := bumpVersion(tx, "products", id, version); err != nil {
+				return err
+			}
+			if err := tx.Model(&model.Product{}).Where("id = ?", id).Updates(columns).Error; err != nil {
+				return err
+			}
+			price, ok := columns["price"]
+			if !ok {
+				return nil
+			}
+			value, ok := price.(float64)
+			if !ok {
+				return fmt.Errorf("price of type %T, want float64", price)
+			}
+			return recordPrice(tx, id, value)
+		})
+	}))
 }
 
+// DeleteProduct moves the product to the trash.
+func (p *productRepo) DeleteProduct(ctx context.Context, id string, version int, actor string) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return audited[model.Product](tx, model.AuditEntityProduct, model.AuditActionDelete, id, func() error {
+			return softDelete(tx, &model.Product{}, "products", id, version, actor)
+		})
+	})
+}
+
+// GetDeletedProducts lists the trash, most recently deleted first. The
+// category and supplier are loaded even when they are in the trash too.
+func (p *productRepo) GetDeletedProducts(ctx context.Context) ([]model.Product, error) {
+	var products []model.Product
+	err := p.trashed(ctx).Order("deleted_at DESC").Find(&products).Error
+	return products, err
+}
+
+func (p *productRepo) GetDeletedProductById(ctx context.Context, id string) (model.Product, error) {
+	var product model.Product
+	err := p.trashed(ctx).Where("id = ?", id).First(&product).Error
+	return product, err
+}
+
+func (p *productRepo) trashed(ctx context.Context) *gorm.DB {
+	unscoped := func(db *gorm.DB) *gorm.DB { return db.Unscoped() }
+	return p.db.WithContext(ctx).Unscoped().
+		Preload("Category", unscoped).
+		Preload("Supplier", unscoped).
+		Where("deleted_at IS NOT NULL")
+}
+
+func (p *productRepo) RestoreProduct(ctx context.Context, id string) error {
+	return p.watch.after(p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var trashed model.Product
+		if err := tx.Unscoped().Select("category_id", "supplier_id").Where("id = ?", id).First(&trashed).Error; err != nil {
+			return err
+		}
+		if err := lockParents(tx, trashed.CategoryId, trashed.SupplierId); err != nil {
+			return err
+		}
+		return audited[model.Product](tx, model.AuditEntityProduct, model.AuditActionRestore, id, func() error {
+			return restore(tx, &model.Product{}, id)
+		})
+	}))
+}
+
+// PurgeProducts permanently deletes the products put in the trash before
+// deletedBefore, recording each purge in the audit log.
+func (p *productRepo) PurgeProducts(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return purgeAudited(p.db.WithContext(ctx), model.AuditEntityProduct,
+		func(product model.Product) uuid.UUID { return product.Id },
+		func(db *gorm.DB) *gorm.DB {
+			return db.Where("deleted_at < ?", deletedBefore)
+		})
+}
+
+// AddProduct creates product. Its initial stock, product.Stock, is put in
+// its warehouses and recorded as opening balances in the stock ledger;
+// product.Quantity must be its total.
 func (p *productRepo) AddProduct(ctx context.Context, product model.Product) error {
-	return p.db.Create(&product).Error
+	return p.watch.after(p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockParents(tx, product.CategoryId, product.SupplierId); err != nil {
+			return err
+		}
+		stock := product.Stock
+		product.Stock = nil
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		if err := recordOpeningStock(tx, product, stock); err != nil {
+			return err
+		}
+		// A new product has no price history to cut yet. Like the migration
+		// that started the history of existing products, it starts on the
+		// date the product was added, or now when that is still to come.
+		from := tx.NowFunc()
+		if !product.AddedDate.IsZero() && product.AddedDate.Before(from) {
+			from = product.AddedDate
+		}
+		err := tx.Create(&model.ProductPrice{
+			ProductId:     product.Id,
+			Price:         product.Price,
+			EffectiveFrom: from,
+			Actor:         auth.Actor(tx.Statement.Context),
+		}).Error
+		if err != nil {
+			return err
+		}
+		return recordChanges(tx, auditChange{model.AuditEntityProduct, model.AuditActionCreate, product.Id.String(), nil, product})
+	}))
+}
+
+// lockParents share-locks the category and supplier a product is written
+// under until tx ends, before the product itself is locked, in the order
+// deleting them takes the locks. It fails with ErrCategoryDeleted or
+// ErrSupplierDeleted when one is in the trash. Zero ids, left as they are
+// by UpdateProduct, are not checked.
+func lockParents(tx *gorm.DB, categoryId, supplierId uuid.UUID) error {
+	if categoryId != uuid.Nil {
+		if err := lockLive(tx, "categories", categoryId, ErrCategoryDeleted); err != nil {
+			return err
+		}
+	}
+	if supplierId != uuid.Nil {
+		return lockLive(tx, "suppliers", supplierId, ErrSupplierDeleted)
+	}
+	return nil
 }
 
-func (p *productRepo) GetProductsPerCategory(ctx context.Context) ([]model.ProductsPerCategoryResponse, error) {
+// GetProductsPerCategory counts the products of each category. With a
+// positive level, products of categories deeper in the tree than level are
+// counted in their ancestor at that level, top-level categories being at
+// level 1.
+func (p *productRepo) GetProductsPerCategory(ctx context.Context, level int) ([]model.ProductsPerCategoryResponse, error) {
 	var results []model.ProductsPerCategoryResponse
+	if level > 0 {
+		err := p.db.WithContext(ctx).Raw(`WITH RECURSIVE tree AS (
+				SELECT id, id AS bucket_id, name AS bucket_name, 1 AS depth
+				FROM categories WHERE parent_id IS NULL AND deleted_at IS NULL
+				UNION ALL
+				SELECT c.id,
+					CASE WHEN t.depth < ? THEN c.id ELSE t.bucket_id END,
+					CASE WHEN t.depth < ? THEN c.name ELSE t.bucket_name END,
+					t.depth + 1
+				FROM categories c JOIN tree t ON c.parent_id = t.id WHERE c.deleted_at IS NULL
+			)
+			SELECT tree.bucket_name AS category_name, COUNT(*) * 100.0 / SUM(COUNT(*)) OVER() AS percentage
+			FROM products JOIN tree ON products.category_id = tree.id
+			WHERE products.deleted_at IS NULL
+			GROUP BY tree.bucket_id, tree.bucket_name`, level, level).Scan(&results).Error
+		if err != nil {
+			return nil, err
+		}
+		return results, nil
+	}
 	err := p.db.WithContext(ctx).
 		Table("products").
 		Select("categories.name as category_name, COUNT(*) * 100.0 / SUM(COUNT(*)) OVER() as percentage").
 		Joins("left join categories on products.category_id = categories.id").
+		Where("products.deleted_at IS NULL").
 		Group("categories.name").
 		Scan(&results).Error
 	if err != nil {
@@ -157,6 +412,7 @@ func (p *productRepo) GetProductsPerSupplier(ctx context.Context) ([]model.Produ
 		Table("products").
 		Select("suppliers.name as supplier_name, COUNT(*) * 100.0 / SUM(COUNT(*)) OVER() as percentage").
 		Joins("left join suppliers on products.supplier_id = suppliers.id").
+		Where("products.deleted_at IS NULL").
 		Group("suppliers.name").
 		Scan(&results).Error
 	if err != nil {
@@ -164,3 +420,13 @@ func (p *productRepo) GetProductsPerSupplier(ctx context.Context) ([]model.Produ
 	}
 	return results, nil
 }
+
+func (p *productRepo) GetInventoryTotals(ctx context.Context) (model.InventoryTotals, error) {
+	var totals model.InventoryTotals
+	err := p.db.WithContext(ctx).
+		Table("products").
+		Select("COUNT(*) as products, COALESCE(SUM(quantity), 0) as quantity").
+		Where("deleted_at IS NULL").
+		Scan(&totals).Error
+	return totals, err
+}
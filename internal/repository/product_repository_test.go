@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql/driver"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
@@ -48,26 +49,39 @@ func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
 // Test query
 func TestAddProduct(t *testing.T) {
 	db, mock := setupMockDB(t)
-	repo := NewProductRepo(db)
+	repo := NewProductRepo(db, nil)
 
 	mockUUID := uuid.New()
+	warehouseID := uuid.New()
 	product := model.Product{
 		Reference:  "Test Reference",
 		Name:       "Test Product",
 		Status:     "Test Status",
 		CategoryId: uuid.Must(uuid.Parse("94d0da61-0bbe-4be8-8435-2b72f03a29ea")),
 		Price:      100.0,
-		StockCity:  "Test Stock",
 		SupplierId: uuid.Must(uuid.Parse("4f8ce93f-46c2-4d20-8a27-92fdbf6ee464")),
 		Quantity:   9,
 		AddedDate:  time.Now(),
+		Stock:      []model.WarehouseStock{{WarehouseId: warehouseID, Quantity: 9}},
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(`INSERT INTO "products" \("reference","name","status","category_id","price","stock_city","supplier_id","quantity","added_date"\) 
-		VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9\) RETURNING "id","added_date"`).
-		WithArgs(product.Reference, product.Name, product.Status, product.CategoryId, product.Price, product.StockCity, product.SupplierId, product.Quantity, product.AddedDate).
+	expectLiveParent(mock, "categories", product.CategoryId, true)
+	expectLiveParent(mock, "suppliers", product.SupplierId, true)
+	mock.ExpectQuery(`INSERT INTO "products" \("reference","name","status","category_id","price","supplier_id","quantity","reorder_point","reorder_quantity","version","deleted_at","added_date"\) 
+		VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12\) RETURNING "id","added_date"`).
+		WithArgs(product.Reference, product.Name, product.Status, product.CategoryId, product.Price, product.SupplierId, product.Quantity, nil, nil, 1, nil, product.AddedDate).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(mockUUID))
+	expectMoveStock(mock, mockUUID, warehouseID, 0, 9)
+	mock.ExpectQuery(`INSERT INTO "inventory_movements" \("product_id","warehouse_id","kind","quantity","quantity_after","reason","reference","actor","request_id"\)`).
+		WithArgs(mockUUID, warehouseID, model.MovementAdjustment, 9, 9, model.ReasonOpeningBalance, "", "system", "").
+		WillReturnRows(sqlmock.NewRows([]string{"occurred_at", "id"}).AddRow(time.Now(), 1))
+	mock.ExpectQuery(`INSERT INTO "product_prices" \("product_id","price","effective_from","effective_to","actor"\)`).
+		WithArgs(mockUUID, product.Price, product.AddedDate, nil, "system").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "id"}).AddRow(time.Now(), 1))
+	mock.ExpectQuery(`INSERT INTO "audit_log" \("actor","request_id","entity","entity_id","action","changes"\)`).
+		WithArgs("system", "", model.AuditEntityProduct, mockUUID, model.AuditActionCreate, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"occurred_at", "id"}).AddRow(time.Now(), 1))
 	mock.ExpectCommit()
 
 	err := repo.AddProduct(context.Background(), product)
@@ -75,6 +89,75 @@ func TestAddProduct(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestAddProductAddedLaterStartsPriceHistoryNow(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewProductRepo(db, nil)
+
+	mockUUID := uuid.New()
+	addedDate := time.Now().Add(30 * 24 * time.Hour)
+	product := model.Product{
+		Reference:  "REF-1",
+		Name:       "Desk lamp",
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		Price:      19.99,
+		SupplierId: uuid.New(),
+		AddedDate:  addedDate,
+	}
+
+	mock.ExpectBegin()
+	expectLiveParent(mock, "categories", product.CategoryId, true)
+	expectLiveParent(mock, "suppliers", product.SupplierId, true)
+	mock.ExpectQuery(`INSERT INTO "products"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(mockUUID))
+	// A history starting on the added date would leave the product without
+	// a price until then.
+	mock.ExpectQuery(`INSERT INTO "product_prices" \("product_id","price","effective_from","effective_to","actor"\)`).
+		WithArgs(mockUUID, product.Price, timeBefore(addedDate), nil, "system").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "id"}).AddRow(time.Now(), 1))
+	expectAuditEntries(mock, 1)
+	mock.ExpectCommit()
+
+	err := repo.AddProduct(context.Background(), product)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// timeBefore matches the times before itself.
+type timeBefore time.Time
+
+func (t timeBefore) Match(v driver.Value) bool {
+	at, ok := v.(time.Time)
+	return ok && at.Before(time.Time(t))
+}
+
+func TestAddProductUnderDeletedCategory(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewProductRepo(db, nil)
+
+	product := model.Product{Reference: "REF-1", CategoryId: uuid.New(), SupplierId: uuid.New()}
+
+	mock.ExpectBegin()
+	expectLiveParent(mock, "categories", product.CategoryId, false)
+	mock.ExpectRollback()
+
+	err := repo.AddProduct(context.Background(), product)
+	assert.ErrorIs(t, err, ErrCategoryDeleted)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// expectLiveParent expects the category or supplier of a product, of table,
+// to be share-locked while the product is written, and found live or not.
+func expectLiveParent(mock sqlmock.Sqlmock, table string, id uuid.UUID, live bool) {
+	rows := sqlmock.NewRows([]string{"id"})
+	if live {
+		rows.AddRow(id.String())
+	}
+	mock.ExpectQuery(`SELECT "id" FROM "` + table + `" WHERE id = \$1 AND deleted_at IS NULL FOR SHARE`).
+		WithArgs(id).
+		WillReturnRows(rows)
+}
+
 func TestGetProductByID(t *testing.T) {
 	mockRepo := new(mocks.MockProductRepo)
 
@@ -90,7 +173,6 @@ func TestGetProductByID(t *testing.T) {
 		Status:     "Available",
 		CategoryId: categoryID,
 		Price:      100.0,
-		StockCity:  "Test Stock",
 		SupplierId: supplierID,
 		Quantity:   50,
 		Category: &model.Category{
@@ -129,7 +211,6 @@ func TestUpdateProduct(t *testing.T) {
 		Status:     "Out of Stock",
 		CategoryId: categoryID,
 		Price:      120.0,
-		StockCity:  "Updated Location",
 		SupplierId: supplierID,
 		Quantity:   30,
 	}
@@ -148,11 +229,144 @@ func TestDeleteProduct(t *testing.T) {
 
 	productID := uuid.New()
 
-	mockRepo.On("DeleteProduct", mock.Anything, productID.String()).
+	mockRepo.On("DeleteProduct", mock.Anything, productID.String(), 0, "alice").
 		Return(nil)
 
-	err := mockRepo.DeleteProduct(context.Background(), productID.String())
+	err := mockRepo.DeleteProduct(context.Background(), productID.String(), 0, "alice")
 
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestGetProductsQualifiesColumnsWhenJoining(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewProductRepo(db, nil)
+
+	// categories have a status too, so a bare one would be ambiguous once
+	// they are joined.
+	mock.ExpectQuery(`FROM "products" JOIN categories ON categories.id = products.category_id JOIN suppliers ON suppliers.id = products.supplier_id `+
+		`WHERE categories.name IN \(\$1\) AND products.status IN \(\$2\) AND \(\s*\(products.reference ILIKE \$3 .*OR products.status ILIKE \$8 .*\)\)`).
+		WithArgs("Lighting", model.ProductStatusAvailable, "%lamp%", "%lamp%", "%lamp%", "%lamp%", "%lamp%", "%lamp%", "%lamp%", defaultSizeLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	products, err := repo.GetProducts(context.Background(), nil, nil, nil, &model.FilterOption{
+		Categories: []string{"Lighting"},
+		Status:     []string{model.ProductStatusAvailable},
+		Search:     "lamp",
+	})
+
+	assert.NoError(t, err)
+	assert.Empty(t, products)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetProductsAsOfReadsPriceInEffect(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewProductRepo(db, nil)
+
+	productID := uuid.New()
+	asOf := time.Date(2026, 11, 28, 0, 0, 0, 0, time.UTC)
+	minPrice := 10.0
+
+	mock.ExpectQuery(`SELECT products.id,.*,products.category_id,product_prices.price,products.supplier_id,.* FROM "products" `+
+		`JOIN product_prices ON product_prices.product_id = products.id AND product_prices.effective_from <= \$1 AND \(product_prices.effective_to IS NULL OR product_prices.effective_to > \$2\) `+
+		`WHERE product_prices.price >= \$3`).
+		WithArgs(asOf, asOf, minPrice, defaultSizeLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price"}).AddRow(productID, "Desk lamp", 14.99))
+	mock.ExpectQuery(`SELECT \* FROM "warehouse_stock"`).WillReturnRows(sqlmock.NewRows([]string{"product_id"}))
+
+	products, err := repo.GetProducts(context.Background(), nil, nil, nil, &model.FilterOption{AsOf: &asOf, MinPrice: &minPrice})
+
+	require.NoError(t, err)
+	require.Len(t, products, 1)
+	assert.Equal(t, 14.99, products[0].Price)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateProductColumnsWritesZeroValues(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewProductRepo(db, nil)
+
+	productID := uuid.New()
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity", "price"}).AddRow(productID, 4, 19.5))
+	mock.ExpectQuery(`UPDATE products SET version = version \+ 1 WHERE id = \$1 AND deleted_at IS NULL AND \(\$2 <= 0 OR version = \$3\) RETURNING version`).
+		WithArgs(productID.String(), 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(3))
+	mock.ExpectExec(`UPDATE "products" SET "price"=\$1,"quantity"=\$2 WHERE id = \$3`).
+		WithArgs(0.0, 0, productID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// The price in effect ends now and the new one holds from now on.
+	mock.ExpectQuery(`SELECT \* FROM "product_prices" WHERE product_id = \$1 AND product_prices.effective_from <= \$2`).
+		WithArgs(productID.String(), sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows(priceColumns).AddRow(1, productID, 19.5, t0, nil, "alice", t0))
+	mock.ExpectQuery(`SELECT "effective_from" FROM "product_prices" WHERE product_id = \$1 AND effective_from > \$2`).
+		WithArgs(productID, sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"effective_from"}))
+	mock.ExpectQuery(`SELECT \* FROM "product_prices" WHERE product_id = \$1 AND \(effective_to IS NULL OR effective_to > \$2\) ORDER BY effective_from`).
+		WithArgs(productID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows(priceColumns).AddRow(1, productID, 19.5, t0, nil, "alice", t0))
+	mock.ExpectExec(`UPDATE "product_prices" SET "effective_to"=\$1 WHERE id = \$2`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "product_prices" \("product_id","price","effective_from","effective_to","actor"\)`).
+		WithArgs(productID, 0.0, sqlmock.AnyArg(), nil, "system").
+		WillReturnRows(sqlmock.NewRows([]string{"created_at", "id"}).AddRow(time.Now(), 2))
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity", "price"}).AddRow(productID, 0, 0))
+	mock.ExpectQuery(`INSERT INTO "audit_log"`).
+		WithArgs("system", "", model.AuditEntityProduct, productID, model.AuditActionUpdate,
+			`{"price":{"before":19.5,"after":0},"quantity":{"before":4,"after":0}}`).
+		WillReturnRows(sqlmock.NewRows([]string{"occurred_at", "id"}).AddRow(time.Now(), 1))
+	mock.ExpectCommit()
+
+	err := repo.UpdateProductColumns(context.Background(), productID.String(), 2, map[string]interface{}{
+		"price":    0.0,
+		"quantity": 0,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateProductColumnsStaleVersion(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewProductRepo(db, nil)
+
+	productID := uuid.New()
+
+	mock.ExpectBegin()
+	expectAuditLock(mock, "products", productID.String())
+	mock.ExpectQuery(`UPDATE products SET version = version \+ 1`).
+		WithArgs(productID.String(), 2, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}))
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "products" WHERE id = \$1`).
+		WithArgs(productID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	err := repo.UpdateProductColumns(context.Background(), productID.String(), 2, map[string]interface{}{"quantity": 0})
+	assert.ErrorIs(t, err, ErrStaleVersion)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeleteProductMissing(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewProductRepo(db, nil)
+
+	productID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	err := repo.DeleteProduct(context.Background(), productID.String(), 5, "alice")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
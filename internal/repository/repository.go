@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStaleVersion is returned by a conditional write when the row exists but
+// is no longer at the version the caller read.
+var ErrStaleVersion = errors.New("stale version")
+
+// ErrInUse is returned when deleting a row that live rows still reference.
+var ErrInUse = errors.New("still referenced")
+
+// ErrInvalidReassignTarget is returned when the products of a deleted row
+// cannot be moved to the requested row.
+var ErrInvalidReassignTarget = errors.New("invalid reassign target")
+
+// ErrHasChildren is returned when deleting a category that has
+// subcategories.
+var ErrHasChildren = errors.New("has children")
+
+// ErrUnknownParent and ErrCategoryCycle are returned when a category is
+// moved under a parent that does not exist or that is one of its own
+// descendants.
+var (
+	ErrUnknownParent = errors.New("unknown parent")
+	ErrCategoryCycle = errors.New("category cycle")
+)
+
+// ErrCategoryDeleted and ErrSupplierDeleted are returned when a product is
+// written under a category or supplier that is in the trash.
+var (
+	ErrCategoryDeleted = errors.New("category deleted")
+	ErrSupplierDeleted = errors.New("supplier deleted")
+)
+
+// ErrInsufficientStock is returned when a movement would take the stock of
+// a product in a warehouse below zero, or a reservation its stock available
+// to promise.
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ErrUnknownWarehouse is returned when stock is moved in or out of a
+// warehouse that does not exist.
+var ErrUnknownWarehouse = errors.New("unknown warehouse")
+
+// ErrReservationClosed is returned when confirming or releasing a
+// reservation that no longer holds stock.
+var ErrReservationClosed = errors.New("reservation closed")
+
+// ErrAlertClosed is returned when acknowledging an alert that is no longer
+// open or resolving one that is already resolved.
+var ErrAlertClosed = errors.New("alert closed")
+
+// InUseError is ErrInUse for a category or supplier, with the number of live
+// products blocking the delete.
+type InUseError struct {
+	Products int64
+}
+
+func (e *InUseError) Error() string {
+	return fmt.Sprintf("still referenced by %d products", e.Products)
+}
+
+func (e *InUseError) Is(target error) bool {
+	return target == ErrInUse
+}
+
+// InsufficientStockError is ErrInsufficientStock with the stock there is to
+// take from. Reserved is set when the stock is there but held by active
+// reservations, Available being what they leave.
+type InsufficientStockError struct {
+	Available int
+	Reserved  int
+}
+
+func (e *InsufficientStockError) Error() string {
+	if e.Reserved > 0 {
+		return fmt.Sprintf("only %d in stock not reserved", e.Available)
+	}
+	return fmt.Sprintf("only %d in stock", e.Available)
+}
+
+func (e *InsufficientStockError) Is(target error) bool {
+	return target == ErrInsufficientStock
+}
+
+// ReservationClosedError is ErrReservationClosed with the status the
+// reservation ended in.
+type ReservationClosedError struct {
+	Status string
+}
+
+func (e *ReservationClosedError) Error() string {
+	return "reservation is " + e.Status
+}
+
+func (e *ReservationClosedError) Is(target error) bool {
+	return target == ErrReservationClosed
+}
+
+// AlertClosedError is ErrAlertClosed with the status of the alert.
+type AlertClosedError struct {
+	Status string
+}
+
+func (e *AlertClosedError) Error() string {
+	return "alert is " + e.Status
+}
+
+func (e *AlertClosedError) Is(target error) bool {
+	return target == ErrAlertClosed
+}
+
+// affectedOne turns an update or delete that matched no row into
+// gorm.ErrRecordNotFound, so callers can tell a missing record from success.
+func affectedOne(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// bumpVersion increments the version of the live row of table with the
+// given id and returns the new version. When expected is positive the row must still
+// be at that version. Call it first in the transaction writing the row: the
+// update locks the row until the transaction ends, so the write cannot be
+// interleaved with another one.
+func bumpVersion(tx *gorm.DB, table, id string, expected int) (int, error) {
+	var versions []int
+	result := tx.Raw("UPDATE "+table+" SET version = version + 1 WHERE id = ? AND deleted_at IS NULL AND (? <= 0 OR version = ?) RETURNING version",
+		id, expected, expected).Scan(&versions)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if len(versions) == 0 {
+		return 0, staleOrMissing(tx, table, id)
+	}
+	return versions[0], nil
+}
+
+// softDelete moves the live row of table with the given id to the trash on
+// behalf of actor, checking its version when expected is positive. value is
+// a pointer to the model, whose gorm.DeletedAt field keeps trashed rows out
+// of ordinary queries.
+func softDelete(tx *gorm.DB, value interface{}, table, id string, expected int, actor string) error {
+	query := tx.Model(value).Where("id = ?", id)
+	if expected > 0 {
+		query = query.Where("version = ?", expected)
+	}
+	result := query.Updates(map[string]interface{}{
+		"deleted_at": gorm.Expr("now()"),
+		"deleted_by": actor,
+		"version":    gorm.Expr("version + 1"),
+	})
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+	return staleOrMissing(tx, table, id)
+}
+
+// restore takes the row of table with the given id out of the trash.
+func restore(tx *gorm.DB, value interface{}, id string) error {
+	result := tx.Unscoped().Model(value).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"deleted_by": nil,
+			"version":    gorm.Expr("version + 1"),
+		})
+	return affectedOne(result)
+}
+
+// staleOrMissing explains why a conditional write on the live row id
+// matched no row.
+func staleOrMissing(tx *gorm.DB, table, id string) error {
+	var count int64
+	if err := tx.Table(table).Where("id = ? AND deleted_at IS NULL", id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return ErrStaleVersion
+}
+
+// lockLive share-locks the live row of table with the given id until tx
+// ends, so that it cannot be deleted meanwhile, and fails with missing when
+// there is none.
+func lockLive(tx *gorm.DB, table string, id interface{}, missing error) error {
+	var ids []string
+	err := tx.Table(table).Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("id = ? AND deleted_at IS NULL", id).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return missing
+	}
+	return nil
+}
+
+// deleteWithProducts moves the row of model T with the given id, of table,
+// to the trash and applies policy to the live products whose column
+// references it, all within tx. Every change is recorded in the audit log
+// under entity for the row, and under product for the products.
+func deleteWithProducts[T any](tx *gorm.DB, entity, table, column, id string, version int, actor string, policy model.DeletePolicy) error {
+	// Trashing the row first locks it and checks its version. Product writes
+	// share-lock the category and supplier they reference, so once it is
+	// locked no new product can reference it.
+	err := audited[T](tx, entity, model.AuditActionDelete, id, func() error {
+		return softDelete(tx, new(T), table, id, version, actor)
+	})
+	if err != nil {
+		return err
+	}
+
+	switch policy.Mode {
+	case model.DeleteReassign:
+		// Share-lock the target so that it cannot be deleted before commit.
+		var targets []string
+		err := tx.Table(table).Clauses(clause.Locking{Strength: "SHARE"}).
+			Where("id = ? AND id <> ? AND deleted_at IS NULL", policy.ReassignTo, id).
+			Pluck("id", &targets).Error
+		if err != nil {
+			return err
+		}
+		if len(targets) == 0 {
+			return ErrInvalidReassignTarget
+		}
+		return auditProducts(tx, model.AuditActionUpdate, func(products *gorm.DB) error {
+			return products.Updates(map[string]interface{}{
+				column:    policy.ReassignTo,
+				"version": gorm.Expr("version + 1"),
+			}).Error
+		}, column+" = ?", id)
+	case model.DeleteArchive:
+		return auditProducts(tx, model.AuditActionDelete, func(products *gorm.DB) error {
+			return products.Updates(map[string]interface{}{
+				"deleted_at": gorm.Expr("now()"),
+				"deleted_by": actor,
+				"version":    gorm.Expr("version + 1"),
+			}).Error
+		}, column+" = ?", id)
+	default:
+		var count int64
+		if err := tx.Model(&model.Product{}).Where(column+" = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return &InUseError{Products: count}
+		}
+		return nil
+	}
+}
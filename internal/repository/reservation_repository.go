@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type IReservationRepo interface {
+	Reserve(ctx context.Context, reservation model.Reservation, now time.Time) (model.Reservation, error)
+	GetReservationById(ctx context.Context, id string) (model.Reservation, error)
+	ConfirmReservation(ctx context.Context, id string, warehouseId uuid.UUID, now time.Time) (model.Reservation, error)
+	ReleaseReservation(ctx context.Context, id string, now time.Time) (model.Reservation, error)
+	GetAvailability(ctx context.Context, productId string, now time.Time) (model.StockAvailability, error)
+	ExpireReservations(ctx context.Context, now time.Time) (int64, error)
+}
+
+type reservationRepo struct {
+	db    *gorm.DB
+	watch StockWatch
+}
+
+func NewReservationRepo(db *gorm.DB, watch StockWatch) *reservationRepo {
+	return &reservationRepo{db: db, watch: watch}
+}
+
+// Reserve creates reservation if the stock of its product available to
+// promise at now covers it, and fails with an *InsufficientStockError
+// otherwise. The product is locked while the reservations are summed, so
+// concurrent reservations cannot promise the same stock twice.
+func (r *reservationRepo) Reserve(ctx context.Context, reservation model.Reservation, now time.Time) (model.Reservation, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		product, err := lockLiveProduct(tx, reservation.ProductId.String())
+		if err != nil {
+			return err
+		}
+		reserved, err := reservedQuantity(tx, product.Id, now)
+		if err != nil {
+			return err
+		}
+		if available := product.Quantity - reserved; reservation.Quantity > available {
+			return &InsufficientStockError{Available: max(available, 0)}
+		}
+		reservation.Status = model.ReservationActive
+		reservation.Actor = auth.Actor(tx.Statement.Context)
+		return tx.Create(&reservation).Error
+	})
+	return reservation, err
+}
+
+func (r *reservationRepo) GetReservationById(ctx context.Context, id string) (model.Reservation, error) {
+	var reservation model.Reservation
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&reservation).Error
+	return reservation, err
+}
+
+// ConfirmReservation ships the stock the reservation holds from the given
+// warehouse, recording the shipment in the stock ledger, and closes the
+// reservation, in one transaction. It fails with a *ReservationClosedError
+// when the reservation is no longer active at now.
+func (r *reservationRepo) ConfirmReservation(ctx context.Context, id string, warehouseId uuid.UUID, now time.Time) (model.Reservation, error) {
+	var reservation model.Reservation
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&reservation).Error; err != nil {
+			return err
+		}
+		// The product is locked before the reservation, in the order
+		// Reserve takes the locks.
+		product, err := lockLiveProduct(tx, reservation.ProductId.String())
+		if err != nil {
+			return err
+		}
+		if err := lockOpenReservation(tx, &reservation, now); err != nil {
+			return err
+		}
+		err = applyMovement(tx, product, &model.InventoryMovement{
+			ProductId:   product.Id,
+			WarehouseId: &warehouseId,
+			Kind:        model.MovementShipment,
+			Quantity:    -reservation.Quantity,
+			Reason:      "reservation " + id,
+			Reference:   reservation.Reference,
+		})
+		if err != nil {
+			return err
+		}
+		reservation.WarehouseId = &warehouseId
+		return closeReservation(tx, &reservation, model.ReservationConfirmed, now)
+	})
+	return reservation, r.watch.after(err)
+}
+
+// ReleaseReservation gives the stock the reservation holds back. It fails
+// with a *ReservationClosedError when the reservation is no longer active at
+// now.
+func (r *reservationRepo) ReleaseReservation(ctx context.Context, id string, now time.Time) (model.Reservation, error) {
+	var reservation model.Reservation
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		reservation.Id, _ = uuid.Parse(id)
+		if err := lockOpenReservation(tx, &reservation, now); err != nil {
+			return err
+		}
+		return closeReservation(tx, &reservation, model.ReservationReleased, now)
+	})
+	return reservation, err
+}
+
+// GetAvailability computes the stock of a product available to promise at
+// now. It fails with gorm.ErrRecordNotFound when the product does not exist
+// or is in the trash.
+func (r *reservationRepo) GetAvailability(ctx context.Context, productId string, now time.Time) (model.StockAvailability, error) {
+	var product model.Product
+	if err := r.db.WithContext(ctx).Where("id = ?", productId).First(&product).Error; err != nil {
+		return model.StockAvailability{}, err
+	}
+	reserved, err := reservedQuantity(r.db.WithContext(ctx), product.Id, now)
+	if err != nil {
+		return model.StockAvailability{}, err
+	}
+	return model.StockAvailability{
+		ProductId: product.Id,
+		OnHand:    product.Quantity,
+		Reserved:  reserved,
+		Available: product.Quantity - reserved,
+	}, nil
+}
+
+// ExpireReservations closes the active reservations that expired before
+// now, as of their expiry.
+func (r *reservationRepo) ExpireReservations(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&model.Reservation{}).
+		Where("status = ? AND expires_at <= ?", model.ReservationActive, now).
+		Updates(map[string]interface{}{
+			"status":    model.ReservationExpired,
+			"closed_at": gorm.Expr("expires_at"),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// reservedQuantity sums the quantities held by the reservations of a product
+// active at now, expired ones holding nothing even before they are swept.
+func reservedQuantity(tx *gorm.DB, productId uuid.UUID, now time.Time) (int, error) {
+	var reserved int
+	err := tx.Model(&model.Reservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND status = ? AND expires_at > ?", productId, model.ReservationActive, now).
+		Scan(&reserved).Error
+	return reserved, err
+}
+
+// lockOpenReservation reads the reservation with the id of reservation and
+// locks it until tx ends. It fails with a *ReservationClosedError unless the
+// reservation is active at now.
+func lockOpenReservation(tx *gorm.DB, reservation *model.Reservation, now time.Time) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", reservation.Id).First(reservation).Error
+	if err != nil {
+		return err
+	}
+	if reservation.Status != model.ReservationActive {
+		return &ReservationClosedError{Status: reservation.Status}
+	}
+	if !reservation.ExpiresAt.After(now) {
+		return &ReservationClosedError{Status: model.ReservationExpired}
+	}
+	return nil
+}
+
+func closeReservation(tx *gorm.DB, reservation *model.Reservation, status string, now time.Time) error {
+	reservation.Status = status
+	reservation.ClosedAt = &now
+	return tx.Model(reservation).Select("status", "closed_at", "warehouse_id").Updates(reservation).Error
+}
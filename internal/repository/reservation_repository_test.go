@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+// expectReserved expects the reservations of a product active at now to be
+// summed to reserved.
+func expectReserved(mock sqlmock.Sqlmock, productID uuid.UUID, now time.Time, reserved int) {
+	mock.ExpectQuery(`SELECT COALESCE\(SUM\(quantity\), 0\) FROM "stock_reservations" WHERE product_id = \$1 AND status = \$2 AND expires_at > \$3`).
+		WithArgs(productID, model.ReservationActive, now).
+		WillReturnRows(sqlmock.NewRows([]string{"coalesce"}).AddRow(reserved))
+}
+
+func TestReserveCreatesReservation(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReservationRepo(db, nil)
+
+	productID := uuid.New()
+	reservationID := uuid.New()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity"}).AddRow(productID, 10))
+	expectReserved(mock, productID, now, 6)
+	mock.ExpectQuery(`INSERT INTO "stock_reservations"`).
+		WithArgs(productID, 4, model.ReservationActive, "SO-1042", "system", nil, now, now.Add(time.Hour), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(reservationID))
+	mock.ExpectCommit()
+
+	reservation, err := repo.Reserve(context.Background(), model.Reservation{
+		ProductId: productID,
+		Quantity:  4,
+		Reference: "SO-1042",
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, reservationID, reservation.Id)
+	assert.Equal(t, model.ReservationActive, reservation.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReserveRefusesMoreThanAvailable(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReservationRepo(db, nil)
+
+	productID := uuid.New()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity"}).AddRow(productID, 10))
+	expectReserved(mock, productID, now, 7)
+	mock.ExpectRollback()
+
+	_, err := repo.Reserve(context.Background(), model.Reservation{
+		ProductId: productID,
+		Quantity:  4,
+		ExpiresAt: now.Add(time.Hour),
+	}, now)
+
+	var insufficient *InsufficientStockError
+	require.ErrorAs(t, err, &insufficient)
+	assert.Equal(t, 3, insufficient.Available)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConfirmReservationShipsStock(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReservationRepo(db, nil)
+
+	productID := uuid.New()
+	warehouseID := uuid.New()
+	reservationID := uuid.New()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	reservationRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "product_id", "quantity", "status", "reference", "expires_at"}).
+			AddRow(reservationID, productID, 4, model.ReservationActive, "SO-1042", now.Add(time.Minute))
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "stock_reservations" WHERE id = \$1 ORDER BY "stock_reservations"."id" LIMIT \$2$`).
+		WithArgs(reservationID.String(), 1).
+		WillReturnRows(reservationRows())
+	mock.ExpectQuery(`SELECT \* FROM "products" WHERE id = \$1 ORDER BY "products"."id" LIMIT \$2 FOR UPDATE`).
+		WithArgs(productID.String(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "quantity"}).AddRow(productID, 10))
+	mock.ExpectQuery(`SELECT \* FROM "stock_reservations" WHERE id = \$1 AND "stock_reservations"."id" = \$2 ORDER BY "stock_reservations"."id" LIMIT \$3 FOR UPDATE`).
+		WithArgs(reservationID, reservationID, 1).
+		WillReturnRows(reservationRows())
+	expectMoveStock(mock, productID, warehouseID, 5, 1)
+	mock.ExpectExec(`UPDATE "products" SET "quantity"=\$1,"version"=version \+ 1`).
+		WithArgs(6, productID.String()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`INSERT INTO "inventory_movements"`).
+		WithArgs(productID, warehouseID, model.MovementShipment, -4, 6, "reservation "+reservationID.String(), "SO-1042", "system", "").
+		WillReturnRows(sqlmock.NewRows([]string{"occurred_at", "id"}).AddRow(time.Now(), 32))
+	expectAuditEntries(mock, 1)
+	mock.ExpectExec(`UPDATE "stock_reservations" SET "status"=\$1,"warehouse_id"=\$2,"closed_at"=\$3 WHERE "id" = \$4`).
+		WithArgs(model.ReservationConfirmed, warehouseID, now, reservationID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	reservation, err := repo.ConfirmReservation(context.Background(), reservationID.String(), warehouseID, now)
+
+	require.NoError(t, err)
+	assert.Equal(t, model.ReservationConfirmed, reservation.Status)
+	assert.Equal(t, &warehouseID, reservation.WarehouseId)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReleaseReservationRefusesExpired(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReservationRepo(db, nil)
+
+	reservationID := uuid.New()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "stock_reservations" WHERE id = \$1 .* FOR UPDATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "expires_at"}).
+			AddRow(reservationID, model.ReservationActive, now.Add(-time.Second)))
+	mock.ExpectRollback()
+
+	_, err := repo.ReleaseReservation(context.Background(), reservationID.String(), now)
+
+	var closed *ReservationClosedError
+	require.ErrorAs(t, err, &closed)
+	assert.ErrorIs(t, err, ErrReservationClosed)
+	assert.Equal(t, model.ReservationExpired, closed.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestExpireReservations(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewReservationRepo(db, nil)
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "stock_reservations" SET "closed_at"=expires_at,"status"=\$1 WHERE status = \$2 AND expires_at <= \$3`).
+		WithArgs(model.ReservationExpired, model.ReservationActive, now).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	expired, err := repo.ExpireReservations(context.Background(), now)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), expired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,16 +2,23 @@ package repository
 
 import (
 	"context"
+	"github.com/google/uuid"
 	"github.com/thinhpq0112/soa-backend/internal/model"
 	"gorm.io/gorm"
+	"time"
 )
 
 type ISupplierRepo interface {
 	GetSuppliers(ctx context.Context) ([]model.Supplier, error)
 	GetSupplierById(ctx context.Context, id string) (model.Supplier, error)
+	GetSupplierByName(ctx context.Context, name string) (model.Supplier, error)
 	AddSupplier(ctx context.Context, supplier model.Supplier) error
-	UpdateSupplier(ctx context.Context, supplier model.Supplier) error
-	DeleteSupplier(ctx context.Context, id string) error
+	UpdateSupplier(ctx context.Context, supplier model.Supplier) (model.Supplier, error)
+	DeleteSupplier(ctx context.Context, id string, version int, actor string, policy model.DeletePolicy) error
+	GetDeletedSuppliers(ctx context.Context) ([]model.Supplier, error)
+	GetDeletedSupplierById(ctx context.Context, id string) (model.Supplier, error)
+	RestoreSupplier(ctx context.Context, id string) error
+	PurgeSuppliers(ctx context.Context, deletedBefore time.Time) (int64, error)
 }
 
 type supplierRepo struct {
@@ -34,14 +41,80 @@ func (r *supplierRepo) GetSupplierById(ctx context.Context, id string) (model.Su
 	return supplier, err
 }
 
+func (r *supplierRepo) GetSupplierByName(ctx context.Context, name string) (model.Supplier, error) {
+	var supplier model.Supplier
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&supplier).Error
+	return supplier, err
+}
+
 func (r *supplierRepo) AddSupplier(ctx context.Context, supplier model.Supplier) error {
-	return r.db.WithContext(ctx).Create(&supplier).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&supplier).Error; err != nil {
+			return err
+		}
+		return recordChanges(tx, auditChange{model.AuditEntitySupplier, model.AuditActionCreate, supplier.Id.String(), nil, supplier})
+	})
+}
+
+// UpdateSupplier writes every field of supplier and returns it with its new version. A
+// positive supplier.Version makes the write conditional on the stored version.
+func (r *supplierRepo) UpdateSupplier(ctx context.Context, supplier model.Supplier) (model.Supplier, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		id := supplier.Id.String()
+		return audited[model.Supplier](tx, model.AuditEntitySupplier, model.AuditActionUpdate, id, func() error {
+			version, err := bumpVersion(tx, "suppliers", id, supplier.Version)
+			if err != nil {
+				return err
+			}
+			supplier.Version = version
+			return tx.Model(&supplier).Select("*").Omit("id", "version", "deleted_at", "deleted_by").Updates(&supplier).Error
+		})
+	})
+	return supplier, err
+}
+
+// DeleteSupplier moves the supplier to the trash and applies policy to its
+// products, in one transaction. The restrict policy fails with an
+// *InUseError while products that are not in the trash belong to it.
+func (r *supplierRepo) DeleteSupplier(ctx context.Context, id string, version int, actor string, policy model.DeletePolicy) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return deleteWithProducts[model.Supplier](tx, model.AuditEntitySupplier, "suppliers", "supplier_id", id, version, actor, policy)
+	})
+}
+
+// GetDeletedSuppliers lists the trash, most recently deleted first.
+func (r *supplierRepo) GetDeletedSuppliers(ctx context.Context) ([]model.Supplier, error) {
+	var suppliers []model.Supplier
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").
+		Find(&suppliers).Error
+	return suppliers, err
+}
+
+func (r *supplierRepo) GetDeletedSupplierById(ctx context.Context, id string) (model.Supplier, error) {
+	var supplier model.Supplier
+	err := r.db.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).First(&supplier).Error
+	return supplier, err
 }
 
-func (r *supplierRepo) UpdateSupplier(ctx context.Context, supplier model.Supplier) error {
-	return r.db.WithContext(ctx).Save(&supplier).Error
+func (r *supplierRepo) RestoreSupplier(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return audited[model.Supplier](tx, model.AuditEntitySupplier, model.AuditActionRestore, id, func() error {
+			return restore(tx, &model.Supplier{}, id)
+		})
+	})
 }
 
-func (r *supplierRepo) DeleteSupplier(ctx context.Context, id string) error {
-	return r.db.WithContext(ctx).Delete(&model.Supplier{}, id).Error
+// PurgeSuppliers permanently deletes the suppliers put in the trash before
+// deletedBefore. Those still referenced by a product, even one in the trash,
+// are kept until the product is purged. Each purge is recorded in the audit
+// log.
+func (r *supplierRepo) PurgeSuppliers(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return purgeAudited(r.db.WithContext(ctx), model.AuditEntitySupplier,
+		func(supplier model.Supplier) uuid.UUID { return supplier.Id },
+		func(db *gorm.DB) *gorm.DB {
+			return db.Where("deleted_at < ?", deletedBefore).
+				Where("NOT EXISTS (SELECT 1 FROM products WHERE products.supplier_id = suppliers.id)")
+		})
 }
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"gorm.io/gorm"
+)
+
+type IWarehouseRepo interface {
+	GetWarehouses(ctx context.Context) ([]model.Warehouse, error)
+	GetWarehouseById(ctx context.Context, id string) (model.Warehouse, error)
+	AddWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error)
+	DeleteWarehouse(ctx context.Context, id string, version int) error
+}
+
+type warehouseRepo struct {
+	db *gorm.DB
+}
+
+func NewWarehouseRepo(db *gorm.DB) *warehouseRepo {
+	return &warehouseRepo{db: db}
+}
+
+func (r *warehouseRepo) GetWarehouses(ctx context.Context) ([]model.Warehouse, error) {
+	var warehouses []model.Warehouse
+	err := r.db.WithContext(ctx).Order("name").Find(&warehouses).Error
+	return warehouses, err
+}
+
+func (r *warehouseRepo) GetWarehouseById(ctx context.Context, id string) (model.Warehouse, error) {
+	var warehouse model.Warehouse
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&warehouse).Error
+	return warehouse, err
+}
+
+func (r *warehouseRepo) AddWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&warehouse).Error; err != nil {
+			return err
+		}
+		return recordChanges(tx, auditChange{model.AuditEntityWarehouse, model.AuditActionCreate, warehouse.Id.String(), nil, warehouse})
+	})
+	return warehouse, err
+}
+
+// UpdateWarehouse writes every field of warehouse and returns it with its
+// new version. A positive warehouse.Version makes the write conditional on
+// the stored version.
+func (r *warehouseRepo) UpdateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		id := warehouse.Id.String()
+		err := audited[model.Warehouse](tx, model.AuditEntityWarehouse, model.AuditActionUpdate, id, func() error {
+			result := whereVersion(tx.Model(&model.Warehouse{}).Where("id = ?", id), warehouse.Version).
+				Updates(map[string]interface{}{
+					"name":      warehouse.Name,
+					"city":      warehouse.City,
+					"latitude":  warehouse.Latitude,
+					"longitude": warehouse.Longitude,
+					"version":   gorm.Expr("version + 1"),
+				})
+			return staleIfNone(result)
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).First(&warehouse).Error
+	})
+	return warehouse, err
+}
+
+// DeleteWarehouse deletes the warehouse. It fails with ErrInUse while the
+// warehouse holds stock; its movements stay in the ledger without it.
+func (r *warehouseRepo) DeleteWarehouse(ctx context.Context, id string, version int) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return audited[model.Warehouse](tx, model.AuditEntityWarehouse, model.AuditActionDelete, id, func() error {
+			var count int64
+			err := tx.Model(&model.WarehouseStock{}).Where("warehouse_id = ? AND quantity <> 0", id).Count(&count).Error
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrInUse
+			}
+			return staleIfNone(whereVersion(tx.Where("id = ?", id), version).Delete(&model.Warehouse{}))
+		})
+	})
+}
+
+// whereVersion restricts query to the given version when it is positive.
+func whereVersion(query *gorm.DB, version int) *gorm.DB {
+	if version > 0 {
+		return query.Where("version = ?", version)
+	}
+	return query
+}
+
+// staleIfNone turns a write that matched no row into ErrStaleVersion, for
+// writes on a row the transaction has already locked and so knows to exist.
+func staleIfNone(result *gorm.DB) error {
+	if result.Error != nil || result.RowsAffected > 0 {
+		return result.Error
+	}
+	return ErrStaleVersion
+}
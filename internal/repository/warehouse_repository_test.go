@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+func TestDeleteWarehouseRefusesWhileHoldingStock(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewWarehouseRepo(db)
+
+	id := uuid.New().String()
+
+	mock.ExpectBegin()
+	expectAuditLock(mock, "warehouses", id)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "warehouse_stock" WHERE warehouse_id = \$1 AND quantity <> 0`).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectRollback()
+
+	err := repo.DeleteWarehouse(context.Background(), id, 0)
+	assert.ErrorIs(t, err, ErrInUse)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateWarehouseStaleVersion(t *testing.T) {
+	db, mock := setupMockDB(t)
+	repo := NewWarehouseRepo(db)
+
+	id := uuid.New()
+
+	mock.ExpectBegin()
+	expectAuditLock(mock, "warehouses", id.String())
+	mock.ExpectExec(`UPDATE "warehouses" SET .* WHERE id = \$\d+ AND version = \$\d+`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	_, err := repo.UpdateWarehouse(context.Background(), model.Warehouse{Id: id, Name: "Lyon North", City: "Lyon", Version: 3})
+	assert.ErrorIs(t, err, ErrStaleVersion)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultAlertLimit = 100
+	maxAlertLimit     = 1000
+	// alertDeliveryBatch bounds the alerts posted to the webhook per
+	// evaluation; the rest are posted by the next ones.
+	alertDeliveryBatch = 100
+)
+
+var alertStatuses = []string{model.AlertOpen, model.AlertAcknowledged, model.AlertResolved}
+
+type IAlertService interface {
+	EvaluateAlerts(ctx context.Context) (int, error)
+	GetAlerts(ctx context.Context, filter model.AlertFilter) (model.AlertListResponse, error)
+	GetAlertById(ctx context.Context, id string) (model.StockAlert, error)
+	AcknowledgeAlert(ctx context.Context, id string) (model.StockAlert, error)
+	ResolveAlert(ctx context.Context, id string) (model.StockAlert, error)
+}
+
+// AlertNotifier delivers new stock alerts outside of the API.
+type AlertNotifier interface {
+	NotifyAlert(ctx context.Context, alert model.StockAlert) error
+}
+
+type alertService struct {
+	repo     repository.IAlertRepo
+	notifier AlertNotifier
+	now      func() time.Time
+}
+
+// NewAlertService returns the stock alert service. New alerts are delivered
+// to notifier unless it is nil.
+func NewAlertService(repo repository.IAlertRepo, notifier AlertNotifier) *alertService {
+	return &alertService{repo: repo, notifier: notifier, now: time.Now}
+}
+
+// EvaluateAlerts resolves the alerts of the products back at their reorder
+// point, raises alerts for the products that dropped below it, and delivers
+// the alerts not yet delivered. It returns the number of alerts raised.
+func (s *alertService) EvaluateAlerts(ctx context.Context) (int, error) {
+	now := s.now()
+	if _, err := s.repo.RecoverAlerts(ctx, now); err != nil {
+		return 0, dbError(err, "alert")
+	}
+	raised, err := s.repo.RaiseAlerts(ctx, now)
+	if err != nil {
+		return 0, dbError(err, "alert")
+	}
+	if s.notifier == nil {
+		return len(raised), nil
+	}
+	return len(raised), s.deliverAlerts(ctx)
+}
+
+// deliverAlerts posts the open alerts not yet delivered, oldest first,
+// stopping at the first failure so that they are retried in order.
+func (s *alertService) deliverAlerts(ctx context.Context) error {
+	alerts, err := s.repo.GetUndeliveredAlerts(ctx, alertDeliveryBatch)
+	if err != nil {
+		return dbError(err, "alert")
+	}
+	for _, alert := range alerts {
+		if err := s.notifier.NotifyAlert(ctx, alert); err != nil {
+			return fmt.Errorf("deliver alert %d: %w", alert.Id, err)
+		}
+		if err := s.repo.MarkAlertDelivered(ctx, alert.Id, s.now()); err != nil {
+			return dbError(err, "alert")
+		}
+	}
+	return nil
+}
+
+// GetAlerts lists the alerts matching filter, newest first, a page at a
+// time. A zero limit means the default page size.
+func (s *alertService) GetAlerts(ctx context.Context, filter model.AlertFilter) (model.AlertListResponse, error) {
+	for _, status := range filter.Statuses {
+		if !slices.Contains(alertStatuses, status) {
+			return model.AlertListResponse{}, ValidationError("invalid_input", "status must be one of open, acknowledged, resolved")
+		}
+	}
+	if filter.ProductId != "" {
+		if _, err := uuid.Parse(filter.ProductId); err != nil {
+			return model.AlertListResponse{}, ValidationError("invalid_id", "product_id must be a UUID")
+		}
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAlertLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxAlertLimit {
+		return model.AlertListResponse{}, ValidationError("invalid_input", "limit must be between 1 and %d", maxAlertLimit)
+	}
+
+	alerts, err := s.repo.GetAlerts(ctx, filter)
+	if err != nil {
+		return model.AlertListResponse{}, dbError(err, "alert")
+	}
+	resp := model.AlertListResponse{Data: alerts}
+	if resp.Data == nil {
+		resp.Data = []model.StockAlert{}
+	}
+	if len(alerts) == filter.Limit {
+		resp.NextBeforeId = alerts[len(alerts)-1].Id
+	}
+	return resp, nil
+}
+
+func (s *alertService) GetAlertById(ctx context.Context, id string) (model.StockAlert, error) {
+	alertId, err := parseAlertId(id)
+	if err != nil {
+		return model.StockAlert{}, err
+	}
+	alert, err := s.repo.GetAlertById(ctx, alertId)
+	return alert, dbError(err, "alert")
+}
+
+// AcknowledgeAlert records that the caller is handling an open alert.
+func (s *alertService) AcknowledgeAlert(ctx context.Context, id string) (model.StockAlert, error) {
+	alertId, err := parseAlertId(id)
+	if err != nil {
+		return model.StockAlert{}, err
+	}
+	alert, err := s.repo.AcknowledgeAlert(ctx, alertId, s.now())
+	return alert, alertError(err)
+}
+
+// ResolveAlert closes an alert before the stock of its product recovers.
+// The product is not alerted again until it has recovered.
+func (s *alertService) ResolveAlert(ctx context.Context, id string) (model.StockAlert, error) {
+	alertId, err := parseAlertId(id)
+	if err != nil {
+		return model.StockAlert{}, err
+	}
+	alert, err := s.repo.ResolveAlert(ctx, alertId, s.now())
+	return alert, alertError(err)
+}
+
+func parseAlertId(id string) (int64, error) {
+	alertId, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || alertId <= 0 {
+		return 0, ValidationError("invalid_id", "id must be a positive integer")
+	}
+	return alertId, nil
+}
+
+// alertError is dbError for changes of an alert.
+func alertError(err error) error {
+	var closed *repository.AlertClosedError
+	if errors.As(err, &closed) {
+		return ConflictError("alert_closed", "alert is %s", closed.Status).wrap(err)
+	}
+	return dbError(err, "alert")
+}
+
+// EvaluateStockAlerts evaluates the stock alerts every interval and
+// whenever a write to the stock is signalled on watch, until ctx is done.
+func EvaluateStockAlerts(ctx context.Context, interval time.Duration, svc IAlertService, watch <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-watch:
+		}
+
+		raised, err := svc.EvaluateAlerts(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("evaluate stock alerts")
+			continue
+		}
+		if raised > 0 {
+			log.Info().Int("raised", raised).Msg("stock alerts raised")
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
+)
+
+type recordingNotifier struct {
+	delivered []int64
+	err       error
+}
+
+func (n *recordingNotifier) NotifyAlert(ctx context.Context, alert model.StockAlert) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.delivered = append(n.delivered, alert.Id)
+	return nil
+}
+
+func newTestAlertService(repo *mocks.MockAlertRepo, notifier AlertNotifier, now time.Time) *alertService {
+	svc := NewAlertService(repo, notifier)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestEvaluateAlertsDeliversNewAlerts(t *testing.T) {
+	repo := new(mocks.MockAlertRepo)
+	notifier := &recordingNotifier{}
+	now := time.Now()
+	svc := newTestAlertService(repo, notifier, now)
+
+	raised := []model.StockAlert{{Id: 7, ProductId: uuid.New(), Status: model.AlertOpen}}
+	repo.On("RecoverAlerts", mock.Anything, now).Return(int64(1), nil).Once()
+	repo.On("RaiseAlerts", mock.Anything, now).Return(raised, nil).Once()
+	repo.On("GetUndeliveredAlerts", mock.Anything, alertDeliveryBatch).Return(raised, nil).Once()
+	repo.On("MarkAlertDelivered", mock.Anything, int64(7), now).Return(nil).Once()
+
+	count, err := svc.EvaluateAlerts(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []int64{7}, notifier.delivered)
+	repo.AssertExpectations(t)
+}
+
+func TestEvaluateAlertsKeepsFailedDeliveries(t *testing.T) {
+	repo := new(mocks.MockAlertRepo)
+	now := time.Now()
+	svc := newTestAlertService(repo, &recordingNotifier{err: errors.New("connection refused")}, now)
+
+	repo.On("RecoverAlerts", mock.Anything, now).Return(int64(0), nil)
+	repo.On("RaiseAlerts", mock.Anything, now).Return([]model.StockAlert{}, nil)
+	repo.On("GetUndeliveredAlerts", mock.Anything, alertDeliveryBatch).Return([]model.StockAlert{{Id: 3}}, nil)
+
+	_, err := svc.EvaluateAlerts(context.Background())
+
+	assert.ErrorContains(t, err, "deliver alert 3")
+	repo.AssertNotCalled(t, "MarkAlertDelivered", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEvaluateAlertsWithoutWebhook(t *testing.T) {
+	repo := new(mocks.MockAlertRepo)
+	now := time.Now()
+	svc := newTestAlertService(repo, nil, now)
+
+	repo.On("RecoverAlerts", mock.Anything, now).Return(int64(0), nil)
+	repo.On("RaiseAlerts", mock.Anything, now).Return([]model.StockAlert{{Id: 1}, {Id: 2}}, nil)
+
+	count, err := svc.EvaluateAlerts(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	repo.AssertNotCalled(t, "GetUndeliveredAlerts", mock.Anything, mock.Anything)
+}
+
+func TestGetAlertsRejectsUnknownStatus(t *testing.T) {
+	repo := new(mocks.MockAlertRepo)
+	svc := newTestAlertService(repo, nil, time.Now())
+
+	_, err := svc.GetAlerts(context.Background(), model.AlertFilter{Statuses: []string{"snoozed"}})
+
+	assert.ErrorIs(t, err, ErrValidation)
+	repo.AssertNotCalled(t, "GetAlerts", mock.Anything, mock.Anything)
+}
+
+func TestResolveAlertReportsClosed(t *testing.T) {
+	repo := new(mocks.MockAlertRepo)
+	now := time.Now()
+	svc := newTestAlertService(repo, nil, now)
+
+	repo.On("ResolveAlert", mock.Anything, int64(4), now).
+		Return(model.StockAlert{}, &repository.AlertClosedError{Status: model.AlertResolved})
+
+	_, err := svc.ResolveAlert(context.Background(), "4")
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "alert_closed", domainErr.Code)
+}
+
+func TestAcknowledgeAlertRejectsInvalidId(t *testing.T) {
+	repo := new(mocks.MockAlertRepo)
+	svc := newTestAlertService(repo, nil, time.Now())
+
+	_, err := svc.AcknowledgeAlert(context.Background(), "abc")
+
+	assert.ErrorIs(t, err, ErrValidation)
+}
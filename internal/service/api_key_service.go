@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	apiKeyPrefix    = "soa_"
+	apiKeyPrefixLen = len(apiKeyPrefix) + 8
+)
+
+type IAPIKeyService interface {
+	GetAPIKeys(ctx context.Context) ([]model.APIKey, error)
+	CreateAPIKey(ctx context.Context, req model.CreateAPIKeyRequest) (model.APIKeySecretResponse, error)
+	RotateAPIKey(ctx context.Context, id string) (model.APIKeySecretResponse, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, rawKey string) (*auth.Claims, error)
+}
+
+type apiKeyService struct {
+	repo repository.IAPIKeyRepo
+	now  func() time.Time
+}
+
+func NewAPIKeyService(repo repository.IAPIKeyRepo) *apiKeyService {
+	return &apiKeyService{repo: repo, now: time.Now}
+}
+
+func (s *apiKeyService) GetAPIKeys(ctx context.Context) ([]model.APIKey, error) {
+	keys, err := s.repo.GetAPIKeys(ctx)
+	return keys, dbError(err, "api_key")
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, req model.CreateAPIKeyRequest) (model.APIKeySecretResponse, error) {
+	for _, scope := range req.Scopes {
+		if !auth.IsKnownPermission(auth.Permission(scope)) {
+			return model.APIKeySecretResponse{}, ValidationError("unknown_scope", "unknown scope %q", scope)
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(s.now()) {
+		return model.APIKeySecretResponse{}, ValidationError("invalid_expiry", "expires_at must be in the future")
+	}
+
+	raw, prefix, hash, err := generateAPIKey()
+	if err != nil {
+		return model.APIKeySecretResponse{}, err
+	}
+
+	key := model.APIKey{
+		Id:        uuid.New(),
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: s.now(),
+	}
+	if claims, ok := auth.FromContext(ctx); ok {
+		key.CreatedBy = claims.Subject
+	}
+
+	if err := s.repo.AddAPIKey(ctx, key); err != nil {
+		return model.APIKeySecretResponse{}, dbError(err, "api_key")
+	}
+	zerolog.Ctx(ctx).Info().Str("api_key_id", key.Id.String()).Str("prefix", prefix).Msg("api key created")
+	return model.APIKeySecretResponse{Key: raw, Data: key}, nil
+}
+
+func (s *apiKeyService) RotateAPIKey(ctx context.Context, id string) (model.APIKeySecretResponse, error) {
+	raw, prefix, hash, err := generateAPIKey()
+	if err != nil {
+		return model.APIKeySecretResponse{}, err
+	}
+	if err := s.repo.RotateAPIKey(ctx, id, prefix, hash); err != nil {
+		return model.APIKeySecretResponse{}, activeKeyError(err)
+	}
+
+	zerolog.Ctx(ctx).Info().Str("api_key_id", id).Str("prefix", prefix).Msg("api key rotated")
+
+	key, err := s.repo.GetAPIKeyById(ctx, id)
+	if err != nil {
+		return model.APIKeySecretResponse{}, dbError(err, "api_key")
+	}
+	return model.APIKeySecretResponse{Key: raw, Data: key}, nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id string) error {
+	if err := s.repo.RevokeAPIKey(ctx, id, s.now()); err != nil {
+		return activeKeyError(err)
+	}
+	zerolog.Ctx(ctx).Info().Str("api_key_id", id).Msg("api key revoked")
+	return nil
+}
+
+// Authenticate resolves a raw X-API-Key value to the claims of its key and
+// records the use.
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*auth.Claims, error) {
+	key, err := s.repo.GetAPIKeyByHash(ctx, hashAPIKey(rawKey))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, dbError(err, "api_key")
+	}
+
+	now := s.now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !key.ExpiresAt.After(now)) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	if err := s.repo.TouchAPIKey(ctx, key.Id.String(), now); err != nil {
+		return nil, dbError(err, "api_key")
+	}
+
+	return &auth.Claims{
+		Scopes: key.Scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: "apikey:" + key.Id.String(),
+		},
+	}, nil
+}
+
+// activeKeyError reports a missing key for updates that only match keys that
+// have not been revoked.
+func activeKeyError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFoundError("api_key_not_found", "api key not found or revoked").wrap(err)
+	}
+	return dbError(err, "api_key")
+}
+
+func generateAPIKey() (raw, prefix, hash string, err error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+	raw = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	return raw, raw[:apiKeyPrefixLen], hashAPIKey(raw), nil
+}
+
+func hashAPIKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
+)
+
+func TestCreateAndAuthenticateAPIKey(t *testing.T) {
+	repo := new(mocks.MockAPIKeyRepo)
+	svc := NewAPIKeyService(repo)
+
+	var stored model.APIKey
+	repo.On("AddAPIKey", mock.Anything, mock.AnythingOfType("model.APIKey")).
+		Run(func(args mock.Arguments) { stored = args.Get(1).(model.APIKey) }).
+		Return(nil)
+
+	created, err := svc.CreateAPIKey(context.Background(), model.CreateAPIKeyRequest{
+		Name:   "importer",
+		Scopes: []string{string(auth.PermProductWrite)},
+	})
+	require.NoError(t, err)
+	assert.NotEqual(t, created.Key, stored.KeyHash, "only the hash may be stored")
+	assert.Equal(t, hashAPIKey(created.Key), stored.KeyHash)
+	assert.Equal(t, created.Key[:apiKeyPrefixLen], stored.Prefix)
+
+	repo.On("GetAPIKeyByHash", mock.Anything, stored.KeyHash).Return(stored, nil)
+	repo.On("TouchAPIKey", mock.Anything, stored.Id.String(), mock.Anything).Return(nil)
+
+	claims, err := svc.Authenticate(context.Background(), created.Key)
+	require.NoError(t, err)
+	assert.Equal(t, "apikey:"+stored.Id.String(), claims.Subject)
+	assert.True(t, auth.ScopesAllow(claims.Scopes, auth.PermProductWrite))
+	repo.AssertExpectations(t)
+}
+
+func TestCreateAPIKeyRejectsUnknownScope(t *testing.T) {
+	svc := NewAPIKeyService(new(mocks.MockAPIKeyRepo))
+
+	_, err := svc.CreateAPIKey(context.Background(), model.CreateAPIKeyRequest{
+		Name:   "importer",
+		Scopes: []string{"products:fly"},
+	})
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestAuthenticateRejectsRevokedAndExpiredKeys(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+
+	tests := map[string]model.APIKey{
+		"revoked": {RevokedAt: &past},
+		"expired": {ExpiresAt: &past},
+	}
+
+	for name, key := range tests {
+		t.Run(name, func(t *testing.T) {
+			repo := new(mocks.MockAPIKeyRepo)
+			repo.On("GetAPIKeyByHash", mock.Anything, hashAPIKey("soa_raw")).Return(key, nil)
+
+			_, err := NewAPIKeyService(repo).Authenticate(context.Background(), "soa_raw")
+			assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+			repo.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"slices"
+)
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+var auditEntities = []string{model.AuditEntityProduct, model.AuditEntityCategory, model.AuditEntitySupplier, model.AuditEntityWarehouse}
+
+type IAuditService interface {
+	GetAuditEntries(ctx context.Context, filter model.AuditFilter) (model.AuditListResponse, error)
+}
+
+type auditService struct {
+	repo repository.IAuditRepo
+}
+
+func NewAuditService(repo repository.IAuditRepo) *auditService {
+	return &auditService{repo: repo}
+}
+
+// GetAuditEntries lists the audit entries matching filter, newest first, a
+// page at a time. A zero limit means the default page size.
+func (s *auditService) GetAuditEntries(ctx context.Context, filter model.AuditFilter) (model.AuditListResponse, error) {
+	if filter.Entity != "" && !slices.Contains(auditEntities, filter.Entity) {
+		return model.AuditListResponse{}, ValidationError("invalid_input", "entity must be one of product, category, supplier, warehouse")
+	}
+	if filter.EntityId != "" {
+		if _, err := uuid.Parse(filter.EntityId); err != nil {
+			return model.AuditListResponse{}, ValidationError("invalid_id", "entity_id must be a UUID")
+		}
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return model.AuditListResponse{}, ValidationError("invalid_input", "from must be before to")
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxAuditLimit {
+		return model.AuditListResponse{}, ValidationError("invalid_input", "limit must be between 1 and %d", maxAuditLimit)
+	}
+
+	entries, err := s.repo.GetAuditEntries(ctx, filter)
+	if err != nil {
+		return model.AuditListResponse{}, dbError(err, "audit_entry")
+	}
+	resp := model.AuditListResponse{Data: entries}
+	if resp.Data == nil {
+		resp.Data = []model.AuditEntry{}
+	}
+	if len(entries) == filter.Limit {
+		resp.NextBeforeId = entries[len(entries)-1].Id
+	}
+	return resp, nil
+}
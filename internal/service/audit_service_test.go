@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
+)
+
+func TestGetAuditEntriesPagesWithDefaultLimit(t *testing.T) {
+	repo := new(mocks.MockAuditRepo)
+	svc := NewAuditService(repo)
+
+	entries := make([]model.AuditEntry, defaultAuditLimit)
+	for i := range entries {
+		entries[i].Id = int64(100 - i)
+	}
+	repo.On("GetAuditEntries", mock.Anything, model.AuditFilter{Actor: "alice", Limit: defaultAuditLimit}).
+		Return(entries, nil)
+
+	resp, err := svc.GetAuditEntries(context.Background(), model.AuditFilter{Actor: "alice"})
+
+	require.NoError(t, err)
+	assert.Len(t, resp.Data, defaultAuditLimit)
+	assert.Equal(t, int64(51), resp.NextBeforeId)
+}
+
+func TestGetAuditEntriesAcceptsWarehouses(t *testing.T) {
+	repo := new(mocks.MockAuditRepo)
+	svc := NewAuditService(repo)
+
+	filter := model.AuditFilter{Entity: model.AuditEntityWarehouse, Limit: defaultAuditLimit}
+	repo.On("GetAuditEntries", mock.Anything, filter).Return([]model.AuditEntry{}, nil)
+
+	_, err := svc.GetAuditEntries(context.Background(), model.AuditFilter{Entity: model.AuditEntityWarehouse})
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestGetAuditEntriesValidatesFilter(t *testing.T) {
+	from := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+	cases := map[string]model.AuditFilter{
+		"unknown entity": {Entity: "order"},
+		"invalid id":     {EntityId: "42"},
+		"inverted range": {From: &from, To: &to},
+		"limit over max": {Limit: maxAuditLimit + 1},
+		"negative limit": {Limit: -1},
+	}
+	for name, filter := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := new(mocks.MockAuditRepo)
+			svc := NewAuditService(repo)
+
+			_, err := svc.GetAuditEntries(context.Background(), filter)
+
+			assert.ErrorIs(t, err, ErrValidation)
+			repo.AssertNotCalled(t, "GetAuditEntries", mock.Anything, mock.Anything)
+		})
+	}
+}
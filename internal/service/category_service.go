@@ -2,16 +2,30 @@ package service
 
 import (
 	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
 	"github.com/thinhpq0112/soa-backend/internal/model"
 	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/validation"
+	"gorm.io/gorm"
+	"slices"
+	"sort"
+	"strings"
 )
 
 type ICategoryService interface {
-	GetCategories(ctx context.Context) ([]model.Category, error)
+	GetCategories(ctx context.Context, statuses []string) ([]model.Category, error)
 	GetCategoryById(ctx context.Context, id string) (model.Category, error)
+	GetCategoryTree(ctx context.Context) ([]model.CategoryNode, error)
+	GetCategorySubtree(ctx context.Context, id string) (model.CategoryNode, error)
+	GetCategoryPath(ctx context.Context, id string) ([]model.Category, error)
 	AddCategory(ctx context.Context, category model.Category) error
-	UpdateCategory(ctx context.Context, category model.Category) error
-	DeleteCategory(ctx context.Context, id string) error
+	UpdateCategory(ctx context.Context, category model.Category) (model.Category, error)
+	SetCategoriesStatus(ctx context.Context, ids []uuid.UUID, status string) ([]model.Category, error)
+	DeleteCategory(ctx context.Context, id string, version int, policy model.DeletePolicy) error
+	GetDeletedCategories(ctx context.Context) ([]model.Category, error)
+	RestoreCategory(ctx context.Context, id string) (model.Category, error)
 }
 
 type CategoryService struct {
@@ -22,22 +36,214 @@ func NewCategoryService(repo repository.ICategoryRepo) *CategoryService {
 	return &CategoryService{repo: repo}
 }
 
-func (s *CategoryService) GetCategories(ctx context.Context) ([]model.Category, error) {
-	return s.repo.GetCategories(ctx)
+var categoryStatuses = []string{model.CategoryStatusActive, model.CategoryStatusInactive, model.CategoryStatusArchived}
+
+// GetCategories lists the categories having one of statuses, the active ones
+// by default.
+func (s *CategoryService) GetCategories(ctx context.Context, statuses []string) ([]model.Category, error) {
+	if len(statuses) == 0 {
+		statuses = []string{model.CategoryStatusActive}
+	}
+	for _, status := range statuses {
+		if !slices.Contains(categoryStatuses, status) {
+			return nil, invalidCategoryStatus()
+		}
+	}
+	categories, err := s.repo.GetCategories(ctx, statuses)
+	return categories, dbError(err, "category")
+}
+
+func invalidCategoryStatus() *Error {
+	return ValidationError("invalid_status", "status must be one of %s", strings.Join(categoryStatuses, ", "))
 }
 
 func (s *CategoryService) GetCategoryById(ctx context.Context, id string) (model.Category, error) {
-	return s.repo.GetCategoryById(ctx, id)
+	category, err := s.repo.GetCategoryById(ctx, id)
+	return category, dbError(err, "category")
+}
+
+// GetCategoryTree returns the active top-level categories with their active
+// descendants. The subcategories of a category that is not active are hidden
+// with it.
+func (s *CategoryService) GetCategoryTree(ctx context.Context) ([]model.CategoryNode, error) {
+	categories, err := s.repo.GetCategories(ctx, []string{model.CategoryStatusActive})
+	if err != nil {
+		return nil, dbError(err, "category")
+	}
+	return buildCategoryTree(categories, nil), nil
+}
+
+// GetCategorySubtree returns an active category with its active
+// descendants.
+func (s *CategoryService) GetCategorySubtree(ctx context.Context, id string) (model.CategoryNode, error) {
+	subtree, err := s.repo.GetCategorySubtree(ctx, id)
+	if err != nil {
+		return model.CategoryNode{}, dbError(err, "category")
+	}
+	var root *model.Category
+	active := make([]model.Category, 0, len(subtree))
+	for i, c := range subtree {
+		if c.Status != model.CategoryStatusActive {
+			continue
+		}
+		if c.Id.String() == id {
+			root = &subtree[i]
+		}
+		active = append(active, c)
+	}
+	if root == nil {
+		return model.CategoryNode{}, dbError(gorm.ErrRecordNotFound, "category")
+	}
+	return model.CategoryNode{Category: *root, Children: buildCategoryTree(active, &root.Id)}, nil
+}
+
+// GetCategoryPath returns the breadcrumb of a category: its ancestors from
+// the top-level one down to the category itself.
+func (s *CategoryService) GetCategoryPath(ctx context.Context, id string) ([]model.Category, error) {
+	path, err := s.repo.GetCategoryPath(ctx, id)
+	if err != nil {
+		return nil, dbError(err, "category")
+	}
+	if len(path) == 0 {
+		return nil, dbError(gorm.ErrRecordNotFound, "category")
+	}
+	return path, nil
+}
+
+// buildCategoryTree nests categories under their parents and returns the
+// children of parent, or the top-level categories when parent is nil.
+// Categories whose parent is not in categories are left out. Siblings are
+// sorted by name.
+func buildCategoryTree(categories []model.Category, parent *uuid.UUID) []model.CategoryNode {
+	children := make(map[uuid.UUID][]model.Category)
+	var roots []model.Category
+	for _, c := range categories {
+		if c.ParentId == nil {
+			roots = append(roots, c)
+		} else {
+			children[*c.ParentId] = append(children[*c.ParentId], c)
+		}
+	}
+	if parent != nil {
+		roots = children[*parent]
+	}
+
+	var nest func(level []model.Category) []model.CategoryNode
+	nest = func(level []model.Category) []model.CategoryNode {
+		sort.Slice(level, func(i, j int) bool { return level[i].Name < level[j].Name })
+		nodes := make([]model.CategoryNode, 0, len(level))
+		for _, c := range level {
+			nodes = append(nodes, model.CategoryNode{Category: c, Children: nest(children[c.Id])})
+		}
+		return nodes
+	}
+	return nest(roots)
 }
 
+// AddCategory creates category, active unless another status is given.
 func (s *CategoryService) AddCategory(ctx context.Context, category model.Category) error {
-	return s.repo.AddCategory(ctx, category)
+	if category.Status == "" {
+		category.Status = model.CategoryStatusActive
+	}
+	errs := validation.Struct(category)
+	if category.ParentId != nil {
+		_, err := s.repo.GetCategoryById(ctx, category.ParentId.String())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			errs.Add("parent_id", "category does not exist")
+		} else if err != nil {
+			return dbError(err, "category")
+		}
+	}
+	if err := invalidInput(errs); err != nil {
+		return err
+	}
+	return categoryWriteError(s.repo.AddCategory(ctx, category))
+}
+
+// UpdateCategory saves category and returns it with its new version. A positive
+// category.Version is the version the client read, as sent in If-Match.
+// Moving a category under itself or one of its descendants is refused.
+func (s *CategoryService) UpdateCategory(ctx context.Context, category model.Category) (model.Category, error) {
+	if err := invalidInput(validation.Struct(category)); err != nil {
+		return model.Category{}, err
+	}
+	updated, err := s.repo.UpdateCategory(ctx, category)
+	if err != nil {
+		return model.Category{}, categoryWriteError(err)
+	}
+	return updated, nil
+}
+
+// categoryWriteError is dbError for the writes that put a category under a
+// parent, which the repository checks under the tree lock.
+func categoryWriteError(err error) error {
+	var errs validation.Errors
+	switch {
+	case errors.Is(err, repository.ErrUnknownParent):
+		errs.Add("parent_id", "category does not exist")
+	case errors.Is(err, repository.ErrCategoryCycle):
+		errs.Add("parent_id", "must not be the category itself or one of its subcategories")
+	default:
+		return dbError(err, "category")
+	}
+	return invalidInput(errs)
+}
+
+// SetCategoriesStatus changes the status of every category in ids at once,
+// or of none of them if one does not exist. Deactivating or archiving a
+// category hides it from public listings and stops it from receiving new
+// products; the products it has are left alone.
+func (s *CategoryService) SetCategoriesStatus(ctx context.Context, ids []uuid.UUID, status string) ([]model.Category, error) {
+	if !slices.Contains(categoryStatuses, status) {
+		return nil, invalidCategoryStatus()
+	}
+	if len(ids) == 0 {
+		return nil, ValidationError("invalid_input", "ids must list at least one category")
+	}
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !slices.Contains(unique, id.String()) {
+			unique = append(unique, id.String())
+		}
+	}
+	categories, err := s.repo.SetCategoriesStatus(ctx, unique, status)
+	return categories, dbError(err, "category")
+}
+
+// DeleteCategory moves the category to the trash, from where RestoreCategory
+// can bring it back until it is purged. policy says what happens to the
+// products of the category; by default the delete is refused while it has any.
+func (s *CategoryService) DeleteCategory(ctx context.Context, id string, version int, policy model.DeletePolicy) error {
+	if err := checkDeletePolicy(id, &policy, "category"); err != nil {
+		return err
+	}
+	return deleteError(s.repo.DeleteCategory(ctx, id, version, auth.Actor(ctx), policy), "category")
 }
 
-func (s *CategoryService) UpdateCategory(ctx context.Context, category model.Category) error {
-	return s.repo.UpdateCategory(ctx, category)
+func (s *CategoryService) GetDeletedCategories(ctx context.Context) ([]model.Category, error) {
+	categories, err := s.repo.GetDeletedCategories(ctx)
+	return categories, dbError(err, "category")
 }
 
-func (s *CategoryService) DeleteCategory(ctx context.Context, id string) error {
-	return s.repo.DeleteCategory(ctx, id)
+// RestoreCategory takes the category out of the trash. Its parent, if any,
+// must not be in the trash.
+func (s *CategoryService) RestoreCategory(ctx context.Context, id string) (model.Category, error) {
+	trashed, err := s.repo.GetDeletedCategoryById(ctx, id)
+	if err != nil {
+		return model.Category{}, dbError(err, "category")
+	}
+	if trashed.ParentId != nil {
+		_, err := s.repo.GetCategoryById(ctx, trashed.ParentId.String())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Category{}, ConflictError("parent_category_deleted", "the parent of this category is in the trash, restore it first")
+		}
+		if err != nil {
+			return model.Category{}, dbError(err, "category")
+		}
+	}
+	if err := s.repo.RestoreCategory(ctx, id); err != nil {
+		return model.Category{}, dbError(err, "category")
+	}
+	category, err := s.repo.GetCategoryById(ctx, id)
+	return category, dbError(err, "category")
 }
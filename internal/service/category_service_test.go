@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
+	"gorm.io/gorm"
+)
+
+func TestGetCategoryTreeNestsSubcategories(t *testing.T) {
+	categories := new(mocks.MockCategoryRepo)
+	svc := NewCategoryService(categories)
+
+	electronics := model.Category{Id: uuid.New(), Name: "Electronics", Status: model.CategoryStatusActive}
+	audio := model.Category{Id: uuid.New(), Name: "Audio", Status: model.CategoryStatusActive, ParentId: &electronics.Id}
+	headphones := model.Category{Id: uuid.New(), Name: "Headphones", Status: model.CategoryStatusActive, ParentId: &audio.Id}
+	books := model.Category{Id: uuid.New(), Name: "Books", Status: model.CategoryStatusActive}
+	categories.On("GetCategories", mock.Anything, []string{model.CategoryStatusActive}).
+		Return([]model.Category{headphones, books, audio, electronics}, nil)
+
+	tree, err := svc.GetCategoryTree(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, tree, 2)
+	assert.Equal(t, "Books", tree[0].Name)
+	assert.Empty(t, tree[0].Children)
+	assert.Equal(t, "Electronics", tree[1].Name)
+	require.Len(t, tree[1].Children, 1)
+	assert.Equal(t, "Audio", tree[1].Children[0].Name)
+	require.Len(t, tree[1].Children[0].Children, 1)
+	assert.Equal(t, "Headphones", tree[1].Children[0].Children[0].Name)
+}
+
+func TestGetCategoryTreeHidesInactiveBranches(t *testing.T) {
+	categories := new(mocks.MockCategoryRepo)
+	svc := NewCategoryService(categories)
+
+	electronics := model.Category{Id: uuid.New(), Name: "Electronics", Status: model.CategoryStatusActive}
+	audio := uuid.New()
+	headphones := model.Category{Id: uuid.New(), Name: "Headphones", Status: model.CategoryStatusActive, ParentId: &audio}
+	categories.On("GetCategories", mock.Anything, []string{model.CategoryStatusActive}).
+		Return([]model.Category{headphones, electronics}, nil)
+
+	tree, err := svc.GetCategoryTree(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, tree, 1)
+	assert.Equal(t, "Electronics", tree[0].Name)
+	assert.Empty(t, tree[0].Children)
+}
+
+func TestGetCategoriesRejectsUnknownStatus(t *testing.T) {
+	categories := new(mocks.MockCategoryRepo)
+	svc := NewCategoryService(categories)
+
+	_, err := svc.GetCategories(context.Background(), []string{"active", "deleted"})
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, "invalid_status", domainErr.Code)
+	categories.AssertNotCalled(t, "GetCategories", mock.Anything, mock.Anything)
+}
+
+func TestSetCategoriesStatusDeduplicatesIds(t *testing.T) {
+	categories := new(mocks.MockCategoryRepo)
+	svc := NewCategoryService(categories)
+
+	id := uuid.New()
+	categories.On("SetCategoriesStatus", mock.Anything, []string{id.String()}, model.CategoryStatusInactive).
+		Return([]model.Category{{Id: id, Status: model.CategoryStatusInactive}}, nil)
+
+	updated, err := svc.SetCategoriesStatus(context.Background(), []uuid.UUID{id, id}, model.CategoryStatusInactive)
+
+	require.NoError(t, err)
+	require.Len(t, updated, 1)
+	categories.AssertExpectations(t)
+}
+
+func TestSetCategoriesStatusReportsMissingCategory(t *testing.T) {
+	categories := new(mocks.MockCategoryRepo)
+	svc := NewCategoryService(categories)
+
+	categories.On("SetCategoriesStatus", mock.Anything, mock.Anything, model.CategoryStatusArchived).
+		Return([]model.Category(nil), gorm.ErrRecordNotFound)
+
+	_, err := svc.SetCategoriesStatus(context.Background(), []uuid.UUID{uuid.New()}, model.CategoryStatusArchived)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestGetCategorySubtreeStartsAtCategory(t *testing.T) {
+	categories := new(mocks.MockCategoryRepo)
+	svc := NewCategoryService(categories)
+
+	electronics := model.Category{Id: uuid.New(), Name: "Electronics", Status: model.CategoryStatusActive}
+	audio := model.Category{Id: uuid.New(), Name: "Audio", Status: model.CategoryStatusActive, ParentId: &electronics.Id}
+	headphones := model.Category{Id: uuid.New(), Name: "Headphones", Status: model.CategoryStatusActive, ParentId: &audio.Id}
+	categories.On("GetCategorySubtree", mock.Anything, audio.Id.String()).
+		Return([]model.Category{headphones, audio}, nil)
+
+	subtree, err := svc.GetCategorySubtree(context.Background(), audio.Id.String())
+
+	require.NoError(t, err)
+	assert.Equal(t, "Audio", subtree.Name)
+	require.Len(t, subtree.Children, 1)
+	assert.Equal(t, "Headphones", subtree.Children[0].Name)
+}
+
+func TestUpdateCategoryRejectsCycle(t *testing.T) {
+	categories := new(mocks.MockCategoryRepo)
+	svc := NewCategoryService(categories)
+
+	parent := uuid.New()
+	category := model.Category{Id: uuid.New(), Name: "Audio", Status: model.CategoryStatusActive, ParentId: &parent}
+	categories.On("UpdateCategory", mock.Anything, category).
+		Return(category, repository.ErrCategoryCycle)
+
+	_, err := svc.UpdateCategory(context.Background(), category)
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, "parent_id", domainErr.Details[0].Field)
+}
+
+func TestRestoreCategoryRequiresLiveParent(t *testing.T) {
+	categories := new(mocks.MockCategoryRepo)
+	svc := NewCategoryService(categories)
+
+	parent := uuid.New()
+	trashed := model.Category{Id: uuid.New(), ParentId: &parent, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}
+	categories.On("GetDeletedCategoryById", mock.Anything, trashed.Id.String()).Return(trashed, nil)
+	categories.On("GetCategoryById", mock.Anything, parent.String()).Return(model.Category{}, gorm.ErrRecordNotFound)
+
+	_, err := svc.RestoreCategory(context.Background(), trashed.Id.String())
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "parent_category_deleted", domainErr.Code)
+	categories.AssertNotCalled(t, "RestoreCategory", mock.Anything, mock.Anything)
+}
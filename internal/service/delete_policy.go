@@ -0,0 +1,33 @@
+package service
+
+import (
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+// checkDeletePolicy validates the policy for deleting the resource with the
+// given id, defaulting to restrict.
+func checkDeletePolicy(id string, policy *model.DeletePolicy, resource string) error {
+	if policy.Mode == "" {
+		policy.Mode = model.DeleteRestrict
+	}
+	switch policy.Mode {
+	case model.DeleteRestrict, model.DeleteArchive:
+		if policy.ReassignTo != "" {
+			return ValidationError("invalid_delete_policy", "reassign_to is only allowed with the %s policy", model.DeleteReassign)
+		}
+	case model.DeleteReassign:
+		target, err := uuid.Parse(policy.ReassignTo)
+		if err != nil {
+			return ValidationError("invalid_delete_policy", "reassign_to must be the id of the %s receiving the products", resource)
+		}
+		if target.String() == id {
+			return ValidationError("invalid_delete_policy", "reassign_to must be another %s", resource)
+		}
+		policy.ReassignTo = target.String()
+	default:
+		return ValidationError("invalid_delete_policy", "policy must be one of %s, %s, %s",
+			model.DeleteRestrict, model.DeleteReassign, model.DeleteArchive)
+	}
+	return nil
+}
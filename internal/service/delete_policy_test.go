@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+func TestCheckDeletePolicy(t *testing.T) {
+	id := uuid.New().String()
+	target := uuid.New().String()
+
+	tests := []struct {
+		name   string
+		policy model.DeletePolicy
+		valid  bool
+	}{
+		{"default", model.DeletePolicy{}, true},
+		{"archive", model.DeletePolicy{Mode: model.DeleteArchive}, true},
+		{"reassign", model.DeletePolicy{Mode: model.DeleteReassign, ReassignTo: target}, true},
+		{"reassign without target", model.DeletePolicy{Mode: model.DeleteReassign}, false},
+		{"reassign to itself", model.DeletePolicy{Mode: model.DeleteReassign, ReassignTo: id}, false},
+		{"target without reassign", model.DeletePolicy{Mode: model.DeleteRestrict, ReassignTo: target}, false},
+		{"unknown", model.DeletePolicy{Mode: "cascade"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := tt.policy
+			err := checkDeletePolicy(id, &policy, "category")
+			if tt.valid {
+				require.NoError(t, err)
+				assert.NotEmpty(t, policy.Mode)
+				return
+			}
+			var domainErr *Error
+			require.ErrorAs(t, err, &domainErr)
+			assert.Equal(t, "invalid_delete_policy", domainErr.Code)
+		})
+	}
+}
@@ -2,27 +2,44 @@ package service
 
 import (
 	"context"
+	"errors"
+	"github.com/thinhpq0112/soa-backend/internal/tracing"
 	"github.com/thinhpq0112/soa-backend/internal/util"
 )
 
 type DistanceService struct {
 	//repo repository.IProductRepo
+	geocoder *util.Geocoder
 }
 
-func NewDistanceService() *DistanceService {
-	return &DistanceService{}
+func NewDistanceService(geocoder *util.Geocoder) *DistanceService {
+	return &DistanceService{geocoder: geocoder}
 }
 
 func (s *DistanceService) CalculateDistance(ctx context.Context, ip, cityName string) (float64, error) {
-	userLat, userLon, err := util.GetLatLonFromIP(ip)
+	ctx, span := tracing.Tracer().Start(ctx, "DistanceService.CalculateDistance")
+	defer span.End()
+
+	userLat, userLon, err := s.geocoder.LatLonFromIP(ctx, ip)
 	if err != nil {
-		return 0, err
+		return 0, geocodingError(err)
 	}
 
-	cityLat, cityLon, err := util.GetLatLonFromCity(cityName)
+	cityLat, cityLon, err := s.geocoder.LatLonFromCity(ctx, cityName)
 	if err != nil {
-		return 0, err
+		return 0, geocodingError(err)
 	}
 
 	return util.CalculateDistance(userLat, userLon, cityLat, cityLon), nil
 }
+
+func geocodingError(err error) error {
+	switch {
+	case errors.Is(err, util.ErrCityNotFound):
+		return NotFoundError("city_not_found", "city not found").wrap(err)
+	case errors.Is(err, util.ErrIPNotLocatable):
+		return ValidationError("ip_not_locatable", "the client ip address cannot be located").wrap(err)
+	default:
+		return UnavailableError("geocoding_unavailable", "geocoding service is unavailable").wrap(err)
+	}
+}
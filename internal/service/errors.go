@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/validation"
+	"gorm.io/gorm"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Kinds of domain errors. Every *Error matches exactly one of them with
+// errors.Is, and the transport layer maps each kind to one HTTP status.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrForbidden   = errors.New("forbidden")
+	ErrUnavailable = errors.New("service unavailable")
+	// ErrPreconditionFailed means the client edited a stale copy.
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+// Error is a domain error safe to show to API clients: Code is a stable
+// machine-readable identifier and Message never contains database details.
+// The underlying cause, if any, is kept for logs.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	// Details lists the invalid fields of a validation error.
+	Details []model.FieldError
+	// BlockingProducts counts the products preventing a delete.
+	BlockingProducts int64
+	Err              error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFoundError(code, format string, args ...interface{}) *Error {
+	return &Error{Kind: ErrNotFound, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func ConflictError(code, format string, args ...interface{}) *Error {
+	return &Error{Kind: ErrConflict, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func ValidationError(code, format string, args ...interface{}) *Error {
+	return &Error{Kind: ErrValidation, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func ForbiddenError(code, format string, args ...interface{}) *Error {
+	return &Error{Kind: ErrForbidden, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func UnavailableError(code, format string, args ...interface{}) *Error {
+	return &Error{Kind: ErrUnavailable, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func PreconditionFailedError(code, format string, args ...interface{}) *Error {
+	return &Error{Kind: ErrPreconditionFailed, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// invalidInput reports every problem in errs at once, or nil when there are
+// none.
+func invalidInput(errs validation.Errors) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &Error{
+		Kind:    ErrValidation,
+		Code:    "validation_failed",
+		Message: "request validation failed",
+		Details: errs,
+	}
+}
+
+func (e *Error) wrap(err error) *Error {
+	e.Err = err
+	return e
+}
+
+// PostgreSQL error codes, see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgInvalidTextRepr     = "22P02"
+	pgCheckViolation      = "23514"
+	pgNotNullViolation    = "23502"
+)
+
+// pgUnavailableCodes are conditions where retrying later may succeed.
+var pgUnavailableCodes = map[pq.ErrorClass]bool{
+	"08": true, // connection exception
+	"53": true, // insufficient resources
+	"57": true, // operator intervention, e.g. admin shutdown
+}
+
+var detailKeyPattern = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// dbError translates an error returned by a repository into a domain error
+// about resource. Errors that do not match a known condition are returned
+// unchanged and end up as a 500 without their text being exposed.
+func dbError(err error, resource string) error {
+	if err == nil {
+		return nil
+	}
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return err
+	}
+	name := strings.ReplaceAll(resource, "_", " ")
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFoundError(resource+"_not_found", "%s not found", name).wrap(err)
+	}
+	if errors.Is(err, repository.ErrStaleVersion) {
+		return staleVersionError(name).wrap(err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch string(pqErr.Code) {
+		case pgUniqueViolation:
+			if column := detailColumn(pqErr); column != "" {
+				return ConflictError(resource+"_already_exists", "%s with this %s already exists", name, column).wrap(err)
+			}
+			return ConflictError(resource+"_already_exists", "%s already exists", name).wrap(err)
+		case pgForeignKeyViolation:
+			if column := detailColumn(pqErr); column != "" {
+				return ValidationError("unknown_reference", "%s does not reference an existing record", column).wrap(err)
+			}
+			return ValidationError("unknown_reference", "%s references a record that does not exist", name).wrap(err)
+		case pgInvalidTextRepr:
+			return ValidationError("invalid_input", "malformed identifier or value").wrap(err)
+		case pgCheckViolation, pgNotNullViolation:
+			return ValidationError("invalid_input", "%s violates a data constraint", name).wrap(err)
+		}
+		if pgUnavailableCodes[pqErr.Code.Class()] {
+			return UnavailableError("database_unavailable", "database is unavailable").wrap(err)
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) {
+		return UnavailableError("database_unavailable", "database is unavailable").wrap(err)
+	}
+	return err
+}
+
+func staleVersionError(name string) *Error {
+	return PreconditionFailedError("version_mismatch", "%s was modified since it was read, fetch it again", name)
+}
+
+// deleteError is dbError for deletes, where a foreign key violation means
+// other records still use the resource rather than a bad reference.
+func deleteError(err error, resource string) error {
+	name := strings.ReplaceAll(resource, "_", " ")
+	var inUse *repository.InUseError
+	if errors.As(err, &inUse) {
+		e := ConflictError(resource+"_in_use", "%s is still used by %d products, delete it with the %s or %s policy", name, inUse.Products, model.DeleteReassign, model.DeleteArchive)
+		e.BlockingProducts = inUse.Products
+		return e.wrap(err)
+	}
+	if errors.Is(err, repository.ErrHasChildren) {
+		return ConflictError(resource+"_has_children", "%s has subcategories, move or delete them first", name).wrap(err)
+	}
+	if errors.Is(err, repository.ErrInvalidReassignTarget) {
+		return ValidationError("invalid_reassign_target", "reassign_to must be the id of another existing %s", name).wrap(err)
+	}
+	var pqErr *pq.Error
+	if errors.Is(err, repository.ErrInUse) || errors.As(err, &pqErr) && pqErr.Code == pgForeignKeyViolation {
+		return ConflictError(resource+"_in_use", "%s is still referenced by other records", name).wrap(err)
+	}
+	return dbError(err, resource)
+}
+
+// detailColumn extracts the column list from details such as
+// `Key (reference)=(ABC-1) already exists.` without the offending value.
+func detailColumn(pqErr *pq.Error) string {
+	if m := detailKeyPattern.FindStringSubmatch(pqErr.Detail); m != nil {
+		return m[1]
+	}
+	return ""
+}
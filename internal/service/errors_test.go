@@ -0,0 +1,98 @@
+package service
+
+import (
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+func TestDBError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		kind    error
+		code    string
+		message string
+	}{
+		{
+			name: "missing record",
+			err:  gorm.ErrRecordNotFound,
+			kind: ErrNotFound, code: "product_not_found", message: "product not found",
+		},
+		{
+			name: "stale version",
+			err:  repository.ErrStaleVersion,
+			kind: ErrPreconditionFailed, code: "version_mismatch", message: "product was modified since it was read, fetch it again",
+		},
+		{
+			name: "unique violation",
+			err:  &pq.Error{Code: "23505", Detail: "Key (reference)=(REF-1) already exists."},
+			kind: ErrConflict, code: "product_already_exists", message: "product with this reference already exists",
+		},
+		{
+			name: "foreign key violation",
+			err:  &pq.Error{Code: "23503", Detail: `Key (category_id)=(94d0da61-0bbe-4be8-8435-2b72f03a29ea) is not present in table "categories".`},
+			kind: ErrValidation, code: "unknown_reference", message: "category_id does not reference an existing record",
+		},
+		{
+			name: "malformed uuid",
+			err:  &pq.Error{Code: "22P02", Message: `invalid input syntax for type uuid: "abc"`},
+			kind: ErrValidation, code: "invalid_input", message: "malformed identifier or value",
+		},
+		{
+			name: "admin shutdown",
+			err:  &pq.Error{Code: "57P01"},
+			kind: ErrUnavailable, code: "database_unavailable", message: "database is unavailable",
+		},
+		{
+			name: "broken connection",
+			err:  driver.ErrBadConn,
+			kind: ErrUnavailable, code: "database_unavailable", message: "database is unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := dbError(tt.err, "product")
+
+			var domainErr *Error
+			assert.ErrorAs(t, err, &domainErr)
+			assert.ErrorIs(t, err, tt.kind)
+			assert.ErrorIs(t, err, tt.err, "the cause is kept for logs")
+			assert.Equal(t, tt.code, domainErr.Code)
+			assert.Equal(t, tt.message, domainErr.Message)
+		})
+	}
+}
+
+func TestDBErrorLeavesUnknownErrorsAlone(t *testing.T) {
+	err := errors.New("boom")
+	assert.Same(t, err, dbError(err, "product"))
+	assert.NoError(t, dbError(nil, "product"))
+}
+
+func TestDeleteErrorReportsResourceInUse(t *testing.T) {
+	for _, cause := range []error{&pq.Error{Code: "23503"}, repository.ErrInUse} {
+		err := deleteError(cause, "category")
+
+		var domainErr *Error
+		assert.ErrorAs(t, err, &domainErr)
+		assert.ErrorIs(t, err, ErrConflict)
+		assert.Equal(t, "category_in_use", domainErr.Code)
+	}
+}
+
+func TestDeleteErrorReportsBlockingProducts(t *testing.T) {
+	err := deleteError(&repository.InUseError{Products: 3}, "supplier")
+
+	var domainErr *Error
+	assert.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "supplier_in_use", domainErr.Code)
+	assert.Equal(t, int64(3), domainErr.BlockingProducts)
+}
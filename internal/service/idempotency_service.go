@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/rs/zerolog/log"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"gorm.io/gorm"
+	"time"
+)
+
+type IIdempotencyService interface {
+	Begin(ctx context.Context, owner, key, requestHash string) (*model.IdempotencyKey, error)
+	Complete(ctx context.Context, owner, key string, statusCode int, contentType string, body []byte) error
+	Abandon(ctx context.Context, owner, key string) error
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+type idempotencyService struct {
+	repo repository.IIdempotencyRepo
+	ttl  time.Duration
+	now  func() time.Time
+}
+
+func NewIdempotencyService(repo repository.IIdempotencyRepo, ttl time.Duration) *idempotencyService {
+	return &idempotencyService{repo: repo, ttl: ttl, now: time.Now}
+}
+
+// Begin claims key for a request. It returns nil when the request should be
+// processed, and the stored key when it is a retry whose response should be
+// replayed. Reusing a key for a different request is a validation error, and
+// retrying while the first request is still running is a conflict.
+func (s *idempotencyService) Begin(ctx context.Context, owner, key, requestHash string) (*model.IdempotencyKey, error) {
+	now := s.now()
+	reservation := model.IdempotencyKey{
+		Owner:       owner,
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	// A second attempt is needed when the key found had expired or was
+	// abandoned meanwhile.
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := s.repo.ReserveKey(ctx, reservation)
+		if err != nil {
+			return nil, dbError(err, "idempotency_key")
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		existing, err := s.repo.GetKey(ctx, owner, key)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, dbError(err, "idempotency_key")
+		}
+		if existing.ExpiresAt.Before(now) {
+			if _, err := s.repo.DeleteExpiredKeys(ctx, now); err != nil {
+				return nil, dbError(err, "idempotency_key")
+			}
+			continue
+		}
+		if existing.RequestHash != requestHash {
+			return nil, ValidationError("idempotency_key_reused", "Idempotency-Key was already used for a different request")
+		}
+		if !existing.Completed() {
+			return nil, idempotencyInProgress()
+		}
+		return &existing, nil
+	}
+	return nil, idempotencyInProgress()
+}
+
+func idempotencyInProgress() *Error {
+	return ConflictError("idempotency_key_in_progress", "a request with this Idempotency-Key is still being processed, retry later")
+}
+
+// Complete stores the response to replay for the key claimed by Begin.
+func (s *idempotencyService) Complete(ctx context.Context, owner, key string, statusCode int, contentType string, body []byte) error {
+	return dbError(s.repo.CompleteKey(ctx, owner, key, statusCode, contentType, body), "idempotency_key")
+}
+
+// Abandon releases the key claimed by Begin without storing a response, so
+// that a retry is processed again. It is used when the request failed for a
+// reason that may be transient.
+func (s *idempotencyService) Abandon(ctx context.Context, owner, key string) error {
+	return dbError(s.repo.DeleteKey(ctx, owner, key), "idempotency_key")
+}
+
+func (s *idempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	purged, err := s.repo.DeleteExpiredKeys(ctx, s.now())
+	return purged, dbError(err, "idempotency_key")
+}
+
+// PurgeExpiredIdempotencyKeys deletes expired keys every interval until ctx
+// is done.
+func PurgeExpiredIdempotencyKeys(ctx context.Context, interval time.Duration, svc IIdempotencyService) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := svc.PurgeExpired(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("purge expired idempotency keys")
+			continue
+		}
+		if purged > 0 {
+			log.Debug().Int64("purged", purged).Msg("expired idempotency keys purged")
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
+)
+
+func newTestIdempotencyService(repo *mocks.MockIdempotencyRepo, now time.Time) *idempotencyService {
+	svc := NewIdempotencyService(repo, time.Hour)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestIdempotencyBeginReservesNewKey(t *testing.T) {
+	repo := new(mocks.MockIdempotencyRepo)
+	now := time.Now()
+	svc := newTestIdempotencyService(repo, now)
+
+	repo.On("ReserveKey", mock.Anything, mock.MatchedBy(func(k model.IdempotencyKey) bool {
+		return k.Owner == "alice" && k.Key == "k1" && k.RequestHash == "h1" && k.ExpiresAt.Equal(now.Add(time.Hour))
+	})).Return(true, nil)
+
+	stored, err := svc.Begin(context.Background(), "alice", "k1", "h1")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+	repo.AssertExpectations(t)
+}
+
+func TestIdempotencyBeginReplaysCompletedKey(t *testing.T) {
+	repo := new(mocks.MockIdempotencyRepo)
+	now := time.Now()
+	svc := newTestIdempotencyService(repo, now)
+
+	existing := model.IdempotencyKey{
+		Owner: "alice", Key: "k1", RequestHash: "h1",
+		StatusCode: 201, ContentType: "application/json", Body: []byte(`{"id":"1"}`),
+		ExpiresAt: now.Add(time.Minute),
+	}
+	repo.On("ReserveKey", mock.Anything, mock.Anything).Return(false, nil)
+	repo.On("GetKey", mock.Anything, "alice", "k1").Return(existing, nil)
+
+	stored, err := svc.Begin(context.Background(), "alice", "k1", "h1")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, 201, stored.StatusCode)
+	assert.Equal(t, existing.Body, stored.Body)
+}
+
+func TestIdempotencyBeginRejectsReusedKey(t *testing.T) {
+	repo := new(mocks.MockIdempotencyRepo)
+	now := time.Now()
+	svc := newTestIdempotencyService(repo, now)
+
+	repo.On("ReserveKey", mock.Anything, mock.Anything).Return(false, nil)
+	repo.On("GetKey", mock.Anything, "alice", "k1").
+		Return(model.IdempotencyKey{RequestHash: "other", StatusCode: 201, ExpiresAt: now.Add(time.Minute)}, nil)
+
+	_, err := svc.Begin(context.Background(), "alice", "k1", "h1")
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, "idempotency_key_reused", domainErr.Code)
+}
+
+func TestIdempotencyBeginConflictsWhileInProgress(t *testing.T) {
+	repo := new(mocks.MockIdempotencyRepo)
+	now := time.Now()
+	svc := newTestIdempotencyService(repo, now)
+
+	repo.On("ReserveKey", mock.Anything, mock.Anything).Return(false, nil)
+	repo.On("GetKey", mock.Anything, "alice", "k1").
+		Return(model.IdempotencyKey{RequestHash: "h1", ExpiresAt: now.Add(time.Minute)}, nil)
+
+	_, err := svc.Begin(context.Background(), "alice", "k1", "h1")
+	assert.ErrorIs(t, err, ErrConflict)
+}
+
+func TestIdempotencyBeginReplacesExpiredKey(t *testing.T) {
+	repo := new(mocks.MockIdempotencyRepo)
+	now := time.Now()
+	svc := newTestIdempotencyService(repo, now)
+
+	repo.On("ReserveKey", mock.Anything, mock.Anything).Return(false, nil).Once()
+	repo.On("GetKey", mock.Anything, "alice", "k1").
+		Return(model.IdempotencyKey{RequestHash: "other", StatusCode: 201, ExpiresAt: now.Add(-time.Minute)}, nil)
+	repo.On("DeleteExpiredKeys", mock.Anything, now).Return(int64(1), nil)
+	repo.On("ReserveKey", mock.Anything, mock.Anything).Return(true, nil).Once()
+
+	stored, err := svc.Begin(context.Background(), "alice", "k1", "h1")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+	repo.AssertExpectations(t)
+}
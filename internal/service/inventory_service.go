@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/validation"
+	"time"
+)
+
+const (
+	defaultMovementLimit = 100
+	maxMovementLimit     = 1000
+)
+
+type IInventoryService interface {
+	AddMovement(ctx context.Context, productId string, req model.MovementRequest) (model.InventoryMovement, error)
+	TransferStock(ctx context.Context, productId string, req model.TransferRequest) (model.TransferResponse, error)
+	GetMovements(ctx context.Context, productId string, filter model.MovementFilter) (model.MovementListResponse, error)
+}
+
+type inventoryService struct {
+	repo repository.IInventoryRepo
+	now  func() time.Time
+}
+
+func NewInventoryService(repo repository.IInventoryRepo) *inventoryService {
+	return &inventoryService{repo: repo, now: time.Now}
+}
+
+// AddMovement posts a movement of the stock of a product in a warehouse. The
+// quantity of receipts, returns and shipments is a number of items,
+// shipments taking them out; adjustments carry the signed change. Stock held
+// by active reservations cannot be taken out this way.
+func (s *inventoryService) AddMovement(ctx context.Context, productId string, req model.MovementRequest) (model.InventoryMovement, error) {
+	id, err := uuid.Parse(productId)
+	if err != nil {
+		return model.InventoryMovement{}, ValidationError("invalid_id", "id must be a UUID")
+	}
+	errs := validation.Struct(req)
+	quantity := req.Quantity
+	switch req.Kind {
+	case model.MovementReceipt, model.MovementReturn, model.MovementShipment:
+		if quantity < 0 {
+			errs.Add("quantity", "must be positive for a %s", req.Kind)
+		}
+		if req.Kind == model.MovementShipment {
+			quantity = -quantity
+		}
+	}
+	if err := invalidInput(errs); err != nil {
+		return model.InventoryMovement{}, err
+	}
+
+	movement, err := s.repo.AddMovement(ctx, model.InventoryMovement{
+		ProductId:   id,
+		WarehouseId: &req.WarehouseId,
+		Kind:        req.Kind,
+		Quantity:    quantity,
+		Reason:      req.Reason,
+		Reference:   req.Reference,
+	}, s.now())
+	return movement, stockError(err)
+}
+
+// TransferStock moves a quantity of a product from one warehouse to another.
+func (s *inventoryService) TransferStock(ctx context.Context, productId string, req model.TransferRequest) (model.TransferResponse, error) {
+	id, err := uuid.Parse(productId)
+	if err != nil {
+		return model.TransferResponse{}, ValidationError("invalid_id", "id must be a UUID")
+	}
+	errs := validation.Struct(req)
+	if req.FromWarehouseId == req.ToWarehouseId && req.ToWarehouseId != uuid.Nil {
+		errs.Add("to_warehouse_id", "must differ from from_warehouse_id")
+	}
+	if err := invalidInput(errs); err != nil {
+		return model.TransferResponse{}, err
+	}
+
+	resp, err := s.repo.TransferStock(ctx, id, req)
+	return resp, stockError(err)
+}
+
+// stockError is dbError for changes of the stock of a product.
+func stockError(err error) error {
+	var insufficient *repository.InsufficientStockError
+	if errors.As(err, &insufficient) && insufficient.Reserved > 0 {
+		return ConflictError("insufficient_stock", "only %d in stock not held by reservations, the movement would take reserved stock", insufficient.Available).wrap(err)
+	}
+	if errors.As(err, &insufficient) {
+		return ConflictError("insufficient_stock", "only %d in stock in the warehouse, the movement would take it below zero", insufficient.Available).wrap(err)
+	}
+	if errors.Is(err, repository.ErrUnknownWarehouse) {
+		return ValidationError("unknown_warehouse", "warehouse does not exist").wrap(err)
+	}
+	return dbError(err, "product")
+}
+
+// GetMovements lists the movements of a product matching filter, newest
+// first, a page at a time. A zero limit means the default page size.
+func (s *inventoryService) GetMovements(ctx context.Context, productId string, filter model.MovementFilter) (model.MovementListResponse, error) {
+	if _, err := uuid.Parse(productId); err != nil {
+		return model.MovementListResponse{}, ValidationError("invalid_id", "id must be a UUID")
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return model.MovementListResponse{}, ValidationError("invalid_input", "from must be before to")
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultMovementLimit
+	}
+	if filter.Limit < 0 || filter.Limit > maxMovementLimit {
+		return model.MovementListResponse{}, ValidationError("invalid_input", "limit must be between 1 and %d", maxMovementLimit)
+	}
+
+	movements, err := s.repo.GetMovements(ctx, productId, filter)
+	if err != nil {
+		return model.MovementListResponse{}, dbError(err, "product")
+	}
+	resp := model.MovementListResponse{Data: movements}
+	if resp.Data == nil {
+		resp.Data = []model.InventoryMovement{}
+	}
+	if len(movements) == filter.Limit {
+		resp.NextBeforeId = movements[len(movements)-1].Id
+	}
+	return resp, nil
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
+)
+
+func TestAddMovementTakesShipmentsOut(t *testing.T) {
+	repo := new(mocks.MockInventoryRepo)
+	svc := NewInventoryService(repo)
+
+	productId, warehouseId := uuid.New(), uuid.New()
+	repo.On("AddMovement", mock.Anything, model.InventoryMovement{
+		ProductId:   productId,
+		WarehouseId: &warehouseId,
+		Kind:        model.MovementShipment,
+		Quantity:    -3,
+		Reason:      "order 1042",
+		Reference:   "SO-1042",
+	}, mock.Anything).Return(model.InventoryMovement{Id: 7, QuantityAfter: 9}, nil)
+
+	movement, err := svc.AddMovement(context.Background(), productId.String(), model.MovementRequest{
+		WarehouseId: warehouseId,
+		Kind:        model.MovementShipment,
+		Quantity:    3,
+		Reason:      "order 1042",
+		Reference:   "SO-1042",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 9, movement.QuantityAfter)
+	repo.AssertExpectations(t)
+}
+
+func TestAddMovementValidatesRequest(t *testing.T) {
+	warehouseId := uuid.New()
+	cases := map[string]model.MovementRequest{
+		"negative receipt":  {WarehouseId: warehouseId, Kind: model.MovementReceipt, Quantity: -2, Reason: "delivery"},
+		"zero adjustment":   {WarehouseId: warehouseId, Kind: model.MovementAdjustment, Quantity: 0, Reason: "count"},
+		"unknown kind":      {WarehouseId: warehouseId, Kind: "loss", Quantity: 1, Reason: "broken"},
+		"transfer":          {WarehouseId: warehouseId, Kind: model.MovementTransfer, Quantity: 1, Reason: "move"},
+		"missing reason":    {WarehouseId: warehouseId, Kind: model.MovementReturn, Quantity: 1},
+		"missing warehouse": {Kind: model.MovementReceipt, Quantity: 1, Reason: "delivery"},
+	}
+	for name, req := range cases {
+		t.Run(name, func(t *testing.T) {
+			repo := new(mocks.MockInventoryRepo)
+			svc := NewInventoryService(repo)
+
+			_, err := svc.AddMovement(context.Background(), uuid.NewString(), req)
+
+			assert.ErrorIs(t, err, ErrValidation)
+			repo.AssertNotCalled(t, "AddMovement", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAddMovementReportsInsufficientStock(t *testing.T) {
+	repo := new(mocks.MockInventoryRepo)
+	svc := NewInventoryService(repo)
+
+	repo.On("AddMovement", mock.Anything, mock.Anything, mock.Anything).
+		Return(model.InventoryMovement{}, &repository.InsufficientStockError{Available: 2})
+
+	_, err := svc.AddMovement(context.Background(), uuid.NewString(), model.MovementRequest{
+		WarehouseId: uuid.New(),
+		Kind:        model.MovementAdjustment,
+		Quantity:    -5,
+		Reason:      "stock count",
+	})
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "insufficient_stock", domainErr.Code)
+	assert.Contains(t, domainErr.Message, "only 2 in stock")
+}
+
+func TestTransferStockRejectsSameWarehouse(t *testing.T) {
+	repo := new(mocks.MockInventoryRepo)
+	svc := NewInventoryService(repo)
+
+	warehouseId := uuid.New()
+	_, err := svc.TransferStock(context.Background(), uuid.NewString(), model.TransferRequest{
+		FromWarehouseId: warehouseId,
+		ToWarehouseId:   warehouseId,
+		Quantity:        2,
+		Reason:          "rebalancing",
+	})
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, []model.FieldError{{Field: "to_warehouse_id", Message: "must differ from from_warehouse_id"}}, domainErr.Details)
+	repo.AssertNotCalled(t, "TransferStock", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransferStockReportsUnknownWarehouse(t *testing.T) {
+	repo := new(mocks.MockInventoryRepo)
+	svc := NewInventoryService(repo)
+
+	productId := uuid.New()
+	req := model.TransferRequest{
+		FromWarehouseId: uuid.New(),
+		ToWarehouseId:   uuid.New(),
+		Quantity:        2,
+		Reason:          "rebalancing",
+	}
+	repo.On("TransferStock", mock.Anything, productId, req).
+		Return(model.TransferResponse{}, repository.ErrUnknownWarehouse)
+
+	_, err := svc.TransferStock(context.Background(), productId.String(), req)
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, "unknown_warehouse", domainErr.Code)
+}
+
+func TestAddMovementReportsReservedStock(t *testing.T) {
+	repo := new(mocks.MockInventoryRepo)
+	svc := NewInventoryService(repo)
+
+	repo.On("AddMovement", mock.Anything, mock.Anything, mock.Anything).
+		Return(model.InventoryMovement{}, &repository.InsufficientStockError{Available: 2, Reserved: 8})
+
+	_, err := svc.AddMovement(context.Background(), uuid.NewString(), model.MovementRequest{
+		WarehouseId: uuid.New(),
+		Kind:        model.MovementShipment,
+		Quantity:    3,
+		Reason:      "walk-in sale",
+	})
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Contains(t, domainErr.Message, "only 2 in stock not held by reservations")
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/validation"
+	"time"
+)
+
+type IPriceService interface {
+	GetPrices(ctx context.Context, productId string) (model.PriceListResponse, error)
+	SchedulePrice(ctx context.Context, productId string, req model.PriceRequest) (model.ProductPrice, error)
+	ApplyScheduledPrices(ctx context.Context) (int64, error)
+}
+
+type priceService struct {
+	repo repository.IPriceRepo
+	now  func() time.Time
+}
+
+func NewPriceService(repo repository.IPriceRepo) *priceService {
+	return &priceService{repo: repo, now: time.Now}
+}
+
+func (s *priceService) GetPrices(ctx context.Context, productId string) (model.PriceListResponse, error) {
+	if _, err := uuid.Parse(productId); err != nil {
+		return model.PriceListResponse{}, ValidationError("invalid_id", "id must be a UUID")
+	}
+	prices, err := s.repo.GetPrices(ctx, productId)
+	if err != nil {
+		return model.PriceListResponse{}, dbError(err, "product")
+	}
+	if prices == nil {
+		prices = []model.ProductPrice{}
+	}
+	return model.PriceListResponse{Data: prices}, nil
+}
+
+// SchedulePrice sets the price of a product for a period to come. Prices
+// already scheduled within that period are replaced; the history up to now
+// cannot be rewritten.
+func (s *priceService) SchedulePrice(ctx context.Context, productId string, req model.PriceRequest) (model.ProductPrice, error) {
+	id, err := uuid.Parse(productId)
+	if err != nil {
+		return model.ProductPrice{}, ValidationError("invalid_id", "id must be a UUID")
+	}
+	errs := validation.Struct(req)
+	if !req.EffectiveFrom.IsZero() && !req.EffectiveFrom.After(s.now()) {
+		errs.Add("effective_from", "must be in the future")
+	}
+	if req.EffectiveTo != nil && !req.EffectiveTo.After(req.EffectiveFrom) {
+		errs.Add("effective_to", "must be after effective_from")
+	}
+	if err := invalidInput(errs); err != nil {
+		return model.ProductPrice{}, err
+	}
+
+	price, err := s.repo.SchedulePrice(ctx, model.ProductPrice{
+		ProductId:     id,
+		Price:         req.Price,
+		EffectiveFrom: req.EffectiveFrom,
+		EffectiveTo:   req.EffectiveTo,
+	})
+	return price, dbError(err, "product")
+}
+
+func (s *priceService) ApplyScheduledPrices(ctx context.Context) (int64, error) {
+	applied, err := s.repo.ApplyScheduledPrices(ctx, s.now())
+	return applied, dbError(err, "product")
+}
+
+// ApplyScheduledPrices switches products to their scheduled prices every
+// interval until ctx is done. Reads with as_of see a scheduled price from
+// the moment it takes effect; the products themselves lag by at most
+// interval.
+func ApplyScheduledPrices(ctx context.Context, interval time.Duration, svc IPriceService) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		applied, err := svc.ApplyScheduledPrices(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("apply scheduled prices")
+			continue
+		}
+		if applied > 0 {
+			log.Info().Int64("applied", applied).Msg("scheduled prices applied")
+		}
+	}
+}
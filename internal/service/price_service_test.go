@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
+	"gorm.io/gorm"
+)
+
+func newTestPriceService(repo *mocks.MockPriceRepo, now time.Time) *priceService {
+	svc := NewPriceService(repo)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestSchedulePricePassesPeriod(t *testing.T) {
+	repo := new(mocks.MockPriceRepo)
+	now := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	svc := newTestPriceService(repo, now)
+
+	productId := uuid.New()
+	from := now.Add(24 * time.Hour)
+	expected := model.ProductPrice{ProductId: productId, Price: 17.99, EffectiveFrom: from}
+	repo.On("SchedulePrice", mock.Anything, expected).Return(expected, nil)
+
+	_, err := svc.SchedulePrice(context.Background(), productId.String(), model.PriceRequest{Price: 17.99, EffectiveFrom: from})
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestSchedulePriceRejectsPastOrEmptyPeriod(t *testing.T) {
+	repo := new(mocks.MockPriceRepo)
+	now := time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	svc := newTestPriceService(repo, now)
+
+	to := now.Add(-time.Hour)
+	_, err := svc.SchedulePrice(context.Background(), uuid.New().String(), model.PriceRequest{
+		Price:         17.99,
+		EffectiveFrom: now,
+		EffectiveTo:   &to,
+	})
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, []model.FieldError{
+		{Field: "effective_from", Message: "must be in the future"},
+		{Field: "effective_to", Message: "must be after effective_from"},
+	}, domainErr.Details)
+	repo.AssertNotCalled(t, "SchedulePrice", mock.Anything, mock.Anything)
+}
+
+func TestGetPricesOfUnknownProduct(t *testing.T) {
+	repo := new(mocks.MockPriceRepo)
+	svc := newTestPriceService(repo, time.Now())
+
+	productId := uuid.New().String()
+	repo.On("GetPrices", mock.Anything, productId).Return([]model.ProductPrice(nil), gorm.ErrRecordNotFound)
+
+	_, err := svc.GetPrices(context.Background(), productId)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
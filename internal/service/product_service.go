@@ -3,65 +3,340 @@ package service
 import (
 	"codeberg.org/go-pdf/fpdf"
 	"context"
+	"errors"
 	"fmt"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/metrics"
 	"github.com/thinhpq0112/soa-backend/internal/model"
 	"github.com/thinhpq0112/soa-backend/internal/repository"
-
+	"github.com/thinhpq0112/soa-backend/internal/tracing"
+	"github.com/thinhpq0112/soa-backend/internal/validation"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
 	"time"
 )
 
 type IProductService interface {
 	GetProducts(ctx context.Context, pageNumber, limit *int, lastCreatedAt *time.Time, option *model.FilterOption) ([]model.Product, error)
 	GetProductById(ctx context.Context, id string) (model.Product, error)
+	GetProductAsOf(ctx context.Context, id string, asOf time.Time) (model.Product, error)
 	AddProduct(ctx context.Context, product model.Product) error
 	UpdateProduct(ctx context.Context, product model.Product) error
-	DeleteProduct(ctx context.Context, id string) error
+	PatchProduct(ctx context.Context, id string, version int, patch ProductPatch) (model.Product, error)
+	DeleteProduct(ctx context.Context, id string, version int) error
+	GetDeletedProducts(ctx context.Context) ([]model.Product, error)
+	RestoreProduct(ctx context.Context, id string) (model.Product, error)
 
-	GetProductsPerCategory(ctx context.Context) ([]model.ProductsPerCategoryResponse, error)
+	GetProductsPerCategory(ctx context.Context, level int) ([]model.ProductsPerCategoryResponse, error)
 	GetProductsPerSupplier(ctx context.Context) ([]model.ProductsPerSupplierResponse, error)
 	GenerateProductPDF(ctx context.Context) (string, error)
 }
 
+// ProductPatch computes the desired state of a product from its current one.
+type ProductPatch func(current model.Product) (model.Product, error)
+
 type productService struct {
-	repo repository.IProductRepo
+	repo       repository.IProductRepo
+	categories repository.ICategoryRepo
+	suppliers  repository.ISupplierRepo
 }
 
-func NewProductService(repo repository.IProductRepo) *productService {
-	return &productService{repo: repo}
+func NewProductService(repo repository.IProductRepo, categories repository.ICategoryRepo, suppliers repository.ISupplierRepo) *productService {
+	return &productService{repo: repo, categories: categories, suppliers: suppliers}
 }
 
 func (s *productService) GetProducts(ctx context.Context, pageNumber, limit *int, lastCreatedAt *time.Time, option *model.FilterOption) ([]model.Product, error) {
-	return s.repo.GetProducts(ctx, pageNumber, limit, lastCreatedAt, option)
+	products, err := s.repo.GetProducts(ctx, pageNumber, limit, lastCreatedAt, option)
+	return products, dbError(err, "product")
 }
 
 func (s *productService) GetProductById(ctx context.Context, id string) (model.Product, error) {
-	return s.repo.GetProductById(ctx, id)
+	product, err := s.repo.GetProductById(ctx, id)
+	return product, dbError(err, "product")
+}
+
+func (s *productService) GetProductAsOf(ctx context.Context, id string, asOf time.Time) (model.Product, error) {
+	product, err := s.repo.GetProductAsOf(ctx, id, asOf)
+	return product, dbError(err, "product")
 }
 
 func (s *productService) AddProduct(ctx context.Context, product model.Product) error {
-	return s.repo.AddProduct(ctx, product)
+	if err := s.prepareProduct(ctx, &product, nil); err != nil {
+		return err
+	}
+	return productWriteError(s.repo.AddProduct(ctx, product))
 }
 
 func (s *productService) UpdateProduct(ctx context.Context, product model.Product) error {
-	return s.repo.UpdateProduct(ctx, product)
+	current, err := s.repo.GetProductById(ctx, product.Id.String())
+	if err != nil {
+		return dbError(err, "product")
+	}
+	// Zero fields are left as they are, the quantity included.
+	if product.Quantity == 0 {
+		product.Quantity = current.Quantity
+	}
+	if err := s.prepareProduct(ctx, &product, &current); err != nil {
+		return err
+	}
+	return productWriteError(s.repo.UpdateProduct(ctx, product))
+}
+
+// PatchProduct applies patch to the stored product, validates the result and
+// writes only the columns that changed, so zero values such as a price of 0
+// or an empty name field are saved too. A positive version must match the
+// stored one; either way the write fails if the product changes meanwhile.
+func (s *productService) PatchProduct(ctx context.Context, id string, version int, patch ProductPatch) (model.Product, error) {
+	current, err := s.repo.GetProductById(ctx, id)
+	if err != nil {
+		return model.Product{}, dbError(err, "product")
+	}
+	if version > 0 && version != current.Version {
+		return model.Product{}, staleVersionError("product")
+	}
+
+	patched, err := patch(current)
+	if err != nil {
+		return model.Product{}, err
+	}
+	patched.Id = current.Id
+	if err := s.prepareProduct(ctx, &patched, &current); err != nil {
+		return model.Product{}, err
+	}
+
+	columns := changedColumns(current, patched)
+	if len(columns) == 0 {
+		return current, nil
+	}
+	if err := s.repo.UpdateProductColumns(ctx, id, current.Version, columns); err != nil {
+		return model.Product{}, productWriteError(err)
+	}
+	updated, err := s.repo.GetProductById(ctx, id)
+	return updated, dbError(err, "product")
+}
+
+// productWriteError is dbError for the writes that put a product under a
+// category and supplier, which are checked again when the product is
+// written in case one was put in the trash since.
+func productWriteError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrCategoryDeleted):
+		return ConflictError("category_deleted", "the category of this product is in the trash, restore it first").wrap(err)
+	case errors.Is(err, repository.ErrSupplierDeleted):
+		return ConflictError("supplier_deleted", "the supplier of this product is in the trash, restore it first").wrap(err)
+	}
+	return dbError(err, "product")
 }
 
-func (s *productService) DeleteProduct(ctx context.Context, id string) error {
-	return s.repo.DeleteProduct(ctx, id)
+func changedColumns(before, after model.Product) map[string]interface{} {
+	columns := make(map[string]interface{})
+	if before.Reference != after.Reference {
+		columns["reference"] = after.Reference
+	}
+	if before.Name != after.Name {
+		columns["name"] = after.Name
+	}
+	if !before.AddedDate.Equal(after.AddedDate) {
+		columns["added_date"] = after.AddedDate
+	}
+	if before.Status != after.Status {
+		columns["status"] = after.Status
+	}
+	if before.CategoryId != after.CategoryId {
+		columns["category_id"] = after.CategoryId
+	}
+	if before.Price != after.Price {
+		columns["price"] = after.Price
+	}
+	if before.SupplierId != after.SupplierId {
+		columns["supplier_id"] = after.SupplierId
+	}
+	if before.Quantity != after.Quantity {
+		columns["quantity"] = after.Quantity
+	}
+	if !equalInt(before.ReorderPoint, after.ReorderPoint) {
+		columns["reorder_point"] = after.ReorderPoint
+	}
+	if !equalInt(before.ReorderQuantity, after.ReorderQuantity) {
+		columns["reorder_quantity"] = after.ReorderQuantity
+	}
+	return columns
 }
 
-func (s *productService) GetProductsPerCategory(ctx context.Context) ([]model.ProductsPerCategoryResponse, error) {
-	return s.repo.GetProductsPerCategory(ctx)
+func equalInt(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// prepareProduct resolves the category and supplier references and checks
+// the field rules, reporting every problem at once. A reference is either an
+// id, which must exist, or, when the id is empty, a name carried in
+// product.Category or product.Supplier. current is the stored product when
+// product updates it. Only active categories take new products, but a
+// product may stay in its current category whatever its status. A new
+// product with a quantity says which warehouse holds it, and the stock of a
+// stored product only changes through inventory movements and transfers.
+// The associations are cleared so that gorm only writes the foreign keys.
+func (s *productService) prepareProduct(ctx context.Context, product *model.Product, current *model.Product) error {
+	var errs validation.Errors
+	currentCategory := uuid.Nil
+	if current != nil {
+		currentCategory = current.CategoryId
+		if product.Quantity != current.Quantity {
+			errs.Add("quantity", "cannot be changed directly, post an inventory movement instead")
+		}
+		if len(product.Stock) > 0 {
+			errs.Add("warehouse_id", "cannot be changed directly, post an inventory movement or transfer instead")
+		}
+	} else if product.Quantity != 0 && len(product.Stock) == 0 {
+		errs.Add("warehouse_id", "is required with a quantity")
+	}
+	if err := s.resolveCategory(ctx, product, currentCategory, &errs); err != nil {
+		return err
+	}
+	if err := s.resolveSupplier(ctx, product, &errs); err != nil {
+		return err
+	}
+	product.Category, product.Supplier = nil, nil
+
+	for _, fe := range validation.Struct(*product) {
+		if fe.Field == "category_id" && errs.Has("category_name") ||
+			fe.Field == "supplier_id" && errs.Has("supplier_name") {
+			continue
+		}
+		errs.Add(fe.Field, "%s", fe.Message)
+	}
+	return invalidInput(errs)
+}
+
+func (s *productService) resolveCategory(ctx context.Context, product *model.Product, currentCategory uuid.UUID, errs *validation.Errors) error {
+	if product.CategoryId != uuid.Nil {
+		category, err := s.categories.GetCategoryById(ctx, product.CategoryId.String())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			errs.Add("category_id", "category does not exist")
+			return nil
+		}
+		if err != nil {
+			return dbError(err, "category")
+		}
+		if category.Status != model.CategoryStatusActive && category.Id != currentCategory {
+			errs.Add("category_id", "category is %s", category.Status)
+		}
+		return nil
+	}
+	if product.Category == nil || product.Category.Name == "" {
+		return nil
+	}
+
+	name := product.Category.Name
+	matches, err := s.categories.FindCategoriesByName(ctx, name)
+	if err != nil {
+		return dbError(err, "category")
+	}
+	switch len(matches) {
+	case 0:
+		errs.Add("category_name", "category %q does not exist", name)
+	case 1:
+		if matches[0].Status != model.CategoryStatusActive && matches[0].Id != currentCategory {
+			errs.Add("category_name", "category %q is %s", name, matches[0].Status)
+			return nil
+		}
+		product.CategoryId = matches[0].Id
+	default:
+		errs.Add("category_name", "several categories are named %q, send category_id instead", name)
+	}
+	return nil
+}
+
+func (s *productService) resolveSupplier(ctx context.Context, product *model.Product, errs *validation.Errors) error {
+	if product.SupplierId != uuid.Nil {
+		_, err := s.suppliers.GetSupplierById(ctx, product.SupplierId.String())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			errs.Add("supplier_id", "supplier does not exist")
+			return nil
+		}
+		return dbError(err, "supplier")
+	}
+	if product.Supplier == nil || product.Supplier.Name == "" {
+		return nil
+	}
+
+	supplier, err := s.suppliers.GetSupplierByName(ctx, product.Supplier.Name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		errs.Add("supplier_name", "supplier %q does not exist", product.Supplier.Name)
+		return nil
+	}
+	if err != nil {
+		return dbError(err, "supplier")
+	}
+	product.SupplierId = supplier.Id
+	return nil
+}
+
+// DeleteProduct moves the product to the trash, from where RestoreProduct
+// can bring it back until it is purged.
+func (s *productService) DeleteProduct(ctx context.Context, id string, version int) error {
+	return deleteError(s.repo.DeleteProduct(ctx, id, version, auth.Actor(ctx)), "product")
+}
+
+func (s *productService) GetDeletedProducts(ctx context.Context) ([]model.Product, error) {
+	products, err := s.repo.GetDeletedProducts(ctx)
+	return products, dbError(err, "product")
+}
+
+// RestoreProduct takes the product out of the trash. Its category and
+// supplier must not be in the trash themselves, and no live product may have
+// taken its reference meanwhile.
+func (s *productService) RestoreProduct(ctx context.Context, id string) (model.Product, error) {
+	trashed, err := s.repo.GetDeletedProductById(ctx, id)
+	if err != nil {
+		return model.Product{}, dbError(err, "product")
+	}
+	if trashed.Category == nil || trashed.Category.DeletedAt.Valid {
+		return model.Product{}, ConflictError("category_deleted", "the category of this product is in the trash, restore it first")
+	}
+	if trashed.Supplier == nil || trashed.Supplier.DeletedAt.Valid {
+		return model.Product{}, ConflictError("supplier_deleted", "the supplier of this product is in the trash, restore it first")
+	}
+	if err := s.repo.RestoreProduct(ctx, id); err != nil {
+		return model.Product{}, productWriteError(err)
+	}
+	product, err := s.repo.GetProductById(ctx, id)
+	return product, dbError(err, "product")
+}
+
+// GetProductsPerCategory counts products per category, rolled up to the
+// categories at level when it is positive.
+func (s *productService) GetProductsPerCategory(ctx context.Context, level int) ([]model.ProductsPerCategoryResponse, error) {
+	if level < 0 {
+		return nil, ValidationError("invalid_level", "level must be 0 or a positive tree depth")
+	}
+	stats, err := s.repo.GetProductsPerCategory(ctx, level)
+	return stats, dbError(err, "product")
 }
 
 func (s *productService) GetProductsPerSupplier(ctx context.Context) ([]model.ProductsPerSupplierResponse, error) {
-	return s.repo.GetProductsPerSupplier(ctx)
+	stats, err := s.repo.GetProductsPerSupplier(ctx)
+	return stats, dbError(err, "product")
 }
 
 func (s *productService) GenerateProductPDF(ctx context.Context) (string, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "productService.GenerateProductPDF")
+	defer span.End()
+
+	start := time.Now()
+	defer func() {
+		metrics.PDFGenerationDuration.Observe(time.Since(start).Seconds())
+	}()
+
 	products, err := s.repo.GetProducts(ctx, nil, nil, nil, &model.FilterOption{})
 	if err != nil {
-		return "", err
+		return "", dbError(err, "product")
 	}
 
 	pdf := fpdf.New("L", "mm", "A3", "")
@@ -74,7 +349,7 @@ func (s *productService) GenerateProductPDF(ctx context.Context) (string, error)
 
 	headers := []string{
 		"Product Reference", "Product Name", "Date Added", "Status",
-		"Product Category", "Price (EUR)", "Stock Location (City)",
+		"Product Category", "Price (EUR)", "Stock Locations (City)",
 		"Supplier", "Availability Quantity",
 	}
 
@@ -107,7 +382,7 @@ func (s *productService) GenerateProductPDF(ctx context.Context) (string, error)
 
 		pdf.CellFormat(35, 8, fmt.Sprintf("%.2f", product.Price), "1", 0, "C", false, 0, "")
 
-		pdf.CellFormat(40, 8, product.StockCity, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(40, 8, stockCities(product), "1", 0, "C", false, 0, "")
 
 		supplierName := "Unknown"
 		if product.Supplier != nil {
@@ -124,6 +399,18 @@ func (s *productService) GenerateProductPDF(ctx context.Context) (string, error)
 	if err != nil {
 		return "", err
 	}
+	zerolog.Ctx(ctx).Info().Int("products", len(products)).Dur("elapsed", time.Since(start)).Msg("product report generated")
 
 	return filePath, nil
 }
+
+// stockCities lists the cities of the warehouses holding product, once each.
+func stockCities(product model.Product) string {
+	var cities []string
+	for _, stock := range product.Stock {
+		if stock.Warehouse != nil && !slices.Contains(cities, stock.Warehouse.City) {
+			cities = append(cities, stock.Warehouse.City)
+		}
+	}
+	return strings.Join(cities, ", ")
+}
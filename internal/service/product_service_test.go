@@ -0,0 +1,413 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
+	"gorm.io/gorm"
+)
+
+func TestAddProductRejectsUnknownReferences(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewProductService(products, categories, suppliers)
+
+	product := model.Product{
+		Reference:  "REF-001",
+		Name:       "Desk lamp",
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		SupplierId: uuid.New(),
+		Price:      -3,
+	}
+	categories.On("GetCategoryById", mock.Anything, product.CategoryId.String()).
+		Return(model.Category{}, gorm.ErrRecordNotFound)
+	suppliers.On("GetSupplierById", mock.Anything, product.SupplierId.String()).
+		Return(model.Supplier{Id: product.SupplierId}, nil)
+
+	err := svc.AddProduct(context.Background(), product)
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, "validation_failed", domainErr.Code)
+	assert.ElementsMatch(t, []model.FieldError{
+		{Field: "price", Message: "must be at least 0"},
+		{Field: "category_id", Message: "category does not exist"},
+	}, domainErr.Details)
+	products.AssertNotCalled(t, "AddProduct", mock.Anything, mock.Anything)
+}
+
+func TestAddProductStoresValidProduct(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewProductService(products, categories, suppliers)
+
+	product := model.Product{
+		Reference:  "REF-001",
+		Name:       "Desk lamp",
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		SupplierId: uuid.New(),
+	}
+	categories.On("GetCategoryById", mock.Anything, product.CategoryId.String()).
+		Return(model.Category{Id: product.CategoryId, Status: model.CategoryStatusActive}, nil)
+	suppliers.On("GetSupplierById", mock.Anything, product.SupplierId.String()).
+		Return(model.Supplier{Id: product.SupplierId}, nil)
+	products.On("AddProduct", mock.Anything, product).Return(nil)
+
+	require.NoError(t, svc.AddProduct(context.Background(), product))
+	products.AssertExpectations(t)
+}
+
+func TestAddProductReportsSupplierDeletedMeanwhile(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewProductService(products, categories, suppliers)
+
+	product := model.Product{
+		Reference:  "REF-001",
+		Name:       "Desk lamp",
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		SupplierId: uuid.New(),
+	}
+	categories.On("GetCategoryById", mock.Anything, product.CategoryId.String()).
+		Return(model.Category{Id: product.CategoryId, Status: model.CategoryStatusActive}, nil)
+	suppliers.On("GetSupplierById", mock.Anything, product.SupplierId.String()).
+		Return(model.Supplier{Id: product.SupplierId}, nil)
+	products.On("AddProduct", mock.Anything, product).Return(repository.ErrSupplierDeleted)
+
+	err := svc.AddProduct(context.Background(), product)
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "supplier_deleted", domainErr.Code)
+}
+
+func TestAddProductRequiresWarehouseForQuantity(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewProductService(products, categories, suppliers)
+
+	product := model.Product{
+		Reference:  "REF-001",
+		Name:       "Desk lamp",
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		SupplierId: uuid.New(),
+		Quantity:   12,
+	}
+	categories.On("GetCategoryById", mock.Anything, product.CategoryId.String()).
+		Return(model.Category{Id: product.CategoryId, Status: model.CategoryStatusActive}, nil)
+	suppliers.On("GetSupplierById", mock.Anything, product.SupplierId.String()).
+		Return(model.Supplier{Id: product.SupplierId}, nil)
+
+	err := svc.AddProduct(context.Background(), product)
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, []model.FieldError{{Field: "warehouse_id", Message: "is required with a quantity"}}, domainErr.Details)
+	products.AssertNotCalled(t, "AddProduct", mock.Anything, mock.Anything)
+}
+
+func TestAddProductResolvesReferencesByName(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewProductService(products, categories, suppliers)
+
+	categoryId, supplierId := uuid.New(), uuid.New()
+	categories.On("FindCategoriesByName", mock.Anything, "Lighting").
+		Return([]model.Category{{Id: categoryId, Name: "Lighting", Status: model.CategoryStatusActive}}, nil)
+	suppliers.On("GetSupplierByName", mock.Anything, "Acme").
+		Return(model.Supplier{Id: supplierId, Name: "Acme"}, nil)
+	products.On("AddProduct", mock.Anything, mock.MatchedBy(func(p model.Product) bool {
+		return p.CategoryId == categoryId && p.SupplierId == supplierId && p.Category == nil && p.Supplier == nil
+	})).Return(nil)
+
+	err := svc.AddProduct(context.Background(), model.Product{
+		Reference: "REF-001",
+		Name:      "Desk lamp",
+		Status:    model.ProductStatusAvailable,
+		Category:  &model.Category{Name: "Lighting"},
+		Supplier:  &model.Supplier{Name: "Acme"},
+	})
+
+	require.NoError(t, err)
+	products.AssertExpectations(t)
+}
+
+func TestAddProductRejectsAmbiguousCategoryName(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewProductService(products, categories, suppliers)
+
+	categories.On("FindCategoriesByName", mock.Anything, "Lighting").
+		Return([]model.Category{{Id: uuid.New()}, {Id: uuid.New()}}, nil)
+	suppliers.On("GetSupplierByName", mock.Anything, "Acme").
+		Return(model.Supplier{}, gorm.ErrRecordNotFound)
+
+	err := svc.AddProduct(context.Background(), model.Product{
+		Reference: "REF-001",
+		Name:      "Desk lamp",
+		Status:    model.ProductStatusAvailable,
+		Category:  &model.Category{Name: "Lighting"},
+		Supplier:  &model.Supplier{Name: "Acme"},
+	})
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ElementsMatch(t, []model.FieldError{
+		{Field: "category_name", Message: `several categories are named "Lighting", send category_id instead`},
+		{Field: "supplier_name", Message: `supplier "Acme" does not exist`},
+	}, domainErr.Details)
+	products.AssertNotCalled(t, "AddProduct", mock.Anything, mock.Anything)
+}
+
+func TestAddProductRejectsInactiveCategory(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewProductService(products, categories, suppliers)
+
+	product := model.Product{
+		Reference:  "REF-001",
+		Name:       "Desk lamp",
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		SupplierId: uuid.New(),
+	}
+	categories.On("GetCategoryById", mock.Anything, product.CategoryId.String()).
+		Return(model.Category{Id: product.CategoryId, Status: model.CategoryStatusArchived}, nil)
+	suppliers.On("GetSupplierById", mock.Anything, product.SupplierId.String()).
+		Return(model.Supplier{Id: product.SupplierId}, nil)
+
+	err := svc.AddProduct(context.Background(), product)
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, []model.FieldError{{Field: "category_id", Message: "category is archived"}}, domainErr.Details)
+	products.AssertNotCalled(t, "AddProduct", mock.Anything, mock.Anything)
+}
+
+func TestPatchProductKeepsInactiveCategory(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewProductService(products, categories, suppliers)
+
+	current := model.Product{
+		Id:         uuid.New(),
+		Reference:  "REF-001",
+		Name:       "Desk lamp",
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		SupplierId: uuid.New(),
+		Version:    1,
+	}
+	id := current.Id.String()
+	products.On("GetProductById", mock.Anything, id).Return(current, nil)
+	categories.On("GetCategoryById", mock.Anything, current.CategoryId.String()).
+		Return(model.Category{Id: current.CategoryId, Status: model.CategoryStatusInactive}, nil)
+	suppliers.On("GetSupplierById", mock.Anything, current.SupplierId.String()).
+		Return(model.Supplier{Id: current.SupplierId}, nil)
+	products.On("UpdateProductColumns", mock.Anything, id, 1, map[string]interface{}{"name": "Floor lamp"}).Return(nil)
+
+	_, err := svc.PatchProduct(context.Background(), id, 0, func(p model.Product) (model.Product, error) {
+		p.Name = "Floor lamp"
+		return p, nil
+	})
+
+	require.NoError(t, err)
+	products.AssertExpectations(t)
+}
+
+func TestPatchProductWritesOnlyChangedColumns(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewProductService(products, categories, suppliers)
+
+	current := model.Product{
+		Id:         uuid.New(),
+		Reference:  "REF-001",
+		Name:       "Desk lamp",
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		SupplierId: uuid.New(),
+		Price:      19.99,
+		Quantity:   12,
+		Version:    3,
+	}
+	id := current.Id.String()
+	products.On("GetProductById", mock.Anything, id).Return(current, nil)
+	categories.On("GetCategoryById", mock.Anything, current.CategoryId.String()).
+		Return(model.Category{Id: current.CategoryId}, nil)
+	suppliers.On("GetSupplierById", mock.Anything, current.SupplierId.String()).
+		Return(model.Supplier{Id: current.SupplierId}, nil)
+	products.On("UpdateProductColumns", mock.Anything, id, 3, map[string]interface{}{
+		"price": float64(0),
+	}).Return(nil)
+
+	_, err := svc.PatchProduct(context.Background(), id, 0, func(p model.Product) (model.Product, error) {
+		p.Price = 0
+		return p, nil
+	})
+
+	require.NoError(t, err)
+	products.AssertExpectations(t)
+}
+
+func TestPatchProductValidatesResult(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewProductService(products, categories, suppliers)
+
+	current := model.Product{
+		Id:         uuid.New(),
+		Reference:  "REF-001",
+		Name:       "Desk lamp",
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		SupplierId: uuid.New(),
+	}
+	id := current.Id.String()
+	products.On("GetProductById", mock.Anything, id).Return(current, nil)
+	categories.On("GetCategoryById", mock.Anything, mock.Anything).Return(model.Category{}, nil)
+	suppliers.On("GetSupplierById", mock.Anything, mock.Anything).Return(model.Supplier{}, nil)
+
+	_, err := svc.PatchProduct(context.Background(), id, 0, func(p model.Product) (model.Product, error) {
+		p.Price = -1
+		return p, nil
+	})
+
+	assert.ErrorIs(t, err, ErrValidation)
+	products.AssertNotCalled(t, "UpdateProductColumns", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPatchProductRejectsQuantityChange(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewProductService(products, categories, suppliers)
+
+	current := model.Product{
+		Id:         uuid.New(),
+		Reference:  "REF-001",
+		Name:       "Desk lamp",
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		SupplierId: uuid.New(),
+		Quantity:   12,
+	}
+	id := current.Id.String()
+	products.On("GetProductById", mock.Anything, id).Return(current, nil)
+	categories.On("GetCategoryById", mock.Anything, mock.Anything).Return(model.Category{Id: current.CategoryId}, nil)
+	suppliers.On("GetSupplierById", mock.Anything, mock.Anything).Return(model.Supplier{}, nil)
+
+	_, err := svc.PatchProduct(context.Background(), id, 0, func(p model.Product) (model.Product, error) {
+		p.Quantity = 0
+		return p, nil
+	})
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, "quantity", domainErr.Details[0].Field)
+	products.AssertNotCalled(t, "UpdateProductColumns", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPatchProductRejectsStaleVersion(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	svc := NewProductService(products, new(mocks.MockCategoryRepo), new(mocks.MockSupplierRepo))
+
+	current := model.Product{Id: uuid.New(), Version: 4}
+	products.On("GetProductById", mock.Anything, current.Id.String()).Return(current, nil)
+
+	_, err := svc.PatchProduct(context.Background(), current.Id.String(), 3, func(p model.Product) (model.Product, error) {
+		t.Fatal("patch applied to a stale version")
+		return p, nil
+	})
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrPreconditionFailed)
+	assert.Equal(t, "version_mismatch", domainErr.Code)
+}
+
+func TestDeleteProductRecordsActor(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	svc := NewProductService(products, new(mocks.MockCategoryRepo), new(mocks.MockSupplierRepo))
+
+	id := uuid.New().String()
+	claims := &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{Subject: "alice"}}
+	products.On("DeleteProduct", mock.Anything, id, 2, "alice").Return(nil)
+
+	err := svc.DeleteProduct(auth.NewContext(context.Background(), claims), id, 2)
+
+	assert.NoError(t, err)
+	products.AssertExpectations(t)
+}
+
+func TestRestoreProductRequiresLiveCategory(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	svc := NewProductService(products, new(mocks.MockCategoryRepo), new(mocks.MockSupplierRepo))
+
+	trashed := model.Product{
+		Id:        uuid.New(),
+		DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true},
+		Category:  &model.Category{Id: uuid.New(), DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}},
+		Supplier:  &model.Supplier{Id: uuid.New()},
+	}
+	products.On("GetDeletedProductById", mock.Anything, trashed.Id.String()).Return(trashed, nil)
+
+	_, err := svc.RestoreProduct(context.Background(), trashed.Id.String())
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "category_deleted", domainErr.Code)
+	products.AssertNotCalled(t, "RestoreProduct", mock.Anything, mock.Anything)
+}
+
+func TestRestoreProduct(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	svc := NewProductService(products, new(mocks.MockCategoryRepo), new(mocks.MockSupplierRepo))
+
+	trashed := model.Product{
+		Id:        uuid.New(),
+		Version:   3,
+		DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true},
+		Category:  &model.Category{Id: uuid.New()},
+		Supplier:  &model.Supplier{Id: uuid.New()},
+	}
+	restored := trashed
+	restored.Version = 4
+	restored.DeletedAt = gorm.DeletedAt{}
+	products.On("GetDeletedProductById", mock.Anything, trashed.Id.String()).Return(trashed, nil)
+	products.On("RestoreProduct", mock.Anything, trashed.Id.String()).Return(nil)
+	products.On("GetProductById", mock.Anything, trashed.Id.String()).Return(restored, nil)
+
+	product, err := svc.RestoreProduct(context.Background(), trashed.Id.String())
+
+	require.NoError(t, err)
+	assert.Equal(t, 4, product.Version)
+	products.AssertExpectations(t)
+}
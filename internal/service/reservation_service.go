@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/validation"
+	"time"
+)
+
+type IReservationService interface {
+	Reserve(ctx context.Context, req model.ReservationRequest) (model.Reservation, error)
+	GetReservationById(ctx context.Context, id string) (model.Reservation, error)
+	ConfirmReservation(ctx context.Context, id string, req model.ConfirmReservationRequest) (model.Reservation, error)
+	ReleaseReservation(ctx context.Context, id string) (model.Reservation, error)
+	GetAvailability(ctx context.Context, productId string) (model.StockAvailability, error)
+	ExpireReservations(ctx context.Context) (int64, error)
+}
+
+type reservationService struct {
+	repo       repository.IReservationRepo
+	defaultTTL time.Duration
+	maxTTL     time.Duration
+	now        func() time.Time
+}
+
+func NewReservationService(repo repository.IReservationRepo, defaultTTL, maxTTL time.Duration) *reservationService {
+	return &reservationService{repo: repo, defaultTTL: defaultTTL, maxTTL: maxTTL, now: time.Now}
+}
+
+// Reserve holds a quantity of a product for req.TTLSeconds, or the default
+// TTL, provided the stock available to promise covers it.
+func (s *reservationService) Reserve(ctx context.Context, req model.ReservationRequest) (model.Reservation, error) {
+	errs := validation.Struct(req)
+	ttl := time.Duration(req.TTLSeconds) * time.Second
+	if ttl > s.maxTTL {
+		errs.Add("ttl_seconds", "must be at most %d", int(s.maxTTL/time.Second))
+	}
+	if err := invalidInput(errs); err != nil {
+		return model.Reservation{}, err
+	}
+	if ttl == 0 {
+		ttl = s.defaultTTL
+	}
+
+	now := s.now()
+	reservation, err := s.repo.Reserve(ctx, model.Reservation{
+		ProductId: req.ProductId,
+		Quantity:  req.Quantity,
+		Reference: req.Reference,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, now)
+	var insufficient *repository.InsufficientStockError
+	if errors.As(err, &insufficient) {
+		return model.Reservation{}, ConflictError("insufficient_stock", "only %d available to promise", insufficient.Available).wrap(err)
+	}
+	return reservation, dbError(err, "product")
+}
+
+func (s *reservationService) GetReservationById(ctx context.Context, id string) (model.Reservation, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.Reservation{}, ValidationError("invalid_id", "id must be a UUID")
+	}
+	reservation, err := s.repo.GetReservationById(ctx, id)
+	return reservation, dbError(err, "reservation")
+}
+
+// ConfirmReservation ships the reserved stock from req.WarehouseId and closes
+// the reservation.
+func (s *reservationService) ConfirmReservation(ctx context.Context, id string, req model.ConfirmReservationRequest) (model.Reservation, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.Reservation{}, ValidationError("invalid_id", "id must be a UUID")
+	}
+	if err := invalidInput(validation.Struct(req)); err != nil {
+		return model.Reservation{}, err
+	}
+	reservation, err := s.repo.ConfirmReservation(ctx, id, req.WarehouseId, s.now())
+	if errors.Is(err, repository.ErrInsufficientStock) || errors.Is(err, repository.ErrUnknownWarehouse) {
+		return model.Reservation{}, stockError(err)
+	}
+	return reservation, reservationError(err)
+}
+
+// ReleaseReservation gives the reserved stock back without shipping it.
+func (s *reservationService) ReleaseReservation(ctx context.Context, id string) (model.Reservation, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return model.Reservation{}, ValidationError("invalid_id", "id must be a UUID")
+	}
+	reservation, err := s.repo.ReleaseReservation(ctx, id, s.now())
+	return reservation, reservationError(err)
+}
+
+// reservationError is dbError for changes of a reservation.
+func reservationError(err error) error {
+	var closed *repository.ReservationClosedError
+	if errors.As(err, &closed) {
+		return ConflictError("reservation_closed", "reservation is %s", closed.Status).wrap(err)
+	}
+	return dbError(err, "reservation")
+}
+
+func (s *reservationService) GetAvailability(ctx context.Context, productId string) (model.StockAvailability, error) {
+	if _, err := uuid.Parse(productId); err != nil {
+		return model.StockAvailability{}, ValidationError("invalid_id", "id must be a UUID")
+	}
+	availability, err := s.repo.GetAvailability(ctx, productId, s.now())
+	return availability, dbError(err, "product")
+}
+
+func (s *reservationService) ExpireReservations(ctx context.Context) (int64, error) {
+	expired, err := s.repo.ExpireReservations(ctx, s.now())
+	return expired, dbError(err, "reservation")
+}
+
+// SweepExpiredReservations closes expired reservations every interval until
+// ctx is done. Expired reservations hold no stock even before they are
+// swept; sweeping records that they are closed.
+func SweepExpiredReservations(ctx context.Context, interval time.Duration, svc IReservationService) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		expired, err := svc.ExpireReservations(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("expire reservations")
+			continue
+		}
+		if expired > 0 {
+			log.Debug().Int64("expired", expired).Msg("expired reservations released")
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
+	"gorm.io/gorm"
+)
+
+func newTestReservationService(repo *mocks.MockReservationRepo, now time.Time) *reservationService {
+	svc := NewReservationService(repo, 15*time.Minute, time.Hour)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestReserveUsesDefaultTTL(t *testing.T) {
+	repo := new(mocks.MockReservationRepo)
+	now := time.Now()
+	svc := newTestReservationService(repo, now)
+
+	productId := uuid.New()
+	expected := model.Reservation{
+		ProductId: productId,
+		Quantity:  2,
+		Reference: "SO-1042",
+		CreatedAt: now,
+		ExpiresAt: now.Add(15 * time.Minute),
+	}
+	repo.On("Reserve", mock.Anything, expected, now).Return(expected, nil)
+
+	_, err := svc.Reserve(context.Background(), model.ReservationRequest{ProductId: productId, Quantity: 2, Reference: "SO-1042"})
+
+	require.NoError(t, err)
+	repo.AssertExpectations(t)
+}
+
+func TestReserveRejectsTTLAboveMax(t *testing.T) {
+	repo := new(mocks.MockReservationRepo)
+	svc := newTestReservationService(repo, time.Now())
+
+	_, err := svc.Reserve(context.Background(), model.ReservationRequest{ProductId: uuid.New(), Quantity: 1, TTLSeconds: 7200})
+
+	assert.ErrorIs(t, err, ErrValidation)
+	repo.AssertNotCalled(t, "Reserve", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestReserveReportsInsufficientStock(t *testing.T) {
+	repo := new(mocks.MockReservationRepo)
+	svc := newTestReservationService(repo, time.Now())
+
+	repo.On("Reserve", mock.Anything, mock.Anything, mock.Anything).
+		Return(model.Reservation{}, &repository.InsufficientStockError{Available: 1})
+
+	_, err := svc.Reserve(context.Background(), model.ReservationRequest{ProductId: uuid.New(), Quantity: 3})
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "insufficient_stock", domainErr.Code)
+	assert.Contains(t, domainErr.Message, "only 1 available")
+}
+
+func TestConfirmReservationReportsClosed(t *testing.T) {
+	repo := new(mocks.MockReservationRepo)
+	now := time.Now()
+	svc := newTestReservationService(repo, now)
+
+	id, warehouseId := uuid.NewString(), uuid.New()
+	repo.On("ConfirmReservation", mock.Anything, id, warehouseId, now).
+		Return(model.Reservation{}, &repository.ReservationClosedError{Status: model.ReservationReleased})
+
+	_, err := svc.ConfirmReservation(context.Background(), id, model.ConfirmReservationRequest{WarehouseId: warehouseId})
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "reservation_closed", domainErr.Code)
+	assert.Equal(t, "reservation is released", domainErr.Message)
+}
+
+func TestReleaseReservationNotFound(t *testing.T) {
+	repo := new(mocks.MockReservationRepo)
+	now := time.Now()
+	svc := newTestReservationService(repo, now)
+
+	id := uuid.NewString()
+	repo.On("ReleaseReservation", mock.Anything, id, now).
+		Return(model.Reservation{}, gorm.ErrRecordNotFound)
+
+	_, err := svc.ReleaseReservation(context.Background(), id)
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
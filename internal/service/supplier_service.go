@@ -2,16 +2,20 @@ package service
 
 import (
 	"context"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
 	"github.com/thinhpq0112/soa-backend/internal/model"
 	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/validation"
 )
 
 type ISupplierService interface {
 	GetSuppliers(ctx context.Context) ([]model.Supplier, error)
 	GetSupplierById(ctx context.Context, id string) (model.Supplier, error)
 	AddSupplier(ctx context.Context, supplier model.Supplier) error
-	UpdateSupplier(ctx context.Context, supplier model.Supplier) error
-	DeleteSupplier(ctx context.Context, id string) error
+	UpdateSupplier(ctx context.Context, supplier model.Supplier) (model.Supplier, error)
+	DeleteSupplier(ctx context.Context, id string, version int, policy model.DeletePolicy) error
+	GetDeletedSuppliers(ctx context.Context) ([]model.Supplier, error)
+	RestoreSupplier(ctx context.Context, id string) (model.Supplier, error)
 }
 
 type supplierService struct {
@@ -23,21 +27,53 @@ func NewSupplierService(repo repository.ISupplierRepo) *supplierService {
 }
 
 func (s *supplierService) GetSuppliers(ctx context.Context) ([]model.Supplier, error) {
-	return s.repo.GetSuppliers(ctx)
+	suppliers, err := s.repo.GetSuppliers(ctx)
+	return suppliers, dbError(err, "supplier")
 }
 
 func (s *supplierService) GetSupplierById(ctx context.Context, id string) (model.Supplier, error) {
-	return s.repo.GetSupplierById(ctx, id)
+	supplier, err := s.repo.GetSupplierById(ctx, id)
+	return supplier, dbError(err, "supplier")
 }
 
 func (s *supplierService) AddSupplier(ctx context.Context, supplier model.Supplier) error {
-	return s.repo.AddSupplier(ctx, supplier)
+	if err := invalidInput(validation.Struct(supplier)); err != nil {
+		return err
+	}
+	return dbError(s.repo.AddSupplier(ctx, supplier), "supplier")
 }
 
-func (s *supplierService) UpdateSupplier(ctx context.Context, supplier model.Supplier) error {
-	return s.repo.UpdateSupplier(ctx, supplier)
+// UpdateSupplier saves supplier and returns it with its new version. A positive
+// supplier.Version is the version the client read, as sent in If-Match.
+func (s *supplierService) UpdateSupplier(ctx context.Context, supplier model.Supplier) (model.Supplier, error) {
+	if err := invalidInput(validation.Struct(supplier)); err != nil {
+		return model.Supplier{}, err
+	}
+	updated, err := s.repo.UpdateSupplier(ctx, supplier)
+	return updated, dbError(err, "supplier")
 }
 
-func (s *supplierService) DeleteSupplier(ctx context.Context, id string) error {
-	return s.repo.DeleteSupplier(ctx, id)
+// DeleteSupplier moves the supplier to the trash, from where RestoreSupplier
+// can bring it back until it is purged. policy says what happens to the
+// products of the supplier; by default the delete is refused while it has any.
+func (s *supplierService) DeleteSupplier(ctx context.Context, id string, version int, policy model.DeletePolicy) error {
+	if err := checkDeletePolicy(id, &policy, "supplier"); err != nil {
+		return err
+	}
+	return deleteError(s.repo.DeleteSupplier(ctx, id, version, auth.Actor(ctx), policy), "supplier")
+}
+
+func (s *supplierService) GetDeletedSuppliers(ctx context.Context) ([]model.Supplier, error) {
+	suppliers, err := s.repo.GetDeletedSuppliers(ctx)
+	return suppliers, dbError(err, "supplier")
+}
+
+// RestoreSupplier takes the supplier out of the trash. It fails with a
+// conflict when a live supplier took its place meanwhile.
+func (s *supplierService) RestoreSupplier(ctx context.Context, id string) (model.Supplier, error) {
+	if err := s.repo.RestoreSupplier(ctx, id); err != nil {
+		return model.Supplier{}, dbError(err, "supplier")
+	}
+	supplier, err := s.repo.GetSupplierById(ctx, id)
+	return supplier, dbError(err, "supplier")
 }
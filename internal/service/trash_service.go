@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"github.com/rs/zerolog"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"time"
+)
+
+type ITrashService interface {
+	Purge(ctx context.Context) (model.TrashPurgeResult, error)
+}
+
+type trashService struct {
+	products   repository.IProductRepo
+	categories repository.ICategoryRepo
+	suppliers  repository.ISupplierRepo
+	retention  time.Duration
+	now        func() time.Time
+}
+
+func NewTrashService(products repository.IProductRepo, categories repository.ICategoryRepo, suppliers repository.ISupplierRepo, retention time.Duration) *trashService {
+	return &trashService{products: products, categories: categories, suppliers: suppliers, retention: retention, now: time.Now}
+}
+
+// Purge permanently deletes the items that have been in the trash for longer
+// than the retention period. Products go first, so that the categories and
+// suppliers they referenced can be purged in the same run.
+func (s *trashService) Purge(ctx context.Context) (model.TrashPurgeResult, error) {
+	result := model.TrashPurgeResult{DeletedBefore: s.now().Add(-s.retention)}
+
+	var err error
+	if result.Products, err = s.products.PurgeProducts(ctx, result.DeletedBefore); err != nil {
+		return result, dbError(err, "product")
+	}
+	if result.Categories, err = s.categories.PurgeCategories(ctx, result.DeletedBefore); err != nil {
+		return result, dbError(err, "category")
+	}
+	if result.Suppliers, err = s.suppliers.PurgeSuppliers(ctx, result.DeletedBefore); err != nil {
+		return result, dbError(err, "supplier")
+	}
+
+	zerolog.Ctx(ctx).Info().
+		Time("deleted_before", result.DeletedBefore).
+		Int64("products", result.Products).
+		Int64("categories", result.Categories).
+		Int64("suppliers", result.Suppliers).
+		Msg("trash purged")
+	return result, nil
+}
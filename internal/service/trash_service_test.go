@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
+)
+
+func TestPurgeTrashUsesRetention(t *testing.T) {
+	products := new(mocks.MockProductRepo)
+	categories := new(mocks.MockCategoryRepo)
+	suppliers := new(mocks.MockSupplierRepo)
+	svc := NewTrashService(products, categories, suppliers, 30*24*time.Hour)
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	cutoff := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	products.On("PurgeProducts", context.Background(), cutoff).Return(int64(4), nil)
+	categories.On("PurgeCategories", context.Background(), cutoff).Return(int64(1), nil)
+	suppliers.On("PurgeSuppliers", context.Background(), cutoff).Return(int64(0), nil)
+
+	result, err := svc.Purge(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, cutoff, result.DeletedBefore)
+	assert.Equal(t, int64(4), result.Products)
+	assert.Equal(t, int64(1), result.Categories)
+	assert.Equal(t, int64(0), result.Suppliers)
+	products.AssertExpectations(t)
+}
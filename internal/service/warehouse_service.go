@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/validation"
+)
+
+type IWarehouseService interface {
+	GetWarehouses(ctx context.Context) ([]model.Warehouse, error)
+	GetWarehouseById(ctx context.Context, id string) (model.Warehouse, error)
+	AddWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error)
+	UpdateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error)
+	DeleteWarehouse(ctx context.Context, id string, version int) error
+}
+
+type warehouseService struct {
+	repo repository.IWarehouseRepo
+}
+
+func NewWarehouseService(repo repository.IWarehouseRepo) *warehouseService {
+	return &warehouseService{repo: repo}
+}
+
+func (s *warehouseService) GetWarehouses(ctx context.Context) ([]model.Warehouse, error) {
+	warehouses, err := s.repo.GetWarehouses(ctx)
+	return warehouses, dbError(err, "warehouse")
+}
+
+func (s *warehouseService) GetWarehouseById(ctx context.Context, id string) (model.Warehouse, error) {
+	warehouse, err := s.repo.GetWarehouseById(ctx, id)
+	return warehouse, dbError(err, "warehouse")
+}
+
+func (s *warehouseService) AddWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error) {
+	if err := checkWarehouse(warehouse); err != nil {
+		return model.Warehouse{}, err
+	}
+	created, err := s.repo.AddWarehouse(ctx, warehouse)
+	return created, dbError(err, "warehouse")
+}
+
+// UpdateWarehouse saves warehouse and returns it with its new version. A
+// positive warehouse.Version is the version the client read, as sent in
+// If-Match.
+func (s *warehouseService) UpdateWarehouse(ctx context.Context, warehouse model.Warehouse) (model.Warehouse, error) {
+	if err := checkWarehouse(warehouse); err != nil {
+		return model.Warehouse{}, err
+	}
+	updated, err := s.repo.UpdateWarehouse(ctx, warehouse)
+	return updated, dbError(err, "warehouse")
+}
+
+// DeleteWarehouse deletes the warehouse once its stock has been shipped or
+// transferred elsewhere.
+func (s *warehouseService) DeleteWarehouse(ctx context.Context, id string, version int) error {
+	err := s.repo.DeleteWarehouse(ctx, id, version)
+	if errors.Is(err, repository.ErrInUse) {
+		return ConflictError("warehouse_in_use", "warehouse still holds stock, ship or transfer it first").wrap(err)
+	}
+	return deleteError(err, "warehouse")
+}
+
+// checkWarehouse checks the field rules of warehouse, its coordinates being
+// given both or not at all.
+func checkWarehouse(warehouse model.Warehouse) error {
+	errs := validation.Struct(warehouse)
+	if warehouse.Latitude == nil && warehouse.Longitude != nil {
+		errs.Add("latitude", "is required with a longitude")
+	}
+	if warehouse.Longitude == nil && warehouse.Latitude != nil {
+		errs.Add("longitude", "is required with a latitude")
+	}
+	return invalidInput(errs)
+}
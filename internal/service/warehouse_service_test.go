@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/repository"
+	"github.com/thinhpq0112/soa-backend/internal/repository/mocks"
+)
+
+func TestAddWarehouseRequiresBothCoordinates(t *testing.T) {
+	repo := new(mocks.MockWarehouseRepo)
+	svc := NewWarehouseService(repo)
+
+	latitude := 45.764
+	_, err := svc.AddWarehouse(context.Background(), model.Warehouse{Name: "Lyon North", City: "Lyon", Latitude: &latitude})
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.Equal(t, []model.FieldError{{Field: "longitude", Message: "is required with a latitude"}}, domainErr.Details)
+	repo.AssertNotCalled(t, "AddWarehouse", mock.Anything, mock.Anything)
+}
+
+func TestDeleteWarehouseHoldingStock(t *testing.T) {
+	repo := new(mocks.MockWarehouseRepo)
+	svc := NewWarehouseService(repo)
+
+	repo.On("DeleteWarehouse", mock.Anything, "6f1c", 0).Return(repository.ErrInUse)
+
+	err := svc.DeleteWarehouse(context.Background(), "6f1c", 0)
+
+	var domainErr *Error
+	require.ErrorAs(t, err, &domainErr)
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, "warehouse_in_use", domainErr.Code)
+}
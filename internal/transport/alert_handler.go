@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+	"net/http"
+)
+
+type AlertHandler struct {
+	service service.IAlertService
+	authz   *middleware.Authorizer
+}
+
+func NewAlertHandler(service service.IAlertService, authz *middleware.Authorizer) *AlertHandler {
+	return &AlertHandler{service: service, authz: authz}
+}
+
+func (h *AlertHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	alert := rg.Group("/alerts")
+	alert.GET("/", h.authz.Require(auth.PermAlertRead), h.GetAlerts)
+	alert.GET("/:id", h.authz.Require(auth.PermAlertRead), h.GetAlertById)
+	alert.POST("/:id/acknowledge", h.authz.Require(auth.PermAlertWrite), h.AcknowledgeAlert)
+	alert.POST("/:id/resolve", h.authz.Require(auth.PermAlertWrite), h.ResolveAlert)
+}
+
+// @Summary Get the stock alerts
+// @Description List the alerts raised for products whose quantity dropped below their reorder point, newest first. A product has one alert per drop: it is resolved once the quantity is back at the reorder point, if it was not resolved before.
+// @Tags alerts
+// @Produce json
+// @Param status query []string false "Statuses of the alerts, comma separated" collectionFormat(csv) Enums(open, acknowledged, resolved)
+// @Param product_id query string false "ID of the product alerted"
+// @Param before_id query int false "next_before_id of the previous page"
+// @Param limit query int false "Alerts per page, at most 1000" default(100)
+// @Success 200 {object} model.AlertListResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/alerts [get]
+func (h *AlertHandler) GetAlerts(c *gin.Context) {
+	filter := model.AlertFilter{
+		Statuses:  parseMultiQuery(c, "status"),
+		ProductId: c.Query("product_id"),
+	}
+	var err error
+	if filter.BeforeId, filter.Limit, err = parsePageQuery(c); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	alerts, err := h.service.GetAlerts(c.Request.Context(), filter)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, alerts)
+}
+
+// @Summary Get a stock alert by ID
+// @Description Retrieve a stock alert by its ID
+// @Tags alerts
+// @Produce json
+// @Param id path int true "Alert ID"
+// @Success 200 {object} model.StockAlert
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/alerts/{id} [get]
+func (h *AlertHandler) GetAlertById(c *gin.Context) {
+	alert, err := h.service.GetAlertById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+// @Summary Acknowledge a stock alert
+// @Description Record that the caller is handling an open alert. Acknowledging an alert that is not open fails with 409.
+// @Tags alerts
+// @Produce json
+// @Param id path int true "Alert ID"
+// @Success 200 {object} model.StockAlert
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/alerts/{id}/acknowledge [post]
+func (h *AlertHandler) AcknowledgeAlert(c *gin.Context) {
+	alert, err := h.service.AcknowledgeAlert(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
+
+// @Summary Resolve a stock alert
+// @Description Close an open or acknowledged alert before the stock of its product recovers. The product is not alerted again until its quantity has been back at its reorder point. Resolving a resolved alert fails with 409.
+// @Tags alerts
+// @Produce json
+// @Param id path int true "Alert ID"
+// @Success 200 {object} model.StockAlert
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/alerts/{id}/resolve [post]
+func (h *AlertHandler) ResolveAlert(c *gin.Context) {
+	alert, err := h.service.ResolveAlert(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, alert)
+}
@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+	"net/http"
+)
+
+type APIKeyHandler struct {
+	service service.IAPIKeyService
+	authz   *middleware.Authorizer
+}
+
+func NewAPIKeyHandler(service service.IAPIKeyService, authz *middleware.Authorizer) *APIKeyHandler {
+	return &APIKeyHandler{service: service, authz: authz}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	keys := rg.Group("/admin/keys")
+	keys.Use(h.authz.Require(auth.PermAPIKeyManage))
+	keys.GET("/", h.GetAPIKeys)
+	keys.POST("/", h.CreateAPIKey)
+	keys.POST("/:id/rotate", h.RotateAPIKey)
+	keys.DELETE("/:id", h.RevokeAPIKey)
+}
+
+// @Summary List API keys
+// @Description List every API key with its scopes, expiry and usage. Secrets are never returned.
+// @Tags api-keys
+// @Produce json
+// @Success 200 {object} model.APIKeyListResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/admin/keys [get]
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	keys, err := h.service.GetAPIKeys(c.Request.Context())
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, model.APIKeyListResponse{Data: keys})
+}
+
+// @Summary Create an API key
+// @Description Create an API key for a service-to-service caller. The key is only shown in this response.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body model.CreateAPIKeyRequest true "API key data"
+// @Success 201 {object} model.APIKeySecretResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/admin/keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req model.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	created, err := h.service.CreateAPIKey(c.Request.Context(), req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// @Summary Rotate an API key
+// @Description Replace the secret of an active API key. The old secret stops working immediately.
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} model.APIKeySecretResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/admin/keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	rotated, err := h.service.RotateAPIKey(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rotated)
+}
+
+// @Summary Revoke an API key
+// @Description Revoke an API key so it can no longer authenticate
+// @Tags api-keys
+// @Param id path string true "API key ID"
+// @Success 200 {object} model.ActionResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/admin/keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	err := h.service.RevokeAPIKey(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked successfully"})
+}
@@ -0,0 +1,99 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type AuditHandler struct {
+	service service.IAuditService
+	authz   *middleware.Authorizer
+}
+
+func NewAuditHandler(service service.IAuditService, authz *middleware.Authorizer) *AuditHandler {
+	return &AuditHandler{service: service, authz: authz}
+}
+
+func (h *AuditHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/audit", h.authz.Require(auth.PermAuditRead), h.GetAuditEntries)
+}
+
+// @Summary Get the audit log
+// @Description List the changes made to products, categories, suppliers and warehouses, newest first. Each entry gives who made the change, when, in which request, and the fields it changed with their values before and after.
+// @Tags audit
+// @Produce json
+// @Param entity query string false "Kind of entity changed" Enums(product, category, supplier, warehouse)
+// @Param entity_id query string false "ID of the entity changed"
+// @Param actor query string false "Subject of the caller who made the change"
+// @Param from query string false "Earliest change, inclusive (RFC 3339, e.g., 2026-01-31T00:00:00Z)"
+// @Param to query string false "Latest change, exclusive (RFC 3339)"
+// @Param before_id query int false "next_before_id of the previous page"
+// @Param limit query int false "Entries per page, at most 500" default(50)
+// @Success 200 {object} model.AuditListResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/audit [get]
+func (h *AuditHandler) GetAuditEntries(c *gin.Context) {
+	filter := model.AuditFilter{
+		Entity:   c.Query("entity"),
+		EntityId: c.Query("entity_id"),
+		Actor:    c.Query("actor"),
+	}
+	var err error
+	if filter.From, err = parseTimestampQuery(c, "from"); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	if filter.To, err = parseTimestampQuery(c, "to"); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	if filter.BeforeId, filter.Limit, err = parsePageQuery(c); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	entries, err := h.service.GetAuditEntries(c.Request.Context(), filter)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// parsePageQuery reads the before_id cursor and the limit of a listing
+// paged newest first, zero when absent.
+func parsePageQuery(c *gin.Context) (beforeId int64, limit int, err error) {
+	if v := c.Query("before_id"); v != "" {
+		if beforeId, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return 0, 0, err
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil {
+			return 0, 0, err
+		}
+	}
+	return beforeId, limit, nil
+}
+
+func parseTimestampQuery(c *gin.Context, key string) (*time.Time, error) {
+	val := c.Query(key)
+	if val == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -3,6 +3,8 @@ package transport
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
 	"github.com/thinhpq0112/soa-backend/internal/model"
 	"github.com/thinhpq0112/soa-backend/internal/service"
 	"net/http"
@@ -10,35 +12,55 @@ import (
 
 type CategoryHandler struct {
 	service service.ICategoryService
+	authz   *middleware.Authorizer
 }
 
-func NewCategoryHandler(service service.ICategoryService) *CategoryHandler {
-	return &CategoryHandler{service: service}
+func NewCategoryHandler(service service.ICategoryService, authz *middleware.Authorizer) *CategoryHandler {
+	return &CategoryHandler{service: service, authz: authz}
 }
 
 func (h *CategoryHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	category := rg.Group("/categories")
-	category.GET("/", h.GetCategories)
-	category.GET("/:id", h.GetCategoryById)
-	category.POST("/", h.AddCategory)
-	category.PUT("/:id", h.UpdateCategory)
-	category.DELETE("/:id", h.DeleteCategory)
+	category.GET("/", h.authz.Require(auth.PermCategoryRead), h.GetCategories)
+	category.GET("/:id", h.authz.Require(auth.PermCategoryRead), h.GetCategoryById)
+	category.GET("/tree", h.authz.Require(auth.PermCategoryRead), h.GetCategoryTree)
+	category.GET("/:id/tree", h.authz.Require(auth.PermCategoryRead), h.GetCategorySubtree)
+	category.GET("/:id/path", h.authz.Require(auth.PermCategoryRead), h.GetCategoryPath)
+	category.POST("/", h.authz.Require(auth.PermCategoryWrite), h.AddCategory)
+	category.PUT("/:id", h.authz.Require(auth.PermCategoryWrite), h.UpdateCategory)
+	category.POST("/status", h.authz.Require(auth.PermCategoryWrite), h.SetCategoriesStatus)
+	category.DELETE("/:id", h.authz.Require(auth.PermCategoryDelete), h.DeleteCategory)
+	category.GET("/trash", h.authz.Require(auth.PermCategoryDelete), h.GetDeletedCategories)
+	category.POST("/:id/restore", h.authz.Require(auth.PermCategoryDelete), h.RestoreCategory)
 }
 
 // @Summary Get all categories
-// @Description Retrieve a list of all categories
+// @Description Retrieve a list of the categories, only the active ones unless status says otherwise. Listing inactive or archived categories requires category:write.
 // @Tags categories
 // @Produce json
+// @Param status query string false "Statuses to list (comma-separated, e.g., active,inactive)" default(active)
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {array} model.Category
+// @Success 304 "Not Modified"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/categories [get]
 func (h *CategoryHandler) GetCategories(c *gin.Context) {
-	categories, err := h.service.GetCategories(c.Request.Context())
+	statuses := parseMultiQuery(c, "status")
+	for _, status := range statuses {
+		if status != model.CategoryStatusActive && !h.authz.Allows(c, auth.PermCategoryWrite) {
+			middleware.AbortForbidden(c, auth.PermCategoryWrite)
+			return
+		}
+	}
+	categories, err := h.service.GetCategories(c.Request.Context(), statuses)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, categories)
+	respondListWithETag(c, categories)
 }
 
 // @Summary Get a category by ID
@@ -46,18 +68,88 @@ func (h *CategoryHandler) GetCategories(c *gin.Context) {
 // @Tags categories
 // @Produce json
 // @Param id path string true "Category ID"
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {object} model.Category
+// @Success 304 "Not Modified"
+// @Header 200 {string} ETag "Version of the category"
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/categories/{id} [get]
 func (h *CategoryHandler) GetCategoryById(c *gin.Context) {
 	id := c.Param("id")
 	category, err := h.service.GetCategoryById(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
+		return
+	}
+	respondWithETag(c, http.StatusOK, versionETag(category.Version), category)
+}
+
+// @Summary Get the category tree
+// @Description Retrieve the top-level categories with their subcategories, recursively
+// @Tags categories
+// @Produce json
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {array} model.CategoryNode
+// @Success 304 "Not Modified"
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/categories/tree [get]
+func (h *CategoryHandler) GetCategoryTree(c *gin.Context) {
+	tree, err := h.service.GetCategoryTree(c.Request.Context())
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	respondListWithETag(c, tree)
+}
+
+// @Summary Get a category subtree
+// @Description Retrieve a category with its subcategories, recursively
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} model.CategoryNode
+// @Success 304 "Not Modified"
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/categories/{id}/tree [get]
+func (h *CategoryHandler) GetCategorySubtree(c *gin.Context) {
+	subtree, err := h.service.GetCategorySubtree(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	respondListWithETag(c, subtree)
+}
+
+// @Summary Get the path to a category
+// @Description Retrieve the breadcrumb of a category: its ancestors from the top-level category down to the category itself
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {array} model.Category
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/categories/{id}/path [get]
+func (h *CategoryHandler) GetCategoryPath(c *gin.Context) {
+	path, err := h.service.GetCategoryPath(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, category)
+	c.JSON(http.StatusOK, path)
 }
 
 // @Summary Add a new category
@@ -66,18 +158,23 @@ func (h *CategoryHandler) GetCategoryById(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param category body model.Category true "Category data"
+// @Param Idempotency-Key header string false "Makes the request safe to retry: retries with the same key get the first response"
 // @Success 201 {object} model.Category
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/categories [post]
 func (h *CategoryHandler) AddCategory(c *gin.Context) {
 	var category model.Category
 	if err := c.ShouldBindJSON(&category); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handleBadRequest(c, err)
 		return
 	}
 	if err := h.service.AddCategory(c.Request.Context(), category); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, category)
@@ -89,39 +186,140 @@ func (h *CategoryHandler) AddCategory(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Category ID"
-// @Param category body model.Category true "Updated category data"
+// @Param category body model.Category true "Updated category data; version is ignored, send If-Match instead"
+// @Param If-Match header string false "ETag the client read; the update fails with 412 if the category changed since"
 // @Success 200 {object} model.Category
+// @Header 200 {string} ETag "New version of the category"
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 412 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/categories/{id} [put]
 func (h *CategoryHandler) UpdateCategory(c *gin.Context) {
 	id := c.Param("id")
 	var category model.Category
 	if err := c.ShouldBindJSON(&category); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handleBadRequest(c, err)
 		return
 	}
-	category.Id = uuid.MustParse(id)
-	if err := h.service.UpdateCategory(c.Request.Context(), category); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	categoryId, err := uuid.Parse(id)
+	if err != nil {
+		handleError(c, service.ValidationError("invalid_id", "id must be a UUID"))
+		return
+	}
+	category.Id = categoryId
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	category.Version = version
+	updated, err := h.service.UpdateCategory(c.Request.Context(), category)
+	if err != nil {
+		handleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, category)
+	respondWithETag(c, http.StatusOK, versionETag(updated.Version), updated)
+}
+
+// @Summary Change the status of categories
+// @Description Set the status of several categories at once. Either every category changes or, if one of them does not exist, none does. Inactive and archived categories are hidden from the public listings and cannot receive new products; their products are left alone.
+// @Tags categories
+// @Accept json
+// @Produce json
+// @Param request body model.CategoryStatusRequest true "Categories and their new status"
+// @Success 200 {array} model.Category
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/categories/status [post]
+func (h *CategoryHandler) SetCategoriesStatus(c *gin.Context) {
+	var req model.CategoryStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	categories, err := h.service.SetCategoriesStatus(c.Request.Context(), req.Ids, req.Status)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, categories)
 }
 
 // @Summary Delete a category
-// @Description Delete a category by ID
+// @Description Move a category to the trash, from where it can be restored until the trash is purged. A category with subcategories cannot be deleted (409). With the restrict policy the delete fails with 409 while products not in the trash belong to it; reassign moves them to the category given by reassign_to and archive moves them to the trash too. Everything happens in one transaction.
 // @Tags categories
 // @Param id path string true "Category ID"
+// @Param policy query string false "What happens to the products of the category" Enums(restrict, reassign, archive) default(restrict)
+// @Param reassign_to query string false "ID of the category receiving the products, with the reassign policy"
+// @Param If-Match header string false "ETag the client read; the delete fails with 412 if the category changed since"
 // @Success 204 "No Content"
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 412 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/categories/{id} [delete]
 func (h *CategoryHandler) DeleteCategory(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.service.DeleteCategory(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	policy := model.DeletePolicy{Mode: c.Query("policy"), ReassignTo: c.Query("reassign_to")}
+	if err := h.service.DeleteCategory(c.Request.Context(), id, version, policy); err != nil {
+		handleError(c, err)
 		return
 	}
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// @Summary List deleted categories
+// @Description List the categories in the trash, most recently deleted first
+// @Tags categories
+// @Produce json
+// @Success 200 {array} TrashedCategory
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/categories/trash [get]
+func (h *CategoryHandler) GetDeletedCategories(c *gin.Context) {
+	categories, err := h.service.GetDeletedCategories(c.Request.Context())
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newTrashedCategories(categories))
+}
+
+// @Summary Restore a category
+// @Description Take a category out of the trash
+// @Tags categories
+// @Produce json
+// @Param id path string true "Category ID"
+// @Success 200 {object} model.Category
+// @Header 200 {string} ETag "New version of the category"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/categories/{id}/restore [post]
+func (h *CategoryHandler) RestoreCategory(c *gin.Context) {
+	category, err := h.service.RestoreCategory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	respondWithETag(c, http.StatusOK, versionETag(category.Version), category)
+}
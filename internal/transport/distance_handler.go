@@ -1,7 +1,6 @@
 package transport
 
 import (
-	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/thinhpq0112/soa-backend/internal/service"
 	"net/http"
@@ -21,7 +20,10 @@ func NewDistanceHandler(distanceService *service.DistanceService) *DistanceHandl
 // @Param city query string true "City name"
 // @Success 200 {object} model.DistanceResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
+// @Failure 503 {object} model.ErrorResponse
 // @Router /api/distance [get]
 func (h *DistanceHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.GET("/distance", h.CalculateDistanceHandler)
@@ -35,13 +37,13 @@ func (h *DistanceHandler) CalculateDistanceHandler(c *gin.Context) {
 
 	city := c.Query("city")
 	if city == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cicty is required"})
+		respondError(c, http.StatusBadRequest, codeBadRequest, "city is required")
 		return
 	}
 
-	distance, err := h.distanceService.CalculateDistance(context.Background(), ip, city)
+	distance, err := h.distanceService.CalculateDistance(c.Request.Context(), ip, city)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
 
@@ -0,0 +1,69 @@
+package transport
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+	"net/http"
+)
+
+// Stable codes for errors that do not come from the service layer.
+const (
+	codeBadRequest           = "bad_request"
+	codeUnsupportedMediaType = "unsupported_media_type"
+	codeInternalError        = "internal_error"
+)
+
+var kindStatus = []struct {
+	kind   error
+	status int
+}{
+	{service.ErrNotFound, http.StatusNotFound},
+	{service.ErrConflict, http.StatusConflict},
+	{service.ErrValidation, http.StatusUnprocessableEntity},
+	{service.ErrForbidden, http.StatusForbidden},
+	{service.ErrUnavailable, http.StatusServiceUnavailable},
+	{service.ErrPreconditionFailed, http.StatusPreconditionFailed},
+}
+
+// respondError writes an ErrorResponse carrying the request ID, so that a
+// client reporting a problem gives support what it needs to find the logs.
+func respondError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, model.ErrorResponse{
+		Error:     message,
+		Code:      code,
+		RequestId: middleware.RequestID(c),
+	})
+}
+
+// handleError translates service errors to their HTTP status. Anything else
+// is an unexpected failure: it is logged and answered with a generic 500 so
+// that database or driver details never reach the client.
+func handleError(c *gin.Context, err error) {
+	c.Error(err)
+
+	var svcErr *service.Error
+	if errors.As(err, &svcErr) {
+		for _, ks := range kindStatus {
+			if errors.Is(svcErr, ks.kind) {
+				c.JSON(ks.status, model.ErrorResponse{
+					Error:            svcErr.Message,
+					Code:             svcErr.Code,
+					Details:          svcErr.Details,
+					BlockingProducts: svcErr.BlockingProducts,
+					RequestId:        middleware.RequestID(c),
+				})
+				return
+			}
+		}
+	}
+	respondError(c, http.StatusInternalServerError, codeInternalError, "internal server error")
+}
+
+// handleBadRequest reports requests that could not be parsed at all, such as
+// malformed JSON or query parameters.
+func handleBadRequest(c *gin.Context, err error) {
+	respondError(c, http.StatusBadRequest, codeBadRequest, err.Error())
+}
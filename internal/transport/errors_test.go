@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+)
+
+func TestHandleError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"not found", service.NotFoundError("product_not_found", "product not found"), http.StatusNotFound, "product_not_found"},
+		{"conflict", service.ConflictError("product_already_exists", "product already exists"), http.StatusConflict, "product_already_exists"},
+		{"validation", service.ValidationError("invalid_id", "id must be a UUID"), http.StatusUnprocessableEntity, "invalid_id"},
+		{"forbidden", service.ForbiddenError("tenant_mismatch", "not your tenant"), http.StatusForbidden, "tenant_mismatch"},
+		{"unavailable", service.UnavailableError("database_unavailable", "database is unavailable"), http.StatusServiceUnavailable, "database_unavailable"},
+		{"unexpected", errors.New(`pq: relation "products" does not exist`), http.StatusInternalServerError, "internal_error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			handleError(c, tt.err)
+
+			assert.Equal(t, tt.status, w.Code)
+			var resp model.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.code, resp.Code)
+			assert.NotContains(t, resp.Error, "pq:")
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const codePreconditionFailed = "version_mismatch"
+
+func versionETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// productETag also covers the embedded category and supplier, whose names
+// are part of the representation. If-Match only looks at the first number,
+// the version of the product itself.
+func productETag(p model.Product) string {
+	var categoryVersion, supplierVersion int
+	if p.Category != nil {
+		categoryVersion = p.Category.Version
+	}
+	if p.Supplier != nil {
+		supplierVersion = p.Supplier.Version
+	}
+	return fmt.Sprintf(`"%d.%d.%d"`, p.Version, categoryVersion, supplierVersion)
+}
+
+// ifMatchVersion returns the version a write is conditional on: 0 when
+// If-Match is absent or "*", since the write already fails on a missing
+// resource. ok is false, and a 412 has been written, when the header cannot
+// match any version.
+func ifMatchVersion(c *gin.Context) (version int, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+	// Only the first tag is considered: a client holds one copy of a
+	// resource. Weak tags never match, as If-Match uses strong comparison.
+	tag, _, _ := strings.Cut(header, ",")
+	tag = strings.TrimSpace(tag)
+	if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || len(tag) < 2 {
+		respondError(c, http.StatusPreconditionFailed, codePreconditionFailed, "If-Match must be an ETag returned by this API")
+		return 0, false
+	}
+	number, _, _ := strings.Cut(strings.Trim(tag, `"`), ".")
+	version, err := strconv.Atoi(number)
+	if err != nil || version <= 0 {
+		respondError(c, http.StatusPreconditionFailed, codePreconditionFailed, "If-Match must be an ETag returned by this API")
+		return 0, false
+	}
+	return version, true
+}
+
+// noneMatch reports whether If-None-Match lists etag, using the weak
+// comparison RFC 9110 prescribes for it.
+func noneMatch(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// respondWithETag writes body with its ETag, or 304 Not Modified when the
+// client already has it.
+func respondWithETag(c *gin.Context, status int, etag string, body interface{}) {
+	c.Header("ETag", etag)
+	if c.Request.Method == http.MethodGet && noneMatch(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(status, body)
+}
+
+// respondListWithETag is respondWithETag for collections and other
+// representations no version covers: their weak ETag is a hash of the
+// encoded body.
+func respondListWithETag(c *gin.Context, body interface{}) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	sum := sha256.Sum256(data)
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	if noneMatch(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
@@ -0,0 +1,78 @@
+package transport
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+func newETagContext(method string, header http.Header) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, "/", nil)
+	c.Request.Header = header
+	return c, w
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		header  string
+		version int
+		ok      bool
+	}{
+		{"", 0, true},
+		{"*", 0, true},
+		{`"7"`, 7, true},
+		{`"7.2.1"`, 7, true},
+		{`"7", "8"`, 7, true},
+		{`W/"7"`, 0, false},
+		{`7`, 0, false},
+		{`"abc"`, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			c, w := newETagContext(http.MethodPut, http.Header{"If-Match": {tt.header}})
+
+			version, ok := ifMatchVersion(c)
+
+			assert.Equal(t, tt.version, version)
+			assert.Equal(t, tt.ok, ok)
+			if !ok {
+				assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+			}
+		})
+	}
+}
+
+func TestRespondWithETagNotModified(t *testing.T) {
+	etag := productETag(model.Product{Version: 3, Category: &model.Category{Version: 2}})
+	assert.Equal(t, `"3.2.0"`, etag)
+
+	c, w := newETagContext(http.MethodGet, http.Header{"If-None-Match": {`"1.1.1", W/"3.2.0"`}})
+	respondWithETag(c, http.StatusOK, etag, gin.H{"data": "product"})
+	c.Writer.WriteHeaderNow()
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+	assert.Empty(t, w.Body.String())
+}
+
+func TestRespondListWithETag(t *testing.T) {
+	body := gin.H{"data": []string{"a", "b"}}
+
+	c, w := newETagContext(http.MethodGet, http.Header{})
+	respondListWithETag(c, body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+
+	c, w = newETagContext(http.MethodGet, http.Header{"If-None-Match": {etag}})
+	respondListWithETag(c, body)
+	c.Writer.WriteHeaderNow()
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
@@ -0,0 +1,47 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/health"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"net/http"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+func (h *HealthHandler) RegisterRoutes(r gin.IRoutes) {
+	r.GET("/healthz", h.Liveness)
+	r.GET("/readyz", h.Readiness)
+}
+
+// @Summary Liveness probe
+// @Description Report that the process is alive. Does not check dependencies.
+// @Tags health
+// @Produce json
+// @Success 200 {object} model.HealthResponse
+// @Router /healthz [get]
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, model.HealthResponse{Status: health.StatusUp})
+}
+
+// @Summary Readiness probe
+// @Description Ping the database, run the registered dependency checks and report connection pool statistics. Returns 503 while a critical check fails or the server is draining.
+// @Tags health
+// @Produce json
+// @Success 200 {object} model.ReadinessResponse
+// @Failure 503 {object} model.ReadinessResponse
+// @Router /readyz [get]
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	resp, ready := h.checker.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, resp)
+}
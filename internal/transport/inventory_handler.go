@@ -0,0 +1,128 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+	"net/http"
+)
+
+type InventoryHandler struct {
+	service service.IInventoryService
+	authz   *middleware.Authorizer
+}
+
+func NewInventoryHandler(service service.IInventoryService, authz *middleware.Authorizer) *InventoryHandler {
+	return &InventoryHandler{service: service, authz: authz}
+}
+
+func (h *InventoryHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/products/:id/movements", h.authz.Require(auth.PermProductWrite), h.AddMovement)
+	rg.GET("/products/:id/movements", h.authz.Require(auth.PermProductRead), h.GetMovements)
+	rg.POST("/products/:id/transfers", h.authz.Require(auth.PermProductWrite), h.TransferStock)
+}
+
+// @Summary Post an inventory movement
+// @Description Record a receipt, shipment, adjustment or return of a product in a warehouse and apply it to its stock there and to its total quantity, in one transaction. Receipts, returns and shipments take a positive quantity, shipments taking the items out; adjustments take the signed change. A movement that would take the stock of the warehouse below zero, or the total quantity below what active reservations hold, fails with 409. Transfers between warehouses go through POST /api/products/{id}/transfers.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param movement body model.MovementRequest true "Movement"
+// @Param Idempotency-Key header string false "Makes the request safe to retry: retries with the same key get the first response"
+// @Success 201 {object} model.InventoryMovement
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/products/{id}/movements [post]
+func (h *InventoryHandler) AddMovement(c *gin.Context) {
+	var req model.MovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	movement, err := h.service.AddMovement(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, movement)
+}
+
+// @Summary Transfer stock between warehouses
+// @Description Move a quantity of a product from one warehouse to another in one transaction, recording a transfer out of the source and a transfer into the destination. The total quantity of the product does not change. A transfer of more than the source holds fails with 409.
+// @Tags inventory
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param transfer body model.TransferRequest true "Transfer"
+// @Param Idempotency-Key header string false "Makes the request safe to retry: retries with the same key get the first response"
+// @Success 201 {object} model.TransferResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/products/{id}/transfers [post]
+func (h *InventoryHandler) TransferStock(c *gin.Context) {
+	var req model.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	transfer, err := h.service.TransferStock(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// @Summary Get the movement history of a product
+// @Description List the inventory movements of a product, newest first, optionally over a time range. Each movement gives its warehouse, the quantity it moved and the total quantity of the product after it.
+// @Tags inventory
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param from query string false "Earliest movement, inclusive (RFC 3339, e.g., 2026-01-31T00:00:00Z)"
+// @Param to query string false "Latest movement, exclusive (RFC 3339)"
+// @Param before_id query int false "next_before_id of the previous page"
+// @Param limit query int false "Movements per page, at most 1000" default(100)
+// @Success 200 {object} model.MovementListResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/products/{id}/movements [get]
+func (h *InventoryHandler) GetMovements(c *gin.Context) {
+	var filter model.MovementFilter
+	var err error
+	if filter.From, err = parseTimestampQuery(c, "from"); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	if filter.To, err = parseTimestampQuery(c, "to"); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	if filter.BeforeId, filter.Limit, err = parsePageQuery(c); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	movements, err := h.service.GetMovements(c.Request.Context(), c.Param("id"), filter)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, movements)
+}
@@ -0,0 +1,75 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+	"net/http"
+)
+
+type PriceHandler struct {
+	service service.IPriceService
+	authz   *middleware.Authorizer
+}
+
+func NewPriceHandler(service service.IPriceService, authz *middleware.Authorizer) *PriceHandler {
+	return &PriceHandler{service: service, authz: authz}
+}
+
+func (h *PriceHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/products/:id/prices", h.authz.Require(auth.PermProductRead), h.GetPrices)
+	rg.POST("/products/:id/prices", h.authz.Require(auth.PermProductWrite), h.SchedulePrice)
+}
+
+// @Summary Get the price history of a product
+// @Description List the prices of a product, oldest first, with the period each is effective for, prices scheduled for later included. The end of a period is exclusive; the last price has none.
+// @Tags prices
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} model.PriceListResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/products/{id}/prices [get]
+func (h *PriceHandler) GetPrices(c *gin.Context) {
+	prices, err := h.service.GetPrices(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, prices)
+}
+
+// @Summary Schedule a price
+// @Description Set the price of a product from a time to come, until effective_to or, without one, until the next price already scheduled. Scheduled prices the period overlaps are cut or replaced. The product switches to the price once it takes effect.
+// @Tags prices
+// @Accept json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param price body model.PriceRequest true "Price"
+// @Param Idempotency-Key header string false "Makes the request safe to retry: retries with the same key get the first response"
+// @Success 201 {object} model.ProductPrice
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/products/{id}/prices [post]
+func (h *PriceHandler) SchedulePrice(c *gin.Context) {
+	var req model.PriceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	price, err := h.service.SchedulePrice(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, price)
+}
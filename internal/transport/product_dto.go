@@ -0,0 +1,152 @@
+package transport
+
+import (
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"sort"
+	"time"
+)
+
+// CreateProductRequest is the body of POST /api/products. The category and
+// supplier are given either by id or by name; the id wins when both are sent.
+// The initial quantity is put in the warehouse given by WarehouseId.
+type CreateProductRequest struct {
+	Reference    string    `json:"reference" example:"REF-001"`
+	Name         string    `json:"name" example:"Desk lamp"`
+	AddedDate    time.Time `json:"added_date"`
+	Status       string    `json:"status" example:"Available" enums:"Available,OutOfStock,Discontinued"`
+	CategoryId   uuid.UUID `json:"category_id"`
+	CategoryName string    `json:"category_name" example:"Lighting"`
+	SupplierId   uuid.UUID `json:"supplier_id"`
+	SupplierName string    `json:"supplier_name" example:"Acme"`
+	Price        float64   `json:"price" example:"19.99"`
+	WarehouseId  uuid.UUID `json:"warehouse_id"`
+	Quantity     int       `json:"quantity" example:"12"`
+	// ReorderPoint and ReorderQuantity default to those of the category.
+	ReorderPoint    *int `json:"reorder_point" example:"5"`
+	ReorderQuantity *int `json:"reorder_quantity" example:"20"`
+}
+
+// UpdateProductRequest is the body of PUT /api/products. The stock of a
+// product is not updated this way: a warehouse_id is rejected with a 422,
+// like a quantity other than the current one.
+type UpdateProductRequest struct {
+	Id uuid.UUID `json:"id" binding:"required"`
+	CreateProductRequest
+}
+
+type ProductCategory struct {
+	Id   uuid.UUID `json:"id"`
+	Name string    `json:"category_name"`
+}
+
+type ProductSupplier struct {
+	Id   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// ProductStock is the stock of a product in one warehouse.
+type ProductStock struct {
+	WarehouseId   uuid.UUID `json:"warehouse_id"`
+	WarehouseName string    `json:"warehouse_name" example:"Lyon North"`
+	City          string    `json:"city" example:"Lyon"`
+	Quantity      int       `json:"quantity" example:"7"`
+}
+
+type ProductResponse struct {
+	Id        uuid.UUID `json:"id"`
+	Reference string    `json:"reference"`
+	Name      string    `json:"name"`
+	AddedDate time.Time `json:"added_date"`
+	Status    string    `json:"status"`
+	Price     float64   `json:"price"`
+	// Quantity is the total of Stock.
+	Quantity        int              `json:"quantity"`
+	Stock           []ProductStock   `json:"stock"`
+	ReorderPoint    *int             `json:"reorder_point"`
+	ReorderQuantity *int             `json:"reorder_quantity"`
+	Category        *ProductCategory `json:"category"`
+	Supplier        *ProductSupplier `json:"supplier"`
+}
+
+type ProductDataResponse struct {
+	Data ProductResponse `json:"data"`
+}
+
+type ProductListResponse struct {
+	Data []ProductResponse `json:"data"`
+}
+
+func (r CreateProductRequest) toModel() model.Product {
+	product := model.Product{
+		Reference:  r.Reference,
+		Name:       r.Name,
+		AddedDate:  r.AddedDate,
+		Status:     r.Status,
+		CategoryId: r.CategoryId,
+		SupplierId: r.SupplierId,
+		Price:      r.Price,
+		Quantity:   r.Quantity,
+
+		ReorderPoint:    r.ReorderPoint,
+		ReorderQuantity: r.ReorderQuantity,
+	}
+	if r.WarehouseId != uuid.Nil {
+		product.Stock = []model.WarehouseStock{{WarehouseId: r.WarehouseId, Quantity: r.Quantity}}
+	}
+	// Names are only looked up by the service when no id is given.
+	if r.CategoryName != "" {
+		product.Category = &model.Category{Name: r.CategoryName}
+	}
+	if r.SupplierName != "" {
+		product.Supplier = &model.Supplier{Name: r.SupplierName}
+	}
+	return product
+}
+
+func (r UpdateProductRequest) toModel() model.Product {
+	product := r.CreateProductRequest.toModel()
+	product.Id = r.Id
+	return product
+}
+
+func newProductResponse(p model.Product) ProductResponse {
+	resp := ProductResponse{
+		Id:        p.Id,
+		Reference: p.Reference,
+		Name:      p.Name,
+		AddedDate: p.AddedDate,
+		Status:    p.Status,
+		Price:     p.Price,
+		Quantity:  p.Quantity,
+		Stock:     make([]ProductStock, 0, len(p.Stock)),
+
+		ReorderPoint:    p.ReorderPoint,
+		ReorderQuantity: p.ReorderQuantity,
+	}
+	for _, s := range p.Stock {
+		stock := ProductStock{WarehouseId: s.WarehouseId, Quantity: s.Quantity}
+		if s.Warehouse != nil {
+			stock.WarehouseName, stock.City = s.Warehouse.Name, s.Warehouse.City
+		}
+		resp.Stock = append(resp.Stock, stock)
+	}
+	sort.Slice(resp.Stock, func(i, j int) bool {
+		return resp.Stock[i].WarehouseName < resp.Stock[j].WarehouseName
+	})
+	if p.Category != nil {
+		resp.Category = &ProductCategory{Id: p.Category.Id, Name: p.Category.Name}
+	}
+	if p.Supplier != nil {
+		resp.Supplier = &ProductSupplier{Id: p.Supplier.Id, Name: p.Supplier.Name}
+	}
+	return resp
+}
+
+func newProductListResponse(products []model.Product) ProductListResponse {
+	resp := ProductListResponse{Data: make([]ProductResponse, 0, len(products))}
+	for _, p := range products {
+		resp.Data = append(resp.Data, newProductResponse(p))
+	}
+	return resp
+}
@@ -0,0 +1,34 @@
+package transport
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+func TestNewProductResponseBreaksDownStock(t *testing.T) {
+	lyon := model.Warehouse{Id: uuid.New(), Name: "Lyon North", City: "Lyon"}
+	paris := model.Warehouse{Id: uuid.New(), Name: "Paris Bercy", City: "Paris"}
+	product := currentProduct()
+	product.Stock = []model.WarehouseStock{
+		{WarehouseId: paris.Id, Quantity: 5, Warehouse: &paris},
+		{WarehouseId: lyon.Id, Quantity: 7, Warehouse: &lyon},
+	}
+
+	resp := newProductResponse(product)
+
+	assert.Equal(t, 12, resp.Quantity)
+	assert.Equal(t, []ProductStock{
+		{WarehouseId: lyon.Id, WarehouseName: "Lyon North", City: "Lyon", Quantity: 7},
+		{WarehouseId: paris.Id, WarehouseName: "Paris Bercy", City: "Paris", Quantity: 5},
+	}, resp.Stock)
+}
+
+func TestCreateProductRequestPutsQuantityInWarehouse(t *testing.T) {
+	warehouseId := uuid.New()
+	product := CreateProductRequest{WarehouseId: warehouseId, Quantity: 12}.toModel()
+
+	assert.Equal(t, []model.WarehouseStock{{WarehouseId: warehouseId, Quantity: 12}}, product.Stock)
+}
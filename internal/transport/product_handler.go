@@ -2,6 +2,8 @@ package transport
 
 import (
 	"errors"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
 	"github.com/thinhpq0112/soa-backend/internal/model"
 	"github.com/thinhpq0112/soa-backend/internal/service"
 	"net/http"
@@ -12,28 +14,34 @@ import (
 import "github.com/gin-gonic/gin"
 
 type productHandler struct {
-	svc service.IProductService
+	svc   service.IProductService
+	authz *middleware.Authorizer
 }
 
-func NewProductHandler(svc service.IProductService) *productHandler {
+func NewProductHandler(svc service.IProductService, authz *middleware.Authorizer) *productHandler {
 	return &productHandler{
-		svc: svc,
+		svc:   svc,
+		authz: authz,
 	}
 }
 
 func (h *productHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	product := rg.Group("/products")
-	product.GET("/", h.GetProducts)
-	product.GET("/:id", h.GetProductById)
-	product.POST("/", h.AddProduct)
-	product.PUT("/", h.UpdateProduct)
-	product.DELETE("/:id", h.DeleteProduct)
+	product.GET("/", h.authz.Require(auth.PermProductRead), h.GetProducts)
+	product.GET("/:id", h.authz.Require(auth.PermProductRead), h.GetProductById)
+	product.POST("/", h.authz.Require(auth.PermProductWrite), h.AddProduct)
+	product.PUT("/", h.authz.Require(auth.PermProductWrite), h.UpdateProduct)
+	product.PATCH("/:id", h.authz.Require(auth.PermProductWrite), h.PatchProduct)
+	product.DELETE("/:id", h.authz.Require(auth.PermProductDelete), h.DeleteProduct)
+	product.GET("/trash", h.authz.Require(auth.PermProductDelete), h.GetDeletedProducts)
+	product.POST("/:id/restore", h.authz.Require(auth.PermProductDelete), h.RestoreProduct)
 
 	statistics := rg.Group("/statistics")
+	statistics.Use(h.authz.Require(auth.PermStatisticsRead))
 	statistics.GET("/products-per-category", h.GetProductsPerCategory)
 	statistics.GET("/products-per-supplier", h.GetProductsPerSupplier)
 
-	product.GET("/pdf", h.GeneratePDF)
+	product.GET("/pdf", h.authz.Require(auth.PermReportExport), h.GeneratePDF)
 }
 
 // @Summary Get all products
@@ -50,12 +58,18 @@ func (h *productHandler) RegisterRoutes(rg *gin.RouterGroup) {
 // @Param min_price query float64 false "Minimum price"
 // @Param max_price query float64 false "Maximum price"
 // @Param categories query string false "Categories (comma-separated, e.g., Books,Electronics)"
+// @Param include_descendants query bool false "Also match the subcategories of categories, at any depth"
 // @Param suppliers query string false "Suppliers (comma-separated, e.g., Supplier1,Supplier2)"
 // @Param stock_cities query string false "Stock cities (comma-separated, e.g., NY,LA,Chicago)"
 // @Param status query string false "Status (comma-separated, e.g., Available,OutOfStock)"
 // @Param search query string false "Search"
-// @Success 200 {object} model.ProductListResponse
+// @Param as_of query string false "Price the products as they were, or are scheduled to be, at this time (RFC 3339, e.g., 2026-11-27T00:00:00Z); price filters apply to those prices and products without a price then are left out"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} ProductListResponse
+// @Success 304 "Not Modified"
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/products [get]
 func (h *productHandler) GetProducts(c *gin.Context) {
@@ -75,30 +89,38 @@ func (h *productHandler) GetProducts(c *gin.Context) {
 		return
 	}
 
+	asOf, err := parseTimestampQuery(c, "as_of")
+	if err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
 	categories := parseMultiQuery(c, "categories")
 	suppliers := parseMultiQuery(c, "suppliers")
 	stockCities := parseMultiQuery(c, "stock_cities")
 	status := parseMultiQuery(c, "status")
 
 	options := &model.FilterOption{
-		Reference:  c.Query("reference"),
-		StartDate:  c.Query("start_date"),
-		EndDate:    c.Query("end_date"),
-		MinPrice:   minPrice,
-		MaxPrice:   maxPrice,
-		Categories: categories,
-		Suppliers:  suppliers,
-		StockCity:  stockCities,
-		Status:     status,
-		Search:     c.Query("search"),
+		Reference:          c.Query("reference"),
+		StartDate:          c.Query("start_date"),
+		EndDate:            c.Query("end_date"),
+		MinPrice:           minPrice,
+		MaxPrice:           maxPrice,
+		Categories:         categories,
+		Suppliers:          suppliers,
+		StockCity:          stockCities,
+		Status:             status,
+		Search:             c.Query("search"),
+		IncludeDescendants: c.Query("include_descendants") == "true",
+		AsOf:               asOf,
 	}
 
 	products, err := h.svc.GetProducts(c, pageNumber, limit, lastCreatedAt, options)
 	if err != nil {
-		handleErrorServer(c, err)
+		handleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, model.ProductListResponse{Data: products})
+	respondListWithETag(c, newProductListResponse(products))
 }
 
 // @Summary Get product by ID
@@ -107,59 +129,139 @@ func (h *productHandler) GetProducts(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
-// @Success 200 {object} model.Product
+// @Param as_of query string false "Price the product as it was, or is scheduled to be, at this time (RFC 3339); 404 if it had no price then"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} ProductDataResponse
+// @Success 304 "Not Modified"
+// @Header 200 {string} ETag "Version of the product, or a hash of the response with as_of"
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
 // @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/products/{id} [get]
 func (h *productHandler) GetProductById(c *gin.Context) {
+	asOf, err := parseTimestampQuery(c, "as_of")
+	if err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	if asOf != nil {
+		product, err := h.svc.GetProductAsOf(c, c.Param("id"), *asOf)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+		// The version does not cover scheduled prices, so the ETag is the
+		// hash of the body, as for a list.
+		respondListWithETag(c, ProductDataResponse{Data: newProductResponse(product)})
+		return
+	}
+
 	product, err := h.svc.GetProductById(c, c.Param("id"))
 	if err != nil {
-		handleErrorServer(c, err)
+		handleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"data": product})
+	respondWithETag(c, http.StatusOK, productETag(product), ProductDataResponse{Data: newProductResponse(product)})
 }
 
 // @Summary Delete product
-// @Description Delete a product by its ID
+// @Description Move a product to the trash, from where it can be restored until the trash is purged
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path string true "Product ID"
+// @Param If-Match header string false "ETag the client read; the delete fails with 412 if the product changed since"
 // @Success 200 {object} model.ActionResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 412 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/products/{id} [delete]
 func (h *productHandler) DeleteProduct(c *gin.Context) {
-	if err := h.svc.DeleteProduct(c, c.Param("id")); err != nil {
-		handleErrorServer(c, err)
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	if err := h.svc.DeleteProduct(c, c.Param("id"), version); err != nil {
+		handleError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Product deleted successfully"})
 }
 
+// @Summary List deleted products
+// @Description List the products in the trash, most recently deleted first
+// @Tags products
+// @Produce json
+// @Success 200 {object} TrashedProductListResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/products/trash [get]
+func (h *productHandler) GetDeletedProducts(c *gin.Context) {
+	products, err := h.svc.GetDeletedProducts(c)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newTrashedProductListResponse(products))
+}
+
+// @Summary Restore product
+// @Description Take a product out of the trash. Its category and supplier must not be in the trash.
+// @Tags products
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} ProductDataResponse
+// @Header 200 {string} ETag "New version of the product"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/products/{id}/restore [post]
+func (h *productHandler) RestoreProduct(c *gin.Context) {
+	product, err := h.svc.RestoreProduct(c, c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	respondWithETag(c, http.StatusOK, productETag(product), ProductDataResponse{Data: newProductResponse(product)})
+}
+
 // @Summary Create product
 // @Description Create a new product
 // @Tags products
 // @Accept json
 // @Produce json
-// @Param product body model.Product true "Product data"
+// @Param product body CreateProductRequest true "Product data"
+// @Param Idempotency-Key header string false "Makes the request safe to retry: retries with the same key get the first response"
 // @Success 200 {object} model.ActionResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/products [post]
 func (h *productHandler) AddProduct(c *gin.Context) {
-	var product model.Product
-	err := c.ShouldBindJSON(&product)
+	var req CreateProductRequest
+	err := c.ShouldBindJSON(&req)
 	if err != nil {
 		handleBadRequest(c, err)
 		return
 	}
 
-	err = h.svc.AddProduct(c, product)
+	err = h.svc.AddProduct(c, req.toModel())
 	if err != nil {
-		handleErrorServer(c, err)
+		handleError(c, err)
+		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Product added successfully"})
 }
@@ -169,38 +271,113 @@ func (h *productHandler) AddProduct(c *gin.Context) {
 // @Tags products
 // @Accept json
 // @Produce json
-// @Param product body model.Product true "Product data"
+// @Param product body UpdateProductRequest true "Product data"
+// @Param If-Match header string false "ETag the client read; the update fails with 412 if the product changed since"
 // @Success 200 {object} model.ActionResponse
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 412 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/products [put]
 func (h *productHandler) UpdateProduct(c *gin.Context) {
-	var product model.Product
-	err := c.ShouldBindJSON(&product)
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	var req UpdateProductRequest
+	err := c.ShouldBindJSON(&req)
 	if err != nil {
 		handleBadRequest(c, err)
 		return
 	}
 
+	product := req.toModel()
+	product.Version = version
 	err = h.svc.UpdateProduct(c, product)
 	if err != nil {
-		handleErrorServer(c, err)
+		handleError(c, err)
+		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Product updated successfully"})
 }
 
+// @Summary Patch product
+// @Description Partially update a product with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902). Only the changed columns are written, so fields can be set to zero or cleared.
+// @Tags products
+// @Accept application/merge-patch+json,application/json-patch+json,json
+// @Produce json
+// @Param id path string true "Product ID"
+// @Param patch body object true "Merge patch object or JSON Patch operations, applied to the fields of CreateProductRequest"
+// @Param If-Match header string false "ETag the client read; the patch fails with 412 if the product changed since"
+// @Success 200 {object} ProductDataResponse
+// @Header 200 {string} ETag "Version of the patched product"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 412 {object} model.ErrorResponse
+// @Failure 415 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/products/{id} [patch]
+func (h *productHandler) PatchProduct(c *gin.Context) {
+	mediaType := c.ContentType()
+	if mediaType != mediaTypeMergePatch && mediaType != mediaTypeJSONPatch && mediaType != mediaTypeJSON {
+		respondError(c, http.StatusUnsupportedMediaType, codeUnsupportedMediaType,
+			"Content-Type must be "+mediaTypeMergePatch+" or "+mediaTypeJSONPatch)
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	body, err := c.GetRawData()
+	if err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	patch, err := parseProductPatch(mediaType, body)
+	if err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+
+	product, err := h.svc.PatchProduct(c, c.Param("id"), version, patch)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	respondWithETag(c, http.StatusOK, productETag(product), ProductDataResponse{Data: newProductResponse(product)})
+}
+
 // @Summary Get products per category
-// @Description Get the number of products per category
+// @Description Get the number of products per category. With level, products of deeper categories are counted in their ancestor at that depth of the tree.
 // @Tags statistics
 // @Accept json
 // @Produce json
+// @Param level query int false "Tree depth to roll counts up to, 1 being the top-level categories; 0 counts every category on its own" default(0)
 // @Success 200 {object} model.StatPercentResponse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 422 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/statistics/products-per-category [get]
 func (h *productHandler) GetProductsPerCategory(c *gin.Context) {
-	stats, err := h.svc.GetProductsPerCategory(c)
+	level, err := strconv.Atoi(c.DefaultQuery("level", "0"))
+	if err != nil {
+		handleBadRequest(c, errors.New("invalid params: level must be an integer"))
+		return
+	}
+	stats, err := h.svc.GetProductsPerCategory(c, level)
 	if err != nil {
-		handleErrorServer(c, err)
+		handleError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": stats})
@@ -212,12 +389,14 @@ func (h *productHandler) GetProductsPerCategory(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Success 200 {object} model.StatPercentResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/statistics/products-per-supplier [get]
 func (h *productHandler) GetProductsPerSupplier(c *gin.Context) {
 	stats, err := h.svc.GetProductsPerSupplier(c)
 	if err != nil {
-		handleErrorServer(c, err)
+		handleError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"data": stats})
@@ -228,12 +407,14 @@ func (h *productHandler) GetProductsPerSupplier(c *gin.Context) {
 // @Tags products
 // @Produce application/pdf
 // @Success 200 {file} application/pdf "PDF file"
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/products/pdf [get]
 func (h *productHandler) GeneratePDF(c *gin.Context) {
 	filePath, err := h.svc.GenerateProductPDF(c)
 	if err != nil {
-		handleErrorServer(c, err)
+		handleError(c, err)
 		return
 	}
 	c.Header("Content-Type", "application/pdf")
@@ -304,11 +485,3 @@ func parseMultiQuery(c *gin.Context, key string) []string {
 	}
 	return vals
 }
-
-func handleBadRequest(c *gin.Context, err error) {
-	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-}
-
-func handleErrorServer(c *gin.Context, err error) {
-	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-}
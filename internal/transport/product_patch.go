@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+)
+
+const (
+	mediaTypeJSON       = "application/json"
+	mediaTypeMergePatch = "application/merge-patch+json"
+	mediaTypeJSONPatch  = "application/json-patch+json"
+)
+
+// newProductDocument is the JSON document patches apply to. It has the shape
+// of CreateProductRequest, with the references given by id and the name
+// fields empty so that a patch can set a reference by name.
+func newProductDocument(p model.Product) CreateProductRequest {
+	return CreateProductRequest{
+		Reference:  p.Reference,
+		Name:       p.Name,
+		AddedDate:  p.AddedDate,
+		Status:     p.Status,
+		CategoryId: p.CategoryId,
+		SupplierId: p.SupplierId,
+		Price:      p.Price,
+		Quantity:   p.Quantity,
+
+		ReorderPoint:    p.ReorderPoint,
+		ReorderQuantity: p.ReorderQuantity,
+	}
+}
+
+// parseProductPatch checks that body is a well-formed patch of the given
+// media type and returns the function applying it. A JSON Patch whose test
+// operation fails is a conflict; any other patch that does not apply to the
+// product is invalid input.
+func parseProductPatch(mediaType string, body []byte) (service.ProductPatch, error) {
+	var apply func(doc []byte) ([]byte, error)
+	switch mediaType {
+	case mediaTypeMergePatch, mediaTypeJSON:
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(body, &obj); err != nil {
+			return nil, fmt.Errorf("merge patch must be a JSON object: %w", err)
+		}
+		apply = func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}
+	case mediaTypeJSONPatch:
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JSON patch: %w", err)
+		}
+		apply = patch.Apply
+	default:
+		return nil, fmt.Errorf("unsupported media type %q", mediaType)
+	}
+
+	return func(current model.Product) (model.Product, error) {
+		doc, err := json.Marshal(newProductDocument(current))
+		if err != nil {
+			return model.Product{}, err
+		}
+		patched, err := apply(doc)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return model.Product{}, service.ConflictError("patch_test_failed", "%s", err.Error())
+		}
+		if err != nil {
+			return model.Product{}, service.ValidationError("invalid_patch", "%s", err.Error())
+		}
+
+		var req CreateProductRequest
+		dec := json.NewDecoder(bytes.NewReader(patched))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			return model.Product{}, service.ValidationError("invalid_patch", "patched product is invalid: %s", err.Error())
+		}
+
+		// A name only takes effect when the patch did not also change the id.
+		if req.CategoryName != "" && req.CategoryId == current.CategoryId {
+			req.CategoryId = uuid.Nil
+		}
+		if req.SupplierName != "" && req.SupplierId == current.SupplierId {
+			req.SupplierId = uuid.Nil
+		}
+		return req.toModel(), nil
+	}, nil
+}
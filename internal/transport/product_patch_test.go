@@ -0,0 +1,105 @@
+package transport
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+)
+
+func currentProduct() model.Product {
+	return model.Product{
+		Id:         uuid.New(),
+		Reference:  "REF-001",
+		Name:       "Desk lamp",
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		SupplierId: uuid.New(),
+		Price:      19.99,
+		Quantity:   12,
+	}
+}
+
+func TestMergePatchSetsZeroValues(t *testing.T) {
+	current := currentProduct()
+	patch, err := parseProductPatch(mediaTypeMergePatch, []byte(`{"quantity": 0, "price": 0}`))
+	require.NoError(t, err)
+
+	patched, err := patch(current)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, patched.Quantity)
+	assert.Equal(t, 0.0, patched.Price)
+	assert.Empty(t, patched.Stock)
+	assert.Equal(t, current.Name, patched.Name)
+	assert.Equal(t, current.CategoryId, patched.CategoryId)
+}
+
+func TestMergePatchClearsReorderPoint(t *testing.T) {
+	current := currentProduct()
+	point := 5
+	current.ReorderPoint = &point
+	patch, err := parseProductPatch(mediaTypeMergePatch, []byte(`{"reorder_point": null, "reorder_quantity": 20}`))
+	require.NoError(t, err)
+
+	patched, err := patch(current)
+	require.NoError(t, err)
+
+	assert.Nil(t, patched.ReorderPoint)
+	require.NotNil(t, patched.ReorderQuantity)
+	assert.Equal(t, 20, *patched.ReorderQuantity)
+}
+
+func TestMergePatchSetsReferenceByName(t *testing.T) {
+	current := currentProduct()
+	patch, err := parseProductPatch(mediaTypeMergePatch, []byte(`{"category_name": "Lighting"}`))
+	require.NoError(t, err)
+
+	patched, err := patch(current)
+	require.NoError(t, err)
+
+	assert.Equal(t, uuid.Nil, patched.CategoryId)
+	require.NotNil(t, patched.Category)
+	assert.Equal(t, "Lighting", patched.Category.Name)
+	assert.Equal(t, current.SupplierId, patched.SupplierId)
+}
+
+func TestJSONPatch(t *testing.T) {
+	current := currentProduct()
+	patch, err := parseProductPatch(mediaTypeJSONPatch, []byte(`[
+		{"op": "test", "path": "/quantity", "value": 12},
+		{"op": "replace", "path": "/quantity", "value": 0}
+	]`))
+	require.NoError(t, err)
+
+	patched, err := patch(current)
+	require.NoError(t, err)
+	assert.Equal(t, 0, patched.Quantity)
+}
+
+func TestJSONPatchFailedTestIsConflict(t *testing.T) {
+	patch, err := parseProductPatch(mediaTypeJSONPatch, []byte(`[{"op": "test", "path": "/quantity", "value": 3}]`))
+	require.NoError(t, err)
+
+	_, err = patch(currentProduct())
+	assert.ErrorIs(t, err, service.ErrConflict)
+}
+
+func TestPatchRejectsUnknownFields(t *testing.T) {
+	patch, err := parseProductPatch(mediaTypeMergePatch, []byte(`{"colour": "red"}`))
+	require.NoError(t, err)
+
+	_, err = patch(currentProduct())
+	assert.ErrorIs(t, err, service.ErrValidation)
+}
+
+func TestParseProductPatchRejectsMalformedDocuments(t *testing.T) {
+	_, err := parseProductPatch(mediaTypeMergePatch, []byte(`[1, 2]`))
+	assert.Error(t, err)
+
+	_, err = parseProductPatch(mediaTypeJSONPatch, []byte(`{"op": "replace"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,152 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+	"net/http"
+)
+
+type ReservationHandler struct {
+	service service.IReservationService
+	authz   *middleware.Authorizer
+}
+
+func NewReservationHandler(service service.IReservationService, authz *middleware.Authorizer) *ReservationHandler {
+	return &ReservationHandler{service: service, authz: authz}
+}
+
+func (h *ReservationHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	reservation := rg.Group("/reservations")
+	reservation.POST("/", h.authz.Require(auth.PermReservationWrite), h.Reserve)
+	reservation.GET("/:id", h.authz.Require(auth.PermReservationRead), h.GetReservationById)
+	reservation.POST("/:id/confirm", h.authz.Require(auth.PermReservationWrite), h.ConfirmReservation)
+	reservation.POST("/:id/release", h.authz.Require(auth.PermReservationWrite), h.ReleaseReservation)
+	rg.GET("/products/:id/availability", h.authz.Require(auth.PermProductRead), h.GetAvailability)
+}
+
+// @Summary Reserve stock
+// @Description Hold a quantity of a product for a pending order until the reservation is confirmed, released or expires. ttl_seconds defaults to RESERVATION_DEFAULT_TTL and may not exceed RESERVATION_MAX_TTL. A reservation of more than is available to promise fails with 409.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Param reservation body model.ReservationRequest true "Reservation"
+// @Param Idempotency-Key header string false "Makes the request safe to retry: retries with the same key get the first response"
+// @Success 201 {object} model.Reservation
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/reservations [post]
+func (h *ReservationHandler) Reserve(c *gin.Context) {
+	var req model.ReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	reservation, err := h.service.Reserve(c.Request.Context(), req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// @Summary Get a reservation by ID
+// @Description Retrieve a reservation by its unique ID
+// @Tags reservations
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Success 200 {object} model.Reservation
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/reservations/{id} [get]
+func (h *ReservationHandler) GetReservationById(c *gin.Context) {
+	reservation, err := h.service.GetReservationById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+// @Summary Confirm a reservation
+// @Description Ship the reserved stock from a warehouse, recording a shipment in the stock ledger, and close the reservation, in one transaction. Confirming a reservation that is no longer active, or whose warehouse does not hold enough stock, fails with 409.
+// @Tags reservations
+// @Accept json
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Param confirmation body model.ConfirmReservationRequest true "Warehouse to ship from"
+// @Success 200 {object} model.Reservation
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/reservations/{id}/confirm [post]
+func (h *ReservationHandler) ConfirmReservation(c *gin.Context) {
+	var req model.ConfirmReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	reservation, err := h.service.ConfirmReservation(c.Request.Context(), c.Param("id"), req)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+// @Summary Release a reservation
+// @Description Give the reserved stock back without shipping it. Releasing a reservation that is no longer active fails with 409.
+// @Tags reservations
+// @Produce json
+// @Param id path string true "Reservation ID"
+// @Success 200 {object} model.Reservation
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/reservations/{id}/release [post]
+func (h *ReservationHandler) ReleaseReservation(c *gin.Context) {
+	reservation, err := h.service.ReleaseReservation(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+// @Summary Get the stock available to promise
+// @Description Get the stock of a product on hand, held by active reservations, and available to promise to new orders.
+// @Tags reservations
+// @Produce json
+// @Param id path string true "Product ID"
+// @Success 200 {object} model.StockAvailability
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/products/{id}/availability [get]
+func (h *ReservationHandler) GetAvailability(c *gin.Context) {
+	availability, err := h.service.GetAvailability(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, availability)
+}
@@ -3,6 +3,8 @@ package transport
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
 	"github.com/thinhpq0112/soa-backend/internal/model"
 	"github.com/thinhpq0112/soa-backend/internal/service"
 	"net/http"
@@ -10,35 +12,42 @@ import (
 
 type SupplierHandler struct {
 	service service.ISupplierService
+	authz   *middleware.Authorizer
 }
 
-func NewSupplierHandler(service service.ISupplierService) *SupplierHandler {
-	return &SupplierHandler{service: service}
+func NewSupplierHandler(service service.ISupplierService, authz *middleware.Authorizer) *SupplierHandler {
+	return &SupplierHandler{service: service, authz: authz}
 }
 
 func (h *SupplierHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	supplier := rg.Group("/suppliers")
-	supplier.GET("/", h.GetSuppliers)
-	supplier.GET("/:id", h.GetSupplierById)
-	supplier.POST("/", h.AddSupplier)
-	supplier.PUT("/:id", h.UpdateSupplier)
-	supplier.DELETE("/:id", h.DeleteSupplier)
+	supplier.GET("/", h.authz.Require(auth.PermSupplierRead), h.GetSuppliers)
+	supplier.GET("/:id", h.authz.Require(auth.PermSupplierRead), h.GetSupplierById)
+	supplier.POST("/", h.authz.Require(auth.PermSupplierWrite), h.AddSupplier)
+	supplier.PUT("/:id", h.authz.Require(auth.PermSupplierWrite), h.UpdateSupplier)
+	supplier.DELETE("/:id", h.authz.Require(auth.PermSupplierDelete), h.DeleteSupplier)
+	supplier.GET("/trash", h.authz.Require(auth.PermSupplierDelete), h.GetDeletedSuppliers)
+	supplier.POST("/:id/restore", h.authz.Require(auth.PermSupplierDelete), h.RestoreSupplier)
 }
 
 // @Summary Get all suppliers
 // @Description Retrieve a list of all suppliers
 // @Tags suppliers
 // @Produce json
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {array} model.Supplier
+// @Success 304 "Not Modified"
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/suppliers [get]
 func (h *SupplierHandler) GetSuppliers(c *gin.Context) {
 	suppliers, err := h.service.GetSuppliers(c.Request.Context())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, suppliers)
+	respondListWithETag(c, suppliers)
 }
 
 // @Summary Get a supplier by ID
@@ -46,18 +55,24 @@ func (h *SupplierHandler) GetSuppliers(c *gin.Context) {
 // @Tags suppliers
 // @Produce json
 // @Param id path string true "Supplier ID"
+// @Param If-None-Match header string false "ETag of a previous response"
 // @Success 200 {object} model.Supplier
+// @Success 304 "Not Modified"
+// @Header 200 {string} ETag "Version of the supplier"
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/suppliers/{id} [get]
 func (h *SupplierHandler) GetSupplierById(c *gin.Context) {
 	id := c.Param("id")
 	supplier, err := h.service.GetSupplierById(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, supplier)
+	respondWithETag(c, http.StatusOK, versionETag(supplier.Version), supplier)
 }
 
 // @Summary Add a new supplier
@@ -66,18 +81,23 @@ func (h *SupplierHandler) GetSupplierById(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param supplier body model.Supplier true "Supplier data"
+// @Param Idempotency-Key header string false "Makes the request safe to retry: retries with the same key get the first response"
 // @Success 201 {object} model.Supplier
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/suppliers [post]
 func (h *SupplierHandler) AddSupplier(c *gin.Context) {
 	var supplier model.Supplier
 	if err := c.ShouldBindJSON(&supplier); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handleBadRequest(c, err)
 		return
 	}
 	if err := h.service.AddSupplier(c.Request.Context(), supplier); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		handleError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, supplier)
@@ -89,39 +109,113 @@ func (h *SupplierHandler) AddSupplier(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param id path string true "Supplier ID"
-// @Param supplier body model.Supplier true "Updated supplier data"
+// @Param supplier body model.Supplier true "Updated supplier data; version is ignored, send If-Match instead"
+// @Param If-Match header string false "ETag the client read; the update fails with 412 if the supplier changed since"
 // @Success 200 {object} model.Supplier
+// @Header 200 {string} ETag "New version of the supplier"
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 412 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/suppliers/{id} [put]
 func (h *SupplierHandler) UpdateSupplier(c *gin.Context) {
 	id := c.Param("id")
 	var supplier model.Supplier
 	if err := c.ShouldBindJSON(&supplier); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		handleBadRequest(c, err)
 		return
 	}
-	supplier.Id = uuid.MustParse(id)
-	if err := h.service.UpdateSupplier(c.Request.Context(), supplier); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	supplierId, err := uuid.Parse(id)
+	if err != nil {
+		handleError(c, service.ValidationError("invalid_id", "id must be a UUID"))
+		return
+	}
+	supplier.Id = supplierId
+	version, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, supplier)
+	supplier.Version = version
+	updated, err := h.service.UpdateSupplier(c.Request.Context(), supplier)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	respondWithETag(c, http.StatusOK, versionETag(updated.Version), updated)
 }
 
 // @Summary Delete a supplier
-// @Description Delete a supplier by ID
+// @Description Move a supplier to the trash, from where it can be restored until the trash is purged. With the restrict policy the delete fails with 409 while products not in the trash belong to it; reassign moves them to the supplier given by reassign_to and archive moves them to the trash too. Everything happens in one transaction.
 // @Tags suppliers
 // @Param id path string true "Supplier ID"
+// @Param policy query string false "What happens to the products of the supplier" Enums(restrict, reassign, archive) default(restrict)
+// @Param reassign_to query string false "ID of the supplier receiving the products, with the reassign policy"
+// @Param If-Match header string false "ETag the client read; the delete fails with 412 if the supplier changed since"
 // @Success 204 "No Content"
 // @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 412 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
 // @Failure 500 {object} model.ErrorResponse
 // @Router /api/suppliers/{id} [delete]
 func (h *SupplierHandler) DeleteSupplier(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.service.DeleteSupplier(c.Request.Context(), id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	policy := model.DeletePolicy{Mode: c.Query("policy"), ReassignTo: c.Query("reassign_to")}
+	if err := h.service.DeleteSupplier(c.Request.Context(), id, version, policy); err != nil {
+		handleError(c, err)
 		return
 	}
 	c.JSON(http.StatusNoContent, nil)
 }
+
+// @Summary List deleted suppliers
+// @Description List the suppliers in the trash, most recently deleted first
+// @Tags suppliers
+// @Produce json
+// @Success 200 {array} TrashedSupplier
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/suppliers/trash [get]
+func (h *SupplierHandler) GetDeletedSuppliers(c *gin.Context) {
+	suppliers, err := h.service.GetDeletedSuppliers(c.Request.Context())
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, newTrashedSuppliers(suppliers))
+}
+
+// @Summary Restore a supplier
+// @Description Take a supplier out of the trash
+// @Tags suppliers
+// @Produce json
+// @Param id path string true "Supplier ID"
+// @Success 200 {object} model.Supplier
+// @Header 200 {string} ETag "New version of the supplier"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/suppliers/{id}/restore [post]
+func (h *SupplierHandler) RestoreSupplier(c *gin.Context) {
+	supplier, err := h.service.RestoreSupplier(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	respondWithETag(c, http.StatusOK, versionETag(supplier.Version), supplier)
+}
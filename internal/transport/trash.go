@@ -0,0 +1,92 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+	"net/http"
+	"time"
+)
+
+type TrashedProduct struct {
+	ProductResponse
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy string    `json:"deleted_by"`
+}
+
+type TrashedProductListResponse struct {
+	Data []TrashedProduct `json:"data"`
+}
+
+type TrashedCategory struct {
+	model.Category
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy string    `json:"deleted_by"`
+}
+
+type TrashedSupplier struct {
+	model.Supplier
+	DeletedAt time.Time `json:"deleted_at"`
+	DeletedBy string    `json:"deleted_by"`
+}
+
+func newTrashedProductListResponse(products []model.Product) TrashedProductListResponse {
+	resp := TrashedProductListResponse{Data: make([]TrashedProduct, 0, len(products))}
+	for _, p := range products {
+		resp.Data = append(resp.Data, TrashedProduct{
+			ProductResponse: newProductResponse(p),
+			DeletedAt:       p.DeletedAt.Time,
+			DeletedBy:       p.DeletedBy,
+		})
+	}
+	return resp
+}
+
+func newTrashedCategories(categories []model.Category) []TrashedCategory {
+	trashed := make([]TrashedCategory, 0, len(categories))
+	for _, c := range categories {
+		trashed = append(trashed, TrashedCategory{Category: c, DeletedAt: c.DeletedAt.Time, DeletedBy: c.DeletedBy})
+	}
+	return trashed
+}
+
+func newTrashedSuppliers(suppliers []model.Supplier) []TrashedSupplier {
+	trashed := make([]TrashedSupplier, 0, len(suppliers))
+	for _, s := range suppliers {
+		trashed = append(trashed, TrashedSupplier{Supplier: s, DeletedAt: s.DeletedAt.Time, DeletedBy: s.DeletedBy})
+	}
+	return trashed
+}
+
+type TrashHandler struct {
+	service service.ITrashService
+	authz   *middleware.Authorizer
+}
+
+func NewTrashHandler(service service.ITrashService, authz *middleware.Authorizer) *TrashHandler {
+	return &TrashHandler{service: service, authz: authz}
+}
+
+func (h *TrashHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.DELETE("/admin/trash", h.authz.Require(auth.PermTrashPurge), h.PurgeTrash)
+}
+
+// @Summary Purge the trash
+// @Description Permanently delete the products, categories and suppliers that have been in the trash for longer than the retention period. Categories and suppliers still referenced by a product in the trash are kept.
+// @Tags trash
+// @Produce json
+// @Success 200 {object} model.TrashPurgeResult
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/admin/trash [delete]
+func (h *TrashHandler) PurgeTrash(c *gin.Context) {
+	result, err := h.service.Purge(c.Request.Context())
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
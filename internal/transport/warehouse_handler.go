@@ -0,0 +1,172 @@
+package transport
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/thinhpq0112/soa-backend/internal/auth"
+	"github.com/thinhpq0112/soa-backend/internal/middleware"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"github.com/thinhpq0112/soa-backend/internal/service"
+	"net/http"
+)
+
+type WarehouseHandler struct {
+	service service.IWarehouseService
+	authz   *middleware.Authorizer
+}
+
+func NewWarehouseHandler(service service.IWarehouseService, authz *middleware.Authorizer) *WarehouseHandler {
+	return &WarehouseHandler{service: service, authz: authz}
+}
+
+func (h *WarehouseHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	warehouse := rg.Group("/warehouses")
+	warehouse.GET("/", h.authz.Require(auth.PermWarehouseRead), h.GetWarehouses)
+	warehouse.GET("/:id", h.authz.Require(auth.PermWarehouseRead), h.GetWarehouseById)
+	warehouse.POST("/", h.authz.Require(auth.PermWarehouseWrite), h.AddWarehouse)
+	warehouse.PUT("/:id", h.authz.Require(auth.PermWarehouseWrite), h.UpdateWarehouse)
+	warehouse.DELETE("/:id", h.authz.Require(auth.PermWarehouseDelete), h.DeleteWarehouse)
+}
+
+// @Summary Get all warehouses
+// @Description Retrieve the warehouses, by name
+// @Tags warehouses
+// @Produce json
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {array} model.Warehouse
+// @Success 304 "Not Modified"
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/warehouses [get]
+func (h *WarehouseHandler) GetWarehouses(c *gin.Context) {
+	warehouses, err := h.service.GetWarehouses(c.Request.Context())
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	respondListWithETag(c, warehouses)
+}
+
+// @Summary Get a warehouse by ID
+// @Description Retrieve a warehouse by its unique ID
+// @Tags warehouses
+// @Produce json
+// @Param id path string true "Warehouse ID"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Success 200 {object} model.Warehouse
+// @Success 304 "Not Modified"
+// @Header 200 {string} ETag "Version of the warehouse"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/warehouses/{id} [get]
+func (h *WarehouseHandler) GetWarehouseById(c *gin.Context) {
+	warehouse, err := h.service.GetWarehouseById(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	respondWithETag(c, http.StatusOK, versionETag(warehouse.Version), warehouse)
+}
+
+// @Summary Add a new warehouse
+// @Description Create a warehouse. Its latitude and longitude are optional but go together.
+// @Tags warehouses
+// @Accept json
+// @Produce json
+// @Param warehouse body model.Warehouse true "Warehouse data"
+// @Param Idempotency-Key header string false "Makes the request safe to retry: retries with the same key get the first response"
+// @Success 201 {object} model.Warehouse
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/warehouses [post]
+func (h *WarehouseHandler) AddWarehouse(c *gin.Context) {
+	var warehouse model.Warehouse
+	if err := c.ShouldBindJSON(&warehouse); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	created, err := h.service.AddWarehouse(c.Request.Context(), warehouse)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// @Summary Update a warehouse
+// @Description Update an existing warehouse by ID
+// @Tags warehouses
+// @Accept json
+// @Produce json
+// @Param id path string true "Warehouse ID"
+// @Param warehouse body model.Warehouse true "Updated warehouse data; version is ignored, send If-Match instead"
+// @Param If-Match header string false "ETag the client read; the update fails with 412 if the warehouse changed since"
+// @Success 200 {object} model.Warehouse
+// @Header 200 {string} ETag "New version of the warehouse"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 412 {object} model.ErrorResponse
+// @Failure 422 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/warehouses/{id} [put]
+func (h *WarehouseHandler) UpdateWarehouse(c *gin.Context) {
+	var warehouse model.Warehouse
+	if err := c.ShouldBindJSON(&warehouse); err != nil {
+		handleBadRequest(c, err)
+		return
+	}
+	warehouseId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		handleError(c, service.ValidationError("invalid_id", "id must be a UUID"))
+		return
+	}
+	warehouse.Id = warehouseId
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	warehouse.Version = version
+	updated, err := h.service.UpdateWarehouse(c.Request.Context(), warehouse)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+	respondWithETag(c, http.StatusOK, versionETag(updated.Version), updated)
+}
+
+// @Summary Delete a warehouse
+// @Description Delete a warehouse that holds no stock; ship or transfer its stock first. Its movements stay in the ledger without a warehouse.
+// @Tags warehouses
+// @Param id path string true "Warehouse ID"
+// @Param If-Match header string false "ETag the client read; the delete fails with 412 if the warehouse changed since"
+// @Success 204 "No Content"
+// @Failure 400 {object} model.ErrorResponse
+// @Failure 401 {object} model.ErrorResponse
+// @Failure 403 {object} model.ForbiddenResponse
+// @Failure 404 {object} model.ErrorResponse
+// @Failure 409 {object} model.ErrorResponse
+// @Failure 412 {object} model.ErrorResponse
+// @Failure 500 {object} model.ErrorResponse
+// @Router /api/warehouses/{id} [delete]
+func (h *WarehouseHandler) DeleteWarehouse(c *gin.Context) {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteWarehouse(c.Request.Context(), c.Param("id"), version); err != nil {
+		handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusNoContent, nil)
+}
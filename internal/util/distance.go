@@ -1,13 +1,20 @@
 package util
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/jftuga/geodist"
+	"github.com/thinhpq0112/soa-backend/config"
+	"github.com/thinhpq0112/soa-backend/internal/metrics"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func CalculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
@@ -18,9 +25,16 @@ func CalculateDistance(lat1, lon1, lat2, lon2 float64) float64 {
 	return kiloM
 }
 
+var (
+	ErrCityNotFound   = errors.New("city not found")
+	ErrIPNotLocatable = errors.New("ip address cannot be located")
+)
+
 type IPGeoResponse struct {
-	Lat float64 `json:"lat"`
-	Lon float64 `json:"lon"`
+	Status  string  `json:"status"`
+	Message string  `json:"message"`
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
 }
 
 type GeoResponse struct {
@@ -28,37 +42,68 @@ type GeoResponse struct {
 	Lon string `json:"lon"`
 }
 
-func GetLatLonFromIP(ip string) (float64, float64, error) {
-	url := fmt.Sprintf("http://ip-api.com/json/%s", ip)
-	resp, err := http.Get(url)
-	if err != nil {
-		return 0, 0, err
+// Geocoder resolves IP addresses and city names to coordinates using the
+// ip-api.com and Nominatim compatible endpoints from config.GeoConfig.
+type Geocoder struct {
+	client    *http.Client
+	ipURL     string
+	cityURL   string
+	userAgent string
+}
+
+func NewGeocoder(cfg config.GeoConfig) *Geocoder {
+	return &Geocoder{
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// Outbound lookups are traced as children of the request span;
+			// calls without one, such as readiness checks, are not traced.
+			Transport: otelhttp.NewTransport(http.DefaultTransport, otelhttp.WithFilter(func(r *http.Request) bool {
+				return trace.SpanFromContext(r.Context()).SpanContext().IsValid()
+			})),
+		},
+		ipURL:     strings.TrimSuffix(cfg.IPLookupURL, "/") + "/",
+		cityURL:   cfg.CityLookupURL,
+		userAgent: cfg.UserAgent,
 	}
-	defer resp.Body.Close()
+}
+
+var defaultGeocoder = NewGeocoder(config.GeoConfig{
+	IPLookupURL:   "http://ip-api.com/json/",
+	CityLookupURL: "https://nominatim.openstreetmap.org/search",
+	UserAgent:     "soa-backend",
+	Timeout:       5 * time.Second,
+})
 
+func GetLatLonFromIP(ip string) (float64, float64, error) {
+	return defaultGeocoder.LatLonFromIP(context.Background(), ip)
+}
+
+func GetLatLonFromCity(city string) (float64, float64, error) {
+	return defaultGeocoder.LatLonFromCity(context.Background(), city)
+}
+
+func (g *Geocoder) LatLonFromIP(ctx context.Context, ip string) (float64, float64, error) {
 	var result IPGeoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+	if err := g.getJSON(ctx, "ip", g.ipURL+url.PathEscape(ip), &result); err != nil {
 		return 0, 0, err
 	}
+	if result.Status == "fail" {
+		return 0, 0, fmt.Errorf("%w: %s", ErrIPNotLocatable, result.Message)
+	}
 
 	return result.Lat, result.Lon, nil
 }
 
-func GetLatLonFromCity(city string) (float64, float64, error) {
-	url := fmt.Sprintf("https://nominatim.openstreetmap.org/search?q=%s&format=json", strings.ReplaceAll(city, " ", "+"))
-	resp, err := http.Get(url)
-	if err != nil {
-		return 0, 0, err
-	}
-	defer resp.Body.Close()
+func (g *Geocoder) LatLonFromCity(ctx context.Context, city string) (float64, float64, error) {
+	query := url.Values{"q": {city}, "format": {"json"}}
 
 	var results []GeoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+	if err := g.getJSON(ctx, "city", g.cityURL+"?"+query.Encode(), &results); err != nil {
 		return 0, 0, err
 	}
 
 	if len(results) == 0 {
-		return 0, 0, errors.New("city not found")
+		return 0, 0, ErrCityNotFound
 	}
 
 	lat, lon := results[0].Lat, results[0].Lon
@@ -66,3 +111,40 @@ func GetLatLonFromCity(city string) (float64, float64, error) {
 	lonF, _ := strconv.ParseFloat(lon, 64)
 	return latF, lonF, nil
 }
+
+// getJSON fetches rawURL into out and counts the outcome under lookup.
+func (g *Geocoder) getJSON(ctx context.Context, lookup, rawURL string, out interface{}) (err error) {
+	defer func() {
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+		}
+		metrics.GeocodingRequests.WithLabelValues(lookup, outcome).Inc()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	if g.userAgent != "" {
+		req.Header.Set("User-Agent", g.userAgent)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("geocoding request failed: %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Check reports whether the IP lookup endpoint is reachable. Without an IP
+// it resolves the caller's own address.
+func (g *Geocoder) Check(ctx context.Context) error {
+	var result IPGeoResponse
+	return g.getJSON(ctx, "check", g.ipURL, &result)
+}
@@ -0,0 +1,131 @@
+package validation
+
+import (
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var referencePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// earliestDate bounds dates such as a product's added_date; anything older is
+// almost certainly a typo or a zero value from a client.
+var earliestDate = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+
+	// Report JSON field names rather than Go field names.
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	mustRegister(v, "notblank", validators.NotBlank)
+	mustRegister(v, "reference", func(fl validator.FieldLevel) bool {
+		return referencePattern.MatchString(fl.Field().String())
+	})
+	mustRegister(v, "sane_date", func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		if !ok || t.IsZero() {
+			return true
+		}
+		return !t.Before(earliestDate) && !t.After(time.Now().Add(24*time.Hour))
+	})
+	return v
+}
+
+func mustRegister(v *validator.Validate, tag string, fn validator.Func) {
+	if err := v.RegisterValidation(tag, fn); err != nil {
+		panic(err)
+	}
+}
+
+// Errors collects every problem found in a payload.
+type Errors []model.FieldError
+
+// Add records a problem with field, unless field already has one: the first
+// message is the most useful and later checks often depend on it.
+func (e *Errors) Add(field, format string, args ...interface{}) {
+	if e.Has(field) {
+		return
+	}
+	*e = append(*e, model.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e Errors) Has(field string) bool {
+	for _, fe := range e {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Struct checks s against its `validate` tags and returns every violation,
+// keyed by JSON field path.
+func Struct(s interface{}) Errors {
+	var errs Errors
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	validationErrs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		errs.Add("", "%s", err.Error())
+		return errs
+	}
+	for _, fe := range validationErrs {
+		errs.Add(fieldPath(fe), "%s", message(fe))
+	}
+	return errs
+}
+
+// fieldPath drops the root struct name from the namespace, turning
+// "Product.category.name" into "category.name".
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func message(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "max", "lte":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "min", "gte":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "reference":
+		return "must start with a letter or digit and contain only letters, digits, '-' and '_'"
+	case "sane_date":
+		return fmt.Sprintf("must be between %s and today", earliestDate.Format("2006-01-02"))
+	default:
+		return fmt.Sprintf("failed the %q rule", fe.Tag())
+	}
+}
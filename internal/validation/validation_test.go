@@ -0,0 +1,64 @@
+package validation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/thinhpq0112/soa-backend/internal/model"
+)
+
+func messages(errs Errors) map[string]string {
+	m := make(map[string]string, len(errs))
+	for _, fe := range errs {
+		m[fe.Field] = fe.Message
+	}
+	return m
+}
+
+func TestStructCollectsEveryViolation(t *testing.T) {
+	errs := Struct(model.Product{
+		Reference: "bad ref!",
+		Name:      "   ",
+		AddedDate: time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),
+		Status:    "Sold",
+		Price:     -1,
+		Quantity:  -5,
+	})
+
+	assert.Equal(t, map[string]string{
+		"reference":   "must start with a letter or digit and contain only letters, digits, '-' and '_'",
+		"name":        "must not be blank",
+		"added_date":  "must be between 1970-01-01 and today",
+		"status":      "must be one of Available, OutOfStock, Discontinued",
+		"category_id": "is required",
+		"supplier_id": "is required",
+		"price":       "must be at least 0",
+		"quantity":    "must be at least 0",
+	}, messages(errs))
+}
+
+func TestStructAcceptsValidProduct(t *testing.T) {
+	errs := Struct(model.Product{
+		Reference:  "REF-001",
+		Name:       "Desk lamp",
+		AddedDate:  time.Now(),
+		Status:     model.ProductStatusAvailable,
+		CategoryId: uuid.New(),
+		SupplierId: uuid.New(),
+		Price:      19.99,
+		Quantity:   3,
+	})
+	assert.Empty(t, errs)
+}
+
+func TestAddKeepsFirstMessagePerField(t *testing.T) {
+	var errs Errors
+	errs.Add("category_id", "is required")
+	errs.Add("category_id", "category does not exist")
+
+	assert.Len(t, errs, 1)
+	assert.True(t, errs.Has("category_id"))
+	assert.Equal(t, "is required", errs[0].Message)
+}
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS categories (
+    id   uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name varchar(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS suppliers (
+    id   uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name varchar(255) NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS products (
+    id          uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    reference   varchar(50)    NOT NULL UNIQUE,
+    name        varchar(255)   NOT NULL,
+    added_date  date           DEFAULT CURRENT_DATE,
+    status      varchar(50),
+    category_id uuid REFERENCES categories (id),
+    price       numeric(10, 2) DEFAULT 0,
+    stock_city  varchar(100),
+    supplier_id uuid REFERENCES suppliers (id),
+    quantity    int            DEFAULT 0
+);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_categories_name;
+DROP INDEX IF EXISTS idx_products_supplier_id;
+DROP INDEX IF EXISTS idx_products_category_id;
+DROP INDEX IF EXISTS idx_products_price;
+DROP INDEX IF EXISTS idx_products_stock_city;
+DROP INDEX IF EXISTS idx_products_status;
+DROP INDEX IF EXISTS idx_products_added_date;
@@ -0,0 +1,8 @@
+-- products.reference is already covered by the index behind its UNIQUE constraint.
+CREATE INDEX IF NOT EXISTS idx_products_added_date ON products (added_date);
+CREATE INDEX IF NOT EXISTS idx_products_status ON products (status);
+CREATE INDEX IF NOT EXISTS idx_products_stock_city ON products (stock_city);
+CREATE INDEX IF NOT EXISTS idx_products_price ON products (price);
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id);
+CREATE INDEX IF NOT EXISTS idx_products_supplier_id ON products (supplier_id);
+CREATE INDEX IF NOT EXISTS idx_categories_name ON categories (name);
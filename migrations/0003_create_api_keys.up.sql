@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name         varchar(255) NOT NULL,
+    prefix       varchar(16)  NOT NULL,
+    key_hash     char(64)     NOT NULL UNIQUE,
+    scopes       text[]       NOT NULL,
+    expires_at   timestamptz,
+    revoked_at   timestamptz,
+    last_used_at timestamptz,
+    usage_count  bigint       NOT NULL DEFAULT 0,
+    created_by   varchar(255),
+    created_at   timestamptz  NOT NULL DEFAULT now()
+);
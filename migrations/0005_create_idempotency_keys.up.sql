@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    owner        varchar(255) NOT NULL,
+    key          varchar(255) NOT NULL,
+    request_hash char(64)     NOT NULL,
+    status_code  int          NOT NULL DEFAULT 0,
+    content_type varchar(255),
+    body         bytea,
+    created_at   timestamptz  NOT NULL DEFAULT now(),
+    expires_at   timestamptz  NOT NULL,
+    PRIMARY KEY (owner, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,18 @@
+-- Soft-deleted rows are removed for good: the unique constraints cannot be
+-- restored while they hold duplicate references or names.
+DELETE FROM products WHERE deleted_at IS NOT NULL;
+DELETE FROM categories WHERE deleted_at IS NOT NULL;
+DELETE FROM suppliers WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_suppliers_deleted_at;
+DROP INDEX IF EXISTS idx_categories_deleted_at;
+DROP INDEX IF EXISTS idx_products_deleted_at;
+
+DROP INDEX IF EXISTS idx_suppliers_name_live;
+ALTER TABLE suppliers ADD CONSTRAINT suppliers_name_key UNIQUE (name);
+DROP INDEX IF EXISTS idx_products_reference_live;
+ALTER TABLE products ADD CONSTRAINT products_reference_key UNIQUE (reference);
+
+ALTER TABLE suppliers DROP COLUMN IF EXISTS deleted_by, DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE categories DROP COLUMN IF EXISTS deleted_by, DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE products DROP COLUMN IF EXISTS deleted_by, DROP COLUMN IF EXISTS deleted_at;
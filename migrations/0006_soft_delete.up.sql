@@ -0,0 +1,20 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_by varchar(255);
+ALTER TABLE categories
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_by varchar(255);
+ALTER TABLE suppliers
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz,
+    ADD COLUMN IF NOT EXISTS deleted_by varchar(255);
+
+-- A deleted product or supplier must not block reusing its reference or name.
+ALTER TABLE products DROP CONSTRAINT IF EXISTS products_reference_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_reference_live ON products (reference) WHERE deleted_at IS NULL;
+ALTER TABLE suppliers DROP CONSTRAINT IF EXISTS suppliers_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_suppliers_name_live ON suppliers (name) WHERE deleted_at IS NULL;
+
+-- The trash is small: only index deleted rows, for listing and purging.
+CREATE INDEX IF NOT EXISTS idx_products_deleted_at ON products (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_categories_deleted_at ON categories (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_suppliers_deleted_at ON suppliers (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_parent_not_self;
+DROP INDEX IF EXISTS idx_categories_parent_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,4 @@
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS parent_id uuid REFERENCES categories (id);
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+-- Cycles through several categories are prevented by the application.
+ALTER TABLE categories ADD CONSTRAINT categories_parent_not_self CHECK (parent_id <> id);
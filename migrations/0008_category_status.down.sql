@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_categories_status;
+ALTER TABLE categories DROP CONSTRAINT IF EXISTS categories_status_check;
+ALTER TABLE categories
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS status;
@@ -0,0 +1,23 @@
+ALTER TABLE categories
+    ADD COLUMN IF NOT EXISTS status     varchar(25),
+    ADD COLUMN IF NOT EXISTS created_at timestamptz,
+    ADD COLUMN IF NOT EXISTS updated_at timestamptz;
+
+-- Existing categories are active. Their creation time is unknown: the date
+-- their first product was added is the best estimate.
+UPDATE categories SET
+    status     = 'active',
+    created_at = COALESCE((SELECT MIN(products.added_date) FROM products WHERE products.category_id = categories.id), now()),
+    updated_at = now()
+WHERE status IS NULL;
+
+ALTER TABLE categories
+    ALTER COLUMN status SET DEFAULT 'active',
+    ALTER COLUMN status SET NOT NULL,
+    ALTER COLUMN created_at SET DEFAULT now(),
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN updated_at SET DEFAULT now(),
+    ALTER COLUMN updated_at SET NOT NULL,
+    ADD CONSTRAINT categories_status_check CHECK (status IN ('active', 'inactive', 'archived'));
+
+CREATE INDEX IF NOT EXISTS idx_categories_status ON categories (status);
@@ -0,0 +1,4 @@
+ALTER TABLE products
+    DROP CONSTRAINT IF EXISTS products_quantity_check,
+    ALTER COLUMN quantity DROP NOT NULL;
+DROP TABLE IF EXISTS inventory_movements;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS inventory_movements (
+    id             bigserial    PRIMARY KEY,
+    product_id     uuid         NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    kind           varchar(25)  NOT NULL CHECK (kind IN ('receipt', 'shipment', 'adjustment', 'return', 'transfer')),
+    quantity       int          NOT NULL CHECK (quantity <> 0),
+    quantity_after int          NOT NULL,
+    reason         varchar(255) NOT NULL,
+    reference      varchar(100),
+    actor          varchar(255) NOT NULL,
+    request_id     varchar(128),
+    occurred_at    timestamptz  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_movements_product ON inventory_movements (product_id, occurred_at);
+
+-- The ledger starts with the stock products have today, so that the
+-- quantity of a product is always the sum of its movements.
+UPDATE products SET quantity = 0 WHERE quantity IS NULL;
+INSERT INTO inventory_movements (product_id, kind, quantity, quantity_after, reason, actor)
+SELECT id, 'adjustment', quantity, quantity, 'opening balance', 'system'
+FROM products
+WHERE quantity <> 0;
+
+ALTER TABLE products
+    ALTER COLUMN quantity SET NOT NULL,
+    -- Existing rows are not checked: a negative quantity can only be fixed
+    -- by posting a movement.
+    ADD CONSTRAINT products_quantity_check CHECK (quantity >= 0) NOT VALID;
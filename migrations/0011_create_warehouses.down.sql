@@ -0,0 +1,18 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS stock_city varchar(100);
+
+-- A product goes back to the city of the warehouse holding most of its stock.
+UPDATE products p
+SET stock_city = (
+    SELECT w.city
+    FROM warehouse_stock s
+    JOIN warehouses w ON w.id = s.warehouse_id
+    WHERE s.product_id = p.id AND s.quantity > 0
+    ORDER BY s.quantity DESC, w.city
+    LIMIT 1
+);
+
+CREATE INDEX IF NOT EXISTS idx_products_stock_city ON products (stock_city);
+
+ALTER TABLE inventory_movements DROP COLUMN IF EXISTS warehouse_id;
+DROP TABLE IF EXISTS warehouse_stock;
+DROP TABLE IF EXISTS warehouses;
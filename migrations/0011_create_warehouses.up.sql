@@ -0,0 +1,54 @@
+CREATE TABLE IF NOT EXISTS warehouses (
+    id        uuid             PRIMARY KEY DEFAULT gen_random_uuid(),
+    name      varchar(255)     NOT NULL,
+    city      varchar(100)     NOT NULL,
+    latitude  double precision CHECK (latitude BETWEEN -90 AND 90),
+    longitude double precision CHECK (longitude BETWEEN -180 AND 180),
+    version   int              NOT NULL DEFAULT 1,
+    CHECK ((latitude IS NULL) = (longitude IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_name ON warehouses (name);
+CREATE INDEX IF NOT EXISTS idx_warehouses_city ON warehouses (city);
+
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+    product_id   uuid NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    warehouse_id uuid NOT NULL REFERENCES warehouses (id) ON DELETE CASCADE,
+    quantity     int  NOT NULL,
+    PRIMARY KEY (product_id, warehouse_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_warehouse_stock_warehouse ON warehouse_stock (warehouse_id);
+
+-- A warehouse is deleted only once it holds no stock; its movements stay in
+-- the ledger without it.
+ALTER TABLE inventory_movements
+    ADD COLUMN IF NOT EXISTS warehouse_id uuid REFERENCES warehouses (id) ON DELETE SET NULL;
+
+-- Every stock city becomes a warehouse holding the stock of its products.
+-- Products with stock but no city get a warehouse of their own to hold it.
+INSERT INTO warehouses (name, city)
+SELECT DISTINCT COALESCE(NULLIF(stock_city, ''), 'Unassigned'), COALESCE(stock_city, '')
+FROM products
+WHERE COALESCE(stock_city, '') <> ''
+   OR quantity <> 0
+   OR EXISTS (SELECT 1 FROM inventory_movements m WHERE m.product_id = products.id);
+
+INSERT INTO warehouse_stock (product_id, warehouse_id, quantity)
+SELECT p.id, w.id, p.quantity
+FROM products p
+JOIN warehouses w ON w.name = COALESCE(NULLIF(p.stock_city, ''), 'Unassigned')
+WHERE p.quantity <> 0;
+
+UPDATE inventory_movements m
+SET warehouse_id = w.id
+FROM products p
+JOIN warehouses w ON w.name = COALESCE(NULLIF(p.stock_city, ''), 'Unassigned')
+WHERE m.product_id = p.id;
+
+-- As for products, existing rows are not checked.
+ALTER TABLE warehouse_stock
+    ADD CONSTRAINT warehouse_stock_quantity_check CHECK (quantity >= 0) NOT VALID;
+
+DROP INDEX IF EXISTS idx_products_stock_city;
+ALTER TABLE products DROP COLUMN IF EXISTS stock_city;
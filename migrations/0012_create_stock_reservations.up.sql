@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id           uuid         PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id   uuid         NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    quantity     int          NOT NULL CHECK (quantity > 0),
+    status       varchar(25)  NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'confirmed', 'released', 'expired')),
+    reference    varchar(100),
+    actor        varchar(255) NOT NULL,
+    warehouse_id uuid         REFERENCES warehouses (id) ON DELETE SET NULL,
+    created_at   timestamptz  NOT NULL DEFAULT now(),
+    expires_at   timestamptz  NOT NULL,
+    closed_at    timestamptz
+);
+
+-- Active reservations are summed per product on every reservation and
+-- swept by expiry.
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_active_product ON stock_reservations (product_id) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_active_expiry ON stock_reservations (expires_at) WHERE status = 'active';
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS stock_alerts;
+
+ALTER TABLE categories
+    DROP COLUMN IF EXISTS default_reorder_quantity,
+    DROP COLUMN IF EXISTS default_reorder_point;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS reorder_quantity,
+    DROP COLUMN IF EXISTS reorder_point;
@@ -0,0 +1,28 @@
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS reorder_point    int CHECK (reorder_point >= 0),
+    ADD COLUMN IF NOT EXISTS reorder_quantity int CHECK (reorder_quantity > 0);
+
+ALTER TABLE categories
+    ADD COLUMN IF NOT EXISTS default_reorder_point    int CHECK (default_reorder_point >= 0),
+    ADD COLUMN IF NOT EXISTS default_reorder_quantity int CHECK (default_reorder_quantity > 0);
+
+CREATE TABLE IF NOT EXISTS stock_alerts (
+    id               bigserial    PRIMARY KEY,
+    product_id       uuid         NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    status           varchar(25)  NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'acknowledged', 'resolved')),
+    quantity         int          NOT NULL,
+    reorder_point    int          NOT NULL,
+    reorder_quantity int,
+    raised_at        timestamptz  NOT NULL DEFAULT now(),
+    acknowledged_at  timestamptz,
+    acknowledged_by  varchar(255),
+    resolved_at      timestamptz,
+    resolved_by      varchar(255),
+    recovered_at     timestamptz,
+    delivered_at     timestamptz
+);
+
+-- A product has at most one alert until its stock recovers, so that an
+-- alert fires once per drop below the reorder point.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_alerts_unrecovered ON stock_alerts (product_id) WHERE recovered_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_status ON stock_alerts (status, id);
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS product_prices (
+    id             bigserial     PRIMARY KEY,
+    product_id     uuid          NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    price          numeric(10,2) NOT NULL CHECK (price >= 0),
+    effective_from timestamptz   NOT NULL,
+    -- effective_to is exclusive; NULL means the price holds until a later
+    -- one is scheduled.
+    effective_to   timestamptz   CHECK (effective_to > effective_from),
+    actor          varchar(255)  NOT NULL,
+    created_at     timestamptz   NOT NULL DEFAULT now(),
+    UNIQUE (product_id, effective_from)
+);
+
+-- Every product starts its history with the price it has now.
+INSERT INTO product_prices (product_id, price, effective_from, actor)
+SELECT id, COALESCE(price, 0), COALESCE(added_date::timestamptz, now()), 'system'
+FROM products;